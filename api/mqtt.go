@@ -1,11 +1,26 @@
 package api
 
 import (
+	"time"
+
 	"github.com/beevik/ntp"
 )
 
 type NTPResponse struct {
 	Server string        `json:",omitempty"`
 	NTP    *ntp.Response `json:",omitempty"`
+	Header *NTPHeader    `json:",omitempty"`
 	Error  string        `json:",omitempty"`
 }
+
+// NTPHeader has the NTP header fields from the response in a more
+// readable form than ntp.Response, plus the server timestamps.
+type NTPHeader struct {
+	Precision      time.Duration
+	ReferenceID    string `json:",omitempty"`
+	RootDelay      time.Duration
+	RootDispersion time.Duration
+	ReferenceTime  time.Time
+	T2             time.Time `json:",omitzero"` // server receive time
+	T3             time.Time `json:",omitzero"` // server transmit time
+}
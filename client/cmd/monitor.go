@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log/slog"
 	"net/netip"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
@@ -22,8 +23,6 @@ import (
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
-	"go.ntppool.org/pingtrace/traceroute"
-
 	"go.ntppool.org/common/logger"
 	"go.ntppool.org/common/tracing"
 	"go.ntppool.org/common/version"
@@ -34,6 +33,8 @@ import (
 	"go.ntppool.org/monitor/client/localok"
 	"go.ntppool.org/monitor/client/metrics"
 	"go.ntppool.org/monitor/client/monitor"
+	"go.ntppool.org/monitor/client/scheduler"
+	"go.ntppool.org/monitor/client/spool"
 	apiv2 "go.ntppool.org/monitor/gen/monitor/v2"
 	apiv2connect "go.ntppool.org/monitor/gen/monitor/v2/monitorv2connect"
 
@@ -67,6 +68,13 @@ var ErrNoWork = errors.New("no work")
 type monitorCmd struct {
 	Once       bool `name:"once" help:"Only run once instead of forever"`
 	SanityOnly bool `name:"sanity-only" help:"Only run the local sanity check"`
+
+	Concurrency int           `name:"concurrency" env:"MONITOR_CONCURRENCY" default:"10" help:"Maximum number of servers checked at the same time (IPv4 and IPv6 combined)"`
+	QueryRate   float64       `name:"query-rate" env:"MONITOR_QUERY_RATE" default:"20" help:"Maximum NTP queries per second for all checks combined"`
+	Pacing      time.Duration `name:"pacing" env:"MONITOR_PACING" default:"100ms" help:"Time between starting each check in a batch"`
+
+	SpoolMaxAge  time.Duration `name:"spool-max-age" env:"MONITOR_SPOOL_MAX_AGE" default:"3h" help:"How long to keep results that couldn't be submitted (0 to disable the spool)"`
+	SpoolMaxSize int64         `name:"spool-max-size" env:"MONITOR_SPOOL_MAX_SIZE" default:"20971520" help:"Maximum size in bytes of the spooled results"`
 }
 
 func (cmd *monitorCmd) Run(ctx context.Context, cli *ClientCmd) error {
@@ -145,11 +153,25 @@ func (cmd *monitorCmd) Run(ctx context.Context, cli *ClientCmd) error {
 
 	mqconfigger := checkconfig.NewConfigger(nil)
 
+	// Shared by the v4/v6 monitor goroutines, the local checks and
+	// the MQTT checks to bound the agent's total NTP traffic.
+	sched := scheduler.New(scheduler.Config{
+		Concurrency: cmd.Concurrency,
+		QueryRate:   cmd.QueryRate,
+		Pacing:      cmd.Pacing,
+	})
+	ctx = scheduler.NewContext(ctx, sched)
+
 	// Shared across v4/v6 monitor goroutines and the MQTT client: when MQTT
 	// reports session takeover, monitor loops pause so we don't submit
 	// results while another client holds our session.
 	takeoverState := mqttcm.NewTakeoverState()
 
+	// Traceroutes run in the background, shared by the v4/v6
+	// monitor goroutines; wait for them before shutting down.
+	traces := newTracerouter(maxConcurrentTraceroutes)
+	defer traces.wait()
+
 	for ix, ipc := range []config.IPConfig{cli.Config.IPv4(), cli.Config.IPv6()} {
 		var ipVersion string
 		switch ix {
@@ -203,7 +225,12 @@ func (cmd *monitorCmd) Run(ctx context.Context, cli *ClientCmd) error {
 				}
 			}
 
-			err := cmd.runMonitor(ctx, ipc, api, mqconfigger, cli.Config, takeoverState)
+			resultSpool, err := cmd.openSpool(cli, ipVersion)
+			if err != nil {
+				ipLog.WarnContext(ctx, "could not open result spool", "err", err)
+			}
+
+			err = cmd.runMonitor(ctx, ipc, api, mqconfigger, cli.Config, takeoverState, resultSpool, traces)
 			ipLog.DebugContext(ctx, "monitor done", "err", err)
 			return err
 		})
@@ -223,7 +250,7 @@ func (cmd *monitorCmd) Run(ctx context.Context, cli *ClientCmd) error {
 			}
 		}
 		log.InfoContext(ctx, "starting mqtt client", "name", cli.Config.TLSName())
-		return runMQTTClient(ctx, cli, mqconfigger, takeoverState)
+		return runMQTTClient(ctx, cli, mqconfigger, takeoverState, sched)
 	})
 
 	err = g.Wait()
@@ -235,7 +262,7 @@ func (cmd *monitorCmd) Run(ctx context.Context, cli *ClientCmd) error {
 	return nil
 }
 
-func runMQTTClient(ctx context.Context, cli *ClientCmd, mqconfigger checkconfig.ConfigGetter, takeoverState *mqttcm.TakeoverState) error {
+func runMQTTClient(ctx context.Context, cli *ClientCmd, mqconfigger checkconfig.ConfigGetter, takeoverState *mqttcm.TakeoverState, sched *scheduler.Scheduler) error {
 	log := logger.FromContext(ctx)
 
 	var mq *autopaho.ConnectionManager
@@ -246,7 +273,7 @@ func runMQTTClient(ctx context.Context, cli *ClientCmd, mqconfigger checkconfig.
 		if mqcfg := mqconfigger.GetMQTTConfig(); mqcfg != nil && len(mqcfg.Host) > 0 {
 			log := log.WithGroup("mqtt")
 
-			mqc := monitor.NewMQClient(log, topics, mqconfigger)
+			mqc := monitor.NewMQClient(log, topics, mqconfigger, sched)
 			router := paho.NewStandardRouterWithDefault(func(m *paho.Publish) {
 				log.Debug("mqtt message (unhandled)", "topic", m.Topic, "payload", m.Payload)
 			})
@@ -297,7 +324,20 @@ func runMQTTClient(ctx context.Context, cli *ClientCmd, mqconfigger checkconfig.
 	return nil
 }
 
-func (cmd *monitorCmd) runMonitor(ctx context.Context, ipc config.IPConfig, api apiv2connect.MonitorServiceClient, mqconfigger checkconfig.ConfigUpdater, appConfig config.AppConfig, takeoverState *mqttcm.TakeoverState) error {
+// openSpool returns the result spool for the IP version, or nil
+// if spooling is disabled.
+func (cmd *monitorCmd) openSpool(cli *ClientCmd, ipVersion string) (*spool.Spool, error) {
+	if cmd.SpoolMaxAge <= 0 || cmd.Once || cmd.SanityOnly {
+		return nil, nil
+	}
+	return spool.New(spool.Config{
+		Dir:     filepath.Join(cli.StateDir, cli.DeployEnv.String(), "spool", ipVersion),
+		MaxAge:  cmd.SpoolMaxAge,
+		MaxSize: cmd.SpoolMaxSize,
+	})
+}
+
+func (cmd *monitorCmd) runMonitor(ctx context.Context, ipc config.IPConfig, api apiv2connect.MonitorServiceClient, mqconfigger checkconfig.ConfigUpdater, appConfig config.AppConfig, takeoverState *mqttcm.TakeoverState, resultSpool *spool.Spool, traces *tracerouter) error {
 	log := logger.FromContext(ctx).With("monitor_ip", ipc.IP.String())
 
 	log.InfoContext(ctx, "starting monitor")
@@ -450,7 +490,7 @@ runLoop:
 			batchCtx, span := tracing.Start(ctx, "monitor-run")
 			defer span.End()
 
-			if count, err := cmd.doMonitorBatch(batchCtx, ipc, api, monconf); count == 0 || err != nil {
+			if count, err := cmd.doMonitorBatch(batchCtx, ipc, api, monconf, localOK, resultSpool, traces); count == 0 || err != nil {
 				if err != nil {
 					span.RecordError(err)
 					span.SetStatus(codes.Error, err.Error())
@@ -532,9 +572,15 @@ func fetchConfig(ctx context.Context, ipc config.IPConfig, api apiv2connect.Moni
 	return cfgresp.Msg, nil
 }
 
-func (cmd *monitorCmd) doMonitorBatch(ctx context.Context, ipc config.IPConfig, api apiv2connect.MonitorServiceClient, cfgStore checkconfig.ConfigProvider) (int, error) {
+func (cmd *monitorCmd) doMonitorBatch(ctx context.Context, ipc config.IPConfig, api apiv2connect.MonitorServiceClient, cfgStore checkconfig.ConfigProvider, localOK *localok.LocalOK, resultSpool *spool.Spool, traces *tracerouter) (int, error) {
 	log := logger.FromContext(ctx)
 
+	if resultSpool != nil {
+		// submit older results first; if the API is still
+		// unreachable the new batch will likely fail as well
+		replaySpool(ctx, api, resultSpool)
+	}
+
 	serverresp, err := api.GetServers(ctx,
 		connect.NewRequest(
 			&apiv2.GetServersRequest{
@@ -591,68 +637,115 @@ func (cmd *monitorCmd) doMonitorBatch(ctx context.Context, ipc config.IPConfig,
 	statuses := []*apiv2.ServerStatus{}
 
 	wg := sync.WaitGroup{}
-
 	mu := sync.Mutex{}
 
-	for _, s := range serverlist.Servers {
+	sched := scheduler.FromContext(ctx)
+	started := 0
 
-		wg.Add(1)
+	for _, s := range serverlist.Servers {
 
-		go func(s *netip.Addr, trace bool, ticket []byte) {
-			if trace {
-				// todo: get psuedo lock from channel to manage parallel traceroutes
+		if s.Trace {
+			// submitted separately, the batch doesn't wait for it
+			if !traces.start(ctx, ipc, api, serverlist.BatchId, s.IP(), s.Ticket) {
+				log.DebugContext(ctx, "skipping traceroute, too many running", "ip", s.IP().String())
+			}
+			continue
+		}
 
-				tr, err := traceroute.New(*s)
-				if err != nil {
-					log.Error("traceroute", "err", err)
-				}
-				if err := tr.Start(ctx); err != nil {
-					log.Error("traceroute start failed", "err", err)
-				}
-				x, err := tr.ReadAll()
-				if err != nil {
-					log.Error("traceroute", "err", err)
-				}
+		if started > 0 {
+			if err := sched.Pace(ctx); err != nil {
+				break
+			}
+		}
+		release, err := sched.Acquire(ctx)
+		if err != nil {
+			break
+		}
+		started++
 
-				log.Info("traceroute", "output", x)
+		wg.Add(1)
 
-				wg.Done()
-				return
-			}
+		go func(s *netip.Addr, ticket []byte) {
+			defer wg.Done()
+			defer release()
 
 			status, _, err := monitor.CheckHost(ctx, s, cfgStore.GetConfig())
-			if status == nil {
-				status = &apiv2.ServerStatus{
-					NoResponse: true,
-				}
-				status.SetIP(s)
-			}
-			status.Ticket = ticket
 			if err != nil {
 				log.Info("ntp error", "server", s, "err", err)
-				if strings.HasPrefix(err.Error(), "network:") {
-					status.NoResponse = true
-				}
-				status.Error = err.Error()
 			}
-			status.Ts = timestamppb.Now()
+			status = checkResult(s, ticket, status, err)
+			if status == nil {
+				return
+			}
 
 			mu.Lock()
 			defer mu.Unlock()
 			statuses = append(statuses, status)
-			wg.Done()
-		}(s.IP(), s.Trace, s.Ticket)
+		}(s.IP(), s.Ticket)
 	}
 
 	wg.Wait()
 
 	list := &apiv2.SubmitResultsRequest{
 		MonId:   ipc.IP.String(),
-		Version: 4,
+		Version: 5,
 		List:    statuses,
 		BatchId: serverlist.BatchId,
+		Clock:   localOK.ClockQuality(),
+	}
+
+	if err := submitResults(ctx, api, list); err != nil {
+		if resultSpool != nil && shouldSpool(ctx, err) {
+			if serr := resultSpool.Add(list); serr != nil {
+				log.WarnContext(ctx, "could not spool results", "err", serr)
+			} else {
+				log.InfoContext(ctx, "spooled results for later submission", "count", len(statuses))
+				recordSpool(ctx, "spooled")
+			}
+		}
+		return 0, err
 	}
 
+	return len(statuses), nil
+}
+
+// checkResult returns the status to submit for the check of ip, or
+// nil if the check was aborted because the context was cancelled
+// (the agent is shutting down); that says nothing about the server.
+func checkResult(ip *netip.Addr, ticket []byte, status *apiv2.ServerStatus, err error) *apiv2.ServerStatus {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return nil
+	}
+	if status == nil {
+		status = &apiv2.ServerStatus{
+			NoResponse: true,
+		}
+		status.SetIP(ip)
+	}
+	status.Ticket = ticket
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "network:") {
+			status.NoResponse = true
+		}
+		status.Error = err.Error()
+	}
+	status.Ts = timestamppb.Now()
+	return status
+}
+
+// shouldSpool returns true if a batch that failed with err can be
+// submitted again later. Batches the API refused aren't, and neither
+// are batches that failed because ctx was cancelled, as the checks
+// in them might have been cut short.
+func shouldSpool(ctx context.Context, err error) bool {
+	return !errors.Is(err, spool.ErrRejected) && ctx.Err() == nil
+}
+
+// submitResults sends the results to the API. If the server
+// refused the batch the error wraps spool.ErrRejected; other
+// errors (including internal errors on the server) are temporary
+// and the batch can be submitted again later.
+func submitResults(ctx context.Context, api apiv2connect.MonitorServiceClient, list *apiv2.SubmitResultsRequest) error {
 	r, err := api.SubmitResults(ctx, connect.NewRequest(list))
 
 	// Record RPC request metric
@@ -668,13 +761,84 @@ func (cmd *monitorCmd) doMonitorBatch(ctx context.Context, ipc config.IPConfig,
 	}
 
 	if err != nil {
-		return 0, fmt.Errorf("SubmitResults: %s", err)
+		switch connect.CodeOf(err) {
+		case connect.CodeInvalidArgument, connect.CodePermissionDenied,
+			connect.CodeUnauthenticated, connect.CodeFailedPrecondition:
+			return fmt.Errorf("%w: SubmitResults: %s", spool.ErrRejected, err)
+		}
+		return fmt.Errorf("SubmitResults: %w", err)
 	}
 	if !r.Msg.Ok {
-		return 0, fmt.Errorf("SubmitResults not okay")
+		return fmt.Errorf("%w: SubmitResults not okay", spool.ErrRejected)
 	}
 
-	return len(statuses), nil
+	recordResults(ctx, list, r.Msg.Results)
+
+	return nil
+}
+
+// recordResults logs the results the server didn't accept and
+// counts the results by status. Servers before v4.2 don't return
+// the result status.
+func recordResults(ctx context.Context, list *apiv2.SubmitResultsRequest, results []apiv2.ResultStatus) {
+	log := logger.FromContext(ctx)
+
+	if len(results) > 0 && len(results) != len(list.List) {
+		log.WarnContext(ctx, "unexpected number of result statuses", "results", len(list.List), "statuses", len(results))
+		return
+	}
+
+	for i, rs := range results {
+		status := strings.ToLower(strings.TrimPrefix(rs.String(), "RESULT_STATUS_"))
+
+		switch rs {
+		case apiv2.ResultStatus_RESULT_STATUS_ACCEPTED:
+		case apiv2.ResultStatus_RESULT_STATUS_QUARANTINED:
+			// the server thinks our network was down
+			log.InfoContext(ctx, "result quarantined", "server", list.List[i].GetIP())
+		default:
+			log.WarnContext(ctx, "result not accepted", "server", list.List[i].GetIP(), "status", status)
+		}
+
+		if metrics.ResultsSubmitted != nil {
+			metrics.ResultsSubmitted.Add(ctx, 1,
+				metric.WithAttributes(attribute.String("status", status)))
+		}
+	}
+}
+
+// replaySpool submits the batches that couldn't be submitted
+// earlier, until the spool is empty or the API is unreachable.
+func replaySpool(ctx context.Context, api apiv2connect.MonitorServiceClient, resultSpool *spool.Spool) {
+	log := logger.FromContext(ctx)
+
+	count, err := resultSpool.Replay(ctx, func(ctx context.Context, list *apiv2.SubmitResultsRequest) error {
+		err := submitResults(ctx, api, list)
+		switch {
+		case err == nil:
+			recordSpool(ctx, "replayed")
+		case errors.Is(err, spool.ErrRejected):
+			recordSpool(ctx, "rejected")
+		}
+		return err
+	})
+	if count > 0 {
+		log.InfoContext(ctx, "submitted spooled results", "batches", count)
+	}
+	if err != nil {
+		if errors.Is(err, spool.ErrRejected) {
+			log.WarnContext(ctx, "spooled results rejected", "err", err)
+		} else {
+			log.InfoContext(ctx, "could not submit spooled results", "remaining", resultSpool.Len(), "err", err)
+		}
+	}
+}
+
+func recordSpool(ctx context.Context, action string) {
+	if metrics.SpoolBatches != nil {
+		metrics.SpoolBatches.Add(ctx, 1,
+			metric.WithAttributes(attribute.String("action", action)))
+	}
 }
 
 func (cmd *monitorCmd) waitForProtocolActivation(ctx context.Context, ipc config.IPConfig, appConfig config.AppConfig, log *slog.Logger) bool {
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.ntppool.org/monitor/client/spool"
+	apiv2 "go.ntppool.org/monitor/gen/monitor/v2"
+	apiv2connect "go.ntppool.org/monitor/gen/monitor/v2/monitorv2connect"
+)
+
+// submitAPI fails SubmitResults with err
+type submitAPI struct {
+	apiv2connect.MonitorServiceClient
+	err error
+}
+
+func (api *submitAPI) SubmitResults(ctx context.Context, req *connect.Request[apiv2.SubmitResultsRequest]) (*connect.Response[apiv2.SubmitResultsResponse], error) {
+	if api.err != nil {
+		return nil, api.err
+	}
+	return connect.NewResponse(&apiv2.SubmitResultsResponse{Ok: true}), nil
+}
+
+func TestSubmitResultsRejected(t *testing.T) {
+	ctx := context.Background()
+	list := &apiv2.SubmitResultsRequest{}
+
+	tests := []struct {
+		code     connect.Code
+		rejected bool
+	}{
+		{connect.CodeInvalidArgument, true},
+		{connect.CodePermissionDenied, true},
+		{connect.CodeUnauthenticated, true},
+		{connect.CodeFailedPrecondition, true},
+
+		// database errors on the server are internal errors; the
+		// batch should be spooled and submitted again
+		{connect.CodeInternal, false},
+		{connect.CodeUnknown, false},
+		{connect.CodeUnavailable, false},
+		{connect.CodeDeadlineExceeded, false},
+		{connect.CodeAborted, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code.String(), func(t *testing.T) {
+			api := &submitAPI{err: connect.NewError(tt.code, errors.New("failed"))}
+			err := submitResults(ctx, api, list)
+			assert.Error(t, err)
+			assert.Equal(t, tt.rejected, errors.Is(err, spool.ErrRejected))
+		})
+	}
+
+	assert.NoError(t, submitResults(ctx, &submitAPI{}, list))
+}
+
+func TestCheckResult(t *testing.T) {
+	ip := netip.MustParseAddr("198.51.100.10")
+
+	// checks aborted by the shutdown aren't submitted
+	assert.Nil(t, checkResult(&ip, nil, nil, context.Canceled))
+	assert.Nil(t, checkResult(&ip, nil, nil, fmt.Errorf("waiting for query: %w", context.DeadlineExceeded)))
+
+	status := checkResult(&ip, []byte("ticket"), nil, errors.New("network: i/o timeout"))
+	require.NotNil(t, status)
+	assert.True(t, status.NoResponse)
+	assert.Equal(t, "network: i/o timeout", status.Error)
+	assert.Equal(t, []byte("ticket"), status.Ticket)
+	assert.Equal(t, ip, *status.GetIP())
+	assert.NotNil(t, status.Ts)
+
+	status = checkResult(&ip, nil, &apiv2.ServerStatus{Stratum: 2}, nil)
+	require.NotNil(t, status)
+	assert.False(t, status.NoResponse)
+	assert.Empty(t, status.Error)
+}
+
+func TestShouldSpool(t *testing.T) {
+	ctx := context.Background()
+	unavailable := connect.NewError(connect.CodeUnavailable, errors.New("unavailable"))
+
+	assert.True(t, shouldSpool(ctx, unavailable))
+	assert.False(t, shouldSpool(ctx, fmt.Errorf("%w: invalid", spool.ErrRejected)))
+
+	// the submit failed because the agent is shutting down
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.False(t, shouldSpool(cancelled, connect.NewError(connect.CodeCanceled, context.Canceled)))
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"sync"
+	"time"
+
+	"connectrpc.com/connect"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"go.ntppool.org/pingtrace/traceroute"
+
+	"go.ntppool.org/common/logger"
+	"go.ntppool.org/common/tracing"
+
+	"go.ntppool.org/monitor/client/config"
+	"go.ntppool.org/monitor/client/metrics"
+	apiv2 "go.ntppool.org/monitor/gen/monitor/v2"
+	apiv2connect "go.ntppool.org/monitor/gen/monitor/v2/monitorv2connect"
+)
+
+const (
+	// maxConcurrentTraceroutes limits how many traceroutes run at
+	// the same time, shared between the IPv4 and IPv6 monitors.
+	maxConcurrentTraceroutes = 2
+
+	// tracerouteTimeout is how long a traceroute, including
+	// submitting the output, can take
+	tracerouteTimeout = 2 * time.Minute
+)
+
+// tracerouter runs the traceroutes requested with a batch in the
+// background so the batch results don't wait for them.
+type tracerouter struct {
+	sem chan struct{}
+	wg  sync.WaitGroup
+
+	// read runs the traceroute; readTraceroute except in tests
+	read func(ctx context.Context, ip *netip.Addr) ([]byte, error)
+}
+
+func newTracerouter(limit int) *tracerouter {
+	return &tracerouter{
+		sem:  make(chan struct{}, limit),
+		read: readTraceroute,
+	}
+}
+
+// start runs a traceroute to the server and submits the output in
+// the background. If the maximum number of traceroutes is already
+// running the traceroute is skipped; the API sends it again later.
+func (t *tracerouter) start(ctx context.Context, ipc config.IPConfig, api apiv2connect.MonitorServiceClient, batchID []byte, ip *netip.Addr, ticket []byte) bool {
+	select {
+	case t.sem <- struct{}{}:
+	default:
+		return false
+	}
+
+	t.wg.Go(func() {
+		defer func() { <-t.sem }()
+
+		ctx, cancel := context.WithTimeout(ctx, tracerouteTimeout)
+		defer cancel()
+
+		if err := t.run(ctx, ipc, api, batchID, ip, ticket); err != nil {
+			logger.FromContext(ctx).ErrorContext(ctx, "traceroute", "ip", ip.String(), "err", err)
+		}
+	})
+
+	return true
+}
+
+// wait returns when the running traceroutes are done
+func (t *tracerouter) wait() {
+	t.wg.Wait()
+}
+
+// run runs a traceroute to the server and submits the output (or
+// the error) to the API.
+func (t *tracerouter) run(ctx context.Context, ipc config.IPConfig, api apiv2connect.MonitorServiceClient, batchID []byte, ip *netip.Addr, ticket []byte) error {
+	ctx, span := tracing.Start(ctx, "traceroute")
+	defer span.End()
+	span.SetAttributes(attribute.String("ip", ip.String()))
+
+	log := logger.FromContext(ctx).With("ip", ip.String())
+
+	req := &apiv2.SubmitTracerouteRequest{
+		MonId:   ipc.IP.String(),
+		BatchId: batchID,
+		Ticket:  ticket,
+		Ts:      timestamppb.Now(),
+	}
+	req.IpBytes, _ = ip.MarshalBinary()
+
+	output, err := t.read(ctx, ip)
+	if err != nil {
+		log.WarnContext(ctx, "traceroute", "err", err)
+		req.Error = err.Error()
+	}
+	req.Output = output
+
+	log.DebugContext(ctx, "traceroute", "output", string(output))
+
+	r, err := api.SubmitTraceroute(ctx, connect.NewRequest(req))
+
+	if metrics.RPCRequests != nil {
+		statusCode := "success"
+		if err != nil || (r != nil && !r.Msg.Ok) {
+			statusCode = "error"
+		}
+		metrics.RPCRequests.Add(ctx, 1,
+			metric.WithAttributes(
+				attribute.String("type", "submitTraceroute"),
+				attribute.String("status_code", statusCode)))
+	}
+
+	if err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("SubmitTraceroute: %s", err)
+	}
+	if !r.Msg.Ok {
+		return fmt.Errorf("SubmitTraceroute not okay")
+	}
+
+	return nil
+}
+
+func readTraceroute(ctx context.Context, ip *netip.Addr) ([]byte, error) {
+	tr, err := traceroute.New(*ip)
+	if err != nil {
+		return nil, err
+	}
+	if err := tr.Start(ctx); err != nil {
+		return nil, fmt.Errorf("traceroute start: %w", err)
+	}
+	return tr.ReadAll()
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"sync"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.ntppool.org/monitor/client/config"
+	apiv2 "go.ntppool.org/monitor/gen/monitor/v2"
+	apiv2connect "go.ntppool.org/monitor/gen/monitor/v2/monitorv2connect"
+)
+
+// tracerouteAPI records the submitted traceroutes
+type tracerouteAPI struct {
+	apiv2connect.MonitorServiceClient
+
+	mu   sync.Mutex
+	reqs []*apiv2.SubmitTracerouteRequest
+}
+
+func (api *tracerouteAPI) SubmitTraceroute(ctx context.Context, req *connect.Request[apiv2.SubmitTracerouteRequest]) (*connect.Response[apiv2.SubmitTracerouteResponse], error) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.reqs = append(api.reqs, req.Msg)
+	return connect.NewResponse(&apiv2.SubmitTracerouteResponse{Ok: true}), nil
+}
+
+func TestTracerouterBackground(t *testing.T) {
+	ctx := context.Background()
+	monIP := netip.MustParseAddr("192.0.2.1")
+	ipc := config.IPConfig{IP: &monIP}
+	api := &tracerouteAPI{}
+
+	release := make(chan struct{})
+	tr := newTracerouter(2)
+	tr.read = func(ctx context.Context, ip *netip.Addr) ([]byte, error) {
+		<-release
+		if ip.Is6() {
+			return nil, errors.New("no route")
+		}
+		return []byte("1 192.0.2.254"), nil
+	}
+
+	ip4 := netip.MustParseAddr("198.51.100.1")
+	ip6 := netip.MustParseAddr("2001:db8::1")
+	ip4b := netip.MustParseAddr("198.51.100.2")
+
+	// start doesn't wait for the traceroutes
+	assert.True(t, tr.start(ctx, ipc, api, []byte("batch"), &ip4, []byte("t1")))
+	assert.True(t, tr.start(ctx, ipc, api, []byte("batch"), &ip6, []byte("t2")))
+
+	// over the limit the traceroute is skipped instead of queued
+	assert.False(t, tr.start(ctx, ipc, api, []byte("batch"), &ip4b, []byte("t3")))
+
+	close(release)
+	tr.wait()
+
+	require.Len(t, api.reqs, 2)
+	for _, req := range api.reqs {
+		assert.Equal(t, "192.0.2.1", req.MonId)
+		assert.Equal(t, []byte("batch"), req.BatchId)
+		ip, _ := netip.AddrFromSlice(req.IpBytes)
+		if ip.Is6() {
+			assert.Equal(t, "no route", req.Error)
+			assert.Empty(t, req.Output)
+		} else {
+			assert.Empty(t, req.Error)
+			assert.Equal(t, []byte("1 192.0.2.254"), req.Output)
+		}
+	}
+
+	// the slots are free again
+	assert.True(t, tr.start(ctx, ipc, api, []byte("batch"), &ip4b, []byte("t3")))
+	tr.wait()
+	assert.Len(t, api.reqs, 3)
+}
+
+func TestTracerouterTimeout(t *testing.T) {
+	monIP := netip.MustParseAddr("192.0.2.1")
+	api := &tracerouteAPI{}
+
+	// a cancelled agent doesn't wait for the traceroute timeout
+	ctx, cancel := context.WithCancel(context.Background())
+	tr := newTracerouter(1)
+	tr.read = func(ctx context.Context, ip *netip.Addr) ([]byte, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	ip := netip.MustParseAddr("198.51.100.1")
+	require.True(t, tr.start(ctx, config.IPConfig{IP: &monIP}, api, []byte("batch"), &ip, nil))
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		tr.wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("traceroute wasn't cancelled")
+	}
+}
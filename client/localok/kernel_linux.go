@@ -0,0 +1,33 @@
+package localok
+
+import (
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	apiv2 "go.ntppool.org/monitor/gen/monitor/v2"
+)
+
+const (
+	staUnsync = 0x0040 // STA_UNSYNC in linux/timex.h
+	timeError = 5      // TIME_ERROR, the clock is not synchronized
+)
+
+// kernelClock returns the kernel's clock synchronization state and
+// error estimates.
+func kernelClock() (apiv2.KernelSyncState, time.Duration, time.Duration) {
+	tx := unix.Timex{}
+	state, err := unix.Adjtimex(&tx)
+	if err != nil {
+		return apiv2.KernelSyncState_KERNEL_SYNC_STATE_UNSPECIFIED, 0, 0
+	}
+
+	sync := apiv2.KernelSyncState_KERNEL_SYNC_STATE_SYNCHRONIZED
+	if state == timeError || tx.Status&staUnsync != 0 {
+		sync = apiv2.KernelSyncState_KERNEL_SYNC_STATE_UNSYNCHRONIZED
+	}
+
+	return sync,
+		time.Duration(int64(tx.Maxerror)) * time.Microsecond,
+		time.Duration(int64(tx.Esterror)) * time.Microsecond
+}
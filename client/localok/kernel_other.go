@@ -0,0 +1,14 @@
+//go:build !linux
+
+package localok
+
+import (
+	"time"
+
+	apiv2 "go.ntppool.org/monitor/gen/monitor/v2"
+)
+
+// kernelClock isn't implemented outside Linux
+func kernelClock() (apiv2.KernelSyncState, time.Duration, time.Duration) {
+	return apiv2.KernelSyncState_KERNEL_SYNC_STATE_UNSPECIFIED, 0, 0
+}
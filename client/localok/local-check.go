@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net"
 	"net/netip"
+	"sort"
 	"sync"
 	"time"
 
@@ -14,12 +15,15 @@ import (
 	"go.opentelemetry.io/otel/trace"
 	"go4.org/netipx"
 	"golang.org/x/sync/errgroup"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"go.ntppool.org/common/logger"
 	"go.ntppool.org/common/tracing"
 	"go.ntppool.org/monitor/client/config/checkconfig"
 	"go.ntppool.org/monitor/client/metrics"
 	"go.ntppool.org/monitor/client/monitor"
+	apiv2 "go.ntppool.org/monitor/gen/monitor/v2"
 )
 
 type LocalOK struct {
@@ -27,6 +31,7 @@ type LocalOK struct {
 	isv4       bool
 	lastCheck  time.Time
 	lastStatus bool
+	quality    *apiv2.ClockQuality
 	seenHosts  map[string]bool
 	mu         sync.RWMutex
 }
@@ -34,6 +39,7 @@ type LocalOK struct {
 type hostResult struct {
 	name      string
 	ok        bool
+	offset    *time.Duration // nil if there was no response
 	checkTime time.Time
 }
 
@@ -101,6 +107,38 @@ func (l *LocalOK) Check(ctx context.Context) bool {
 	return ok
 }
 
+// ClockQuality returns the local clock estimate from the last
+// check with the current kernel synchronization state, or nil if
+// no BaseCheck has responded yet.
+func (l *LocalOK) ClockQuality() *apiv2.ClockQuality {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if l.quality == nil {
+		return nil
+	}
+
+	cq := &apiv2.ClockQuality{
+		Ts:         l.quality.Ts,
+		Offset:     l.quality.Offset,
+		Dispersion: l.quality.Dispersion,
+		Samples:    l.quality.Samples,
+	}
+
+	sync, maxError, estError := kernelClock()
+	cq.KernelSync = sync
+	if sync != apiv2.KernelSyncState_KERNEL_SYNC_STATE_UNSPECIFIED {
+		cq.KernelMaxError = durationpb.New(maxError)
+		cq.KernelEstError = durationpb.New(estError)
+	}
+
+	return cq
+}
+
 func (l *LocalOK) update(ctx context.Context) bool {
 	ctx, span := tracing.Start(ctx,
 		"localcheck-update",
@@ -178,6 +216,8 @@ func (l *LocalOK) update(ctx context.Context) bool {
 
 	results := make(chan bool)
 	hostResults := make(chan hostResult)
+	var allHostResults []hostResult
+	checkStart := time.Now()
 
 	g, _ := errgroup.WithContext(ctx)
 
@@ -191,7 +231,6 @@ func (l *LocalOK) update(ctx context.Context) bool {
 	})
 
 	g.Go(func() error {
-		var allHostResults []hostResult
 		for hr := range hostResults {
 			allHostResults = append(allHostResults, hr)
 		}
@@ -234,7 +273,7 @@ func (l *LocalOK) update(ctx context.Context) bool {
 
 			go func(h namedIP) {
 				checkTime := time.Now()
-				ok, err := l.sanityCheckHost(ctx, cfg, h.Name, h.IP)
+				ok, offset, err := l.sanityCheckHost(ctx, cfg, h.Name, h.IP)
 				if err != nil {
 					log.WarnContext(ctx, "local-check failure", "server", h.Name, "ip", h.IP.String(), "err", err.Error())
 					span.RecordError(err)
@@ -244,6 +283,7 @@ func (l *LocalOK) update(ctx context.Context) bool {
 				hostResults <- hostResult{
 					name:      h.Name,
 					ok:        ok,
+					offset:    offset,
 					checkTime: checkTime,
 				}
 				wg.Done()
@@ -264,6 +304,8 @@ func (l *LocalOK) update(ctx context.Context) bool {
 		return false
 	}
 
+	l.quality = clockQuality(checkStart, allHostResults)
+
 	failureThreshold := len(hosts) - ((len(hosts) + 2) / 2)
 	log.InfoContext(ctx, "local-check", "failures", fails, "threshold", failureThreshold, "hosts", len(hosts))
 
@@ -282,23 +324,56 @@ func (l *LocalOK) update(ctx context.Context) bool {
 	return ok
 }
 
-func (l *LocalOK) sanityCheckHost(ctx context.Context, cfg *checkconfig.Config, name string, ip *netip.Addr) (bool, error) {
+// sanityCheckHost checks the local clock against the host; the
+// offset is returned if the host responded.
+func (l *LocalOK) sanityCheckHost(ctx context.Context, cfg *checkconfig.Config, name string, ip *netip.Addr) (bool, *time.Duration, error) {
 	status, _, err := monitor.CheckHost(ctx, ip, cfg, attribute.String("name", name))
 	if err != nil {
-		return false, err
+		return false, nil, err
+	}
+
+	if status.Leap == 3 {
+		return false, nil, fmt.Errorf("NotInSync")
 	}
 
+	signedOffset := status.Offset.AsDuration()
 	offset := status.AbsoluteOffset()
 
 	// log.Printf("offset for %s (%s): %s", name, ip, status.Offset.AsDuration())
 
 	if *offset > maxOffset || *offset < maxOffset*-1 {
-		return false, fmt.Errorf("offset too large: %s", status.Offset.AsDuration().String())
+		return false, &signedOffset, fmt.Errorf("offset too large: %s", status.Offset.AsDuration().String())
 	}
 
-	if status.Leap == 3 {
-		return false, fmt.Errorf("NotInSync")
+	return true, &signedOffset, nil
+}
+
+// clockQuality reduces the BaseCheck offsets to the median and the
+// largest difference from it. It returns nil if no host responded.
+func clockQuality(ts time.Time, results []hostResult) *apiv2.ClockQuality {
+	offsets := []time.Duration{}
+	for _, hr := range results {
+		if hr.offset != nil {
+			offsets = append(offsets, *hr.offset)
+		}
+	}
+	if len(offsets) == 0 {
+		return nil
 	}
 
-	return true, nil
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+
+	median := offsets[len(offsets)/2]
+	if len(offsets)%2 == 0 {
+		median = (offsets[len(offsets)/2-1] + median) / 2
+	}
+
+	dispersion := max(offsets[len(offsets)-1]-median, median-offsets[0])
+
+	return &apiv2.ClockQuality{
+		Ts:         timestamppb.New(ts),
+		Offset:     durationpb.New(median),
+		Dispersion: durationpb.New(dispersion),
+		Samples:    int32(len(offsets)),
+	}
 }
@@ -0,0 +1,37 @@
+package localok
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClockQuality(t *testing.T) {
+	if cq := clockQuality(time.Now(), []hostResult{{name: "a"}}); cq != nil {
+		t.Errorf("expected nil without responses, got %v", cq)
+	}
+
+	d := func(ms float64) *time.Duration {
+		v := time.Duration(ms * float64(time.Millisecond))
+		return &v
+	}
+
+	cq := clockQuality(time.Now(), []hostResult{
+		{name: "a", offset: d(1)},
+		{name: "b", offset: d(-2)},
+		{name: "c"},
+		{name: "d", offset: d(3)},
+		{name: "e", offset: d(2)},
+	})
+	if cq == nil {
+		t.Fatal("expected a clock quality")
+	}
+	if cq.Samples != 4 {
+		t.Errorf("samples = %d, want 4", cq.Samples)
+	}
+	if got := cq.Offset.AsDuration(); got != 1500*time.Microsecond {
+		t.Errorf("offset = %s, want 1.5ms", got)
+	}
+	if got := cq.Dispersion.AsDuration(); got != 3500*time.Microsecond {
+		t.Errorf("dispersion = %s, want 3.5ms", got)
+	}
+}
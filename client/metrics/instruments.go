@@ -15,9 +15,11 @@ var (
 	LocalCheckTime metric.Int64Gauge
 
 	// New counters
-	ServersChecked metric.Int64Counter
-	NTPQueriesSent metric.Int64Counter
-	RPCRequests    metric.Int64Counter
+	ServersChecked   metric.Int64Counter
+	NTPQueriesSent   metric.Int64Counter
+	RPCRequests      metric.Int64Counter
+	SpoolBatches     metric.Int64Counter
+	ResultsSubmitted metric.Int64Counter
 
 	setupOnce sync.Once
 	setupErr  error
@@ -79,6 +81,20 @@ func initializeInstruments() error {
 		return err
 	}
 
+	SpoolBatches, err = meter.Int64Counter("monitor.spool_batches_total",
+		metric.WithDescription("Result batches spooled, replayed or rejected on replay"))
+	if err != nil {
+		log.ErrorContext(context.Background(), "failed to create SpoolBatches counter", "err", err)
+		return err
+	}
+
+	ResultsSubmitted, err = meter.Int64Counter("monitor.results_submitted_total",
+		metric.WithDescription("Results submitted by the status the server returned"))
+	if err != nil {
+		log.ErrorContext(context.Background(), "failed to create ResultsSubmitted counter", "err", err)
+		return err
+	}
+
 	log.Info("client metrics instruments initialized successfully")
 	return nil
 }
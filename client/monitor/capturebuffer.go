@@ -3,13 +3,20 @@ package monitor
 import (
 	"bytes"
 	"net/netip"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	monitorv2 "go.ntppool.org/monitor/gen/monitor/v2"
 )
 
+// CaptureBuffer is an ntp.Extension that records the query and
+// response packets for each exchange. Use GetSystemTime as the
+// QueryOptions.GetSystemTime callback to also record t1 and t4.
 type CaptureBuffer struct {
 	dest, src *netip.Addr
-	responses []*monitorv2.NTPPacket
+	current   *monitorv2.NTPPacket
+	packets   []*monitorv2.NTPPacket
 }
 
 func NewCaptureBuffer(dest, src *netip.Addr) *CaptureBuffer {
@@ -20,20 +27,53 @@ func NewCaptureBuffer(dest, src *netip.Addr) *CaptureBuffer {
 }
 
 func (cb *CaptureBuffer) ProcessQuery(buf *bytes.Buffer) error {
+	pkt := &monitorv2.NTPPacket{
+		QueryData: bytes.Clone(buf.Bytes()),
+	}
+	if cb.src != nil && cb.src.IsValid() {
+		pkt.SourceIpBytes = cb.src.AsSlice()
+	}
+	if cb.dest != nil && cb.dest.IsValid() {
+		pkt.DestinationIpBytes = cb.dest.AsSlice()
+	}
+	cb.current = pkt
+	cb.packets = append(cb.packets, pkt)
 	return nil
 }
 
 func (cb *CaptureBuffer) ProcessResponse(buf []byte) error {
-	pkt := monitorv2.NTPPacket{}
-	pkt.Data = buf
-	cb.responses = append(cb.responses, &pkt)
+	if cb.current == nil {
+		// response without a query we saw; keep it anyway
+		cb.current = &monitorv2.NTPPacket{}
+		cb.packets = append(cb.packets, cb.current)
+	}
+	cb.current.Data = bytes.Clone(buf)
 	return nil
 }
 
-func (cb *CaptureBuffer) ResponsePackets() []*monitorv2.NTPPacket {
-	return cb.responses
+// GetSystemTime returns the current time. The ntp library calls it
+// right before sending the query and right after the response has
+// been received, so the first call for an exchange is t1 and the
+// second is t4.
+func (cb *CaptureBuffer) GetSystemTime() time.Time {
+	now := time.Now()
+	if cb.current != nil {
+		switch {
+		case cb.current.T1 == nil:
+			cb.current.T1 = timestamppb.New(now)
+		case cb.current.T4 == nil:
+			cb.current.T4 = timestamppb.New(now)
+		}
+	}
+	return now
+}
+
+// Packets returns the exchanges captured since the last Clear
+func (cb *CaptureBuffer) Packets() []*monitorv2.NTPPacket {
+	return cb.packets
 }
 
 func (cb *CaptureBuffer) Clear() {
-	cb.responses = []*monitorv2.NTPPacket{}
+	cb.current = nil
+	cb.packets = []*monitorv2.NTPPacket{}
 }
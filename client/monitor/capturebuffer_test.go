@@ -0,0 +1,83 @@
+package monitor
+
+import (
+	"bytes"
+	"net/netip"
+	"testing"
+
+	apiv2 "go.ntppool.org/monitor/gen/monitor/v2"
+)
+
+func TestCaptureBuffer(t *testing.T) {
+	dest := netip.MustParseAddr("192.0.2.1")
+	src := netip.MustParseAddr("198.51.100.2")
+
+	cb := NewCaptureBuffer(&dest, &src)
+
+	query := bytes.NewBuffer([]byte{0x23, 0x00})
+	if err := cb.ProcessQuery(query); err != nil {
+		t.Fatal(err)
+	}
+	t1 := cb.GetSystemTime()
+	t4 := cb.GetSystemTime()
+	if err := cb.ProcessResponse([]byte{0x24, 0x01}); err != nil {
+		t.Fatal(err)
+	}
+
+	packets := cb.Packets()
+	if len(packets) != 1 {
+		t.Fatalf("expected 1 packet, got %d", len(packets))
+	}
+	pkt := packets[0]
+
+	if !bytes.Equal(pkt.QueryData, []byte{0x23, 0x00}) {
+		t.Errorf("unexpected query data %x", pkt.QueryData)
+	}
+	if !bytes.Equal(pkt.Data, []byte{0x24, 0x01}) {
+		t.Errorf("unexpected response data %x", pkt.Data)
+	}
+	if !pkt.T1.AsTime().Equal(t1) || !pkt.T4.AsTime().Equal(t4) {
+		t.Errorf("unexpected timestamps t1=%s t4=%s", pkt.T1.AsTime(), pkt.T4.AsTime())
+	}
+	if ip, _ := netip.AddrFromSlice(pkt.SourceIpBytes); ip != src {
+		t.Errorf("unexpected source %s", ip)
+	}
+	if ip, _ := netip.AddrFromSlice(pkt.DestinationIpBytes); ip != dest {
+		t.Errorf("unexpected destination %s", ip)
+	}
+
+	cb.Clear()
+	if len(cb.Packets()) != 0 {
+		t.Errorf("expected no packets after Clear")
+	}
+}
+
+func TestAddSamples(t *testing.T) {
+	timeout := createTestResponse(true, "network: i/o timeout", true, 0)
+	timeout.Packet = &apiv2.NTPPacket{QueryData: []byte{1}}
+
+	noPacket := createTestResponse(false, "", false, 40)
+
+	ok := createTestResponse(false, "", false, 50)
+	ok.Packet = &apiv2.NTPPacket{QueryData: []byte{2}, Data: []byte{3}}
+
+	addSamples([]*response{timeout, noPacket, ok}, ok)
+
+	if len(ok.Status.Responses) != 2 {
+		t.Fatalf("expected 2 packets, got %d", len(ok.Status.Responses))
+	}
+	if ok.Status.SelectedResponse != 1 {
+		t.Errorf("expected selected response 1, got %d", ok.Status.SelectedResponse)
+	}
+	if len(ok.Status.Samples) != 3 {
+		t.Fatalf("expected 3 samples, got %d", len(ok.Status.Samples))
+	}
+	if !ok.Status.Samples[0].NoResponse || ok.Status.Samples[0].Error == "" {
+		t.Errorf("expected first sample to be a timeout")
+	}
+
+	addSamples([]*response{timeout, noPacket, ok}, noPacket)
+	if noPacket.Status.SelectedResponse != -1 {
+		t.Errorf("expected selected response -1, got %d", noPacket.Status.SelectedResponse)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"net"
 	"net/netip"
 	"os"
+	"slices"
 	"sync"
 	"time"
 	"unicode/utf8"
@@ -22,6 +23,7 @@ import (
 	"go.ntppool.org/common/tracing"
 	"go.ntppool.org/monitor/client/config/checkconfig"
 	"go.ntppool.org/monitor/client/metrics"
+	"go.ntppool.org/monitor/client/scheduler"
 	apiv2 "go.ntppool.org/monitor/gen/monitor/v2"
 )
 
@@ -42,8 +44,17 @@ type response struct {
 	Status   *apiv2.ServerStatus
 	Packet   *apiv2.NTPPacket
 	Error    error
+	Outlier  bool
 }
 
+const (
+	// an offset is an outlier if it's further from the median than
+	// outlierMADs times the median absolute deviation, and at least
+	// outlierMinDeviation.
+	outlierMADs         = 3
+	outlierMinDeviation = 10 * time.Millisecond
+)
+
 // CheckHost runs the configured queries to the IP and returns one ServerStatus
 func CheckHost(ctx context.Context, ip *netip.Addr, cfg *checkconfig.Config, traceAttributes ...attribute.KeyValue) (*apiv2.ServerStatus, *ntp.Response, error) {
 	log := logger.FromContext(ctx)
@@ -105,13 +116,16 @@ func CheckHost(ctx context.Context, ip *netip.Addr, cfg *checkconfig.Config, tra
 		return nil, nil, err
 	}
 
+	sched := scheduler.FromContext(ctx)
+
 	ntpCaptureBuffer := NewCaptureBuffer(ip, configIP)
 	responses := []*response{}
 
 	opts := ntp.QueryOptions{
-		Timeout:      3 * time.Second,
-		Extensions:   []ntp.Extension{ntpCaptureBuffer},
-		LocalAddress: localAddress,
+		Timeout:       3 * time.Second,
+		Extensions:    []ntp.Extension{ntpCaptureBuffer},
+		GetSystemTime: ntpCaptureBuffer.GetSystemTime,
+		LocalAddress:  localAddress,
 	}
 
 	for i := int32(0); i < cfg.Samples; i++ {
@@ -126,8 +140,24 @@ func CheckHost(ctx context.Context, ip *netip.Addr, cfg *checkconfig.Config, tra
 			ipStr = "[" + ipStr + "]:123"
 		}
 
+		// stay within the agent wide query rate
+		if err := sched.WaitQuery(ctx); err != nil {
+			span.RecordError(err)
+			return nil, nil, err
+		}
+
+		ntpCaptureBuffer.Clear()
 		resp, err := ntp.QueryWithOptions(ipStr, opts)
 
+		packets := ntpCaptureBuffer.Packets()
+		if len(packets) > 1 {
+			log.WarnContext(ctx, "got more than one packet for a query")
+		}
+		var packet *apiv2.NTPPacket
+		if len(packets) > 0 {
+			packet = packets[0]
+		}
+
 		// Increment NTP queries sent counter
 		if metrics.NTPQueriesSent != nil {
 			metrics.NTPQueriesSent.Add(ctx, 1, metric.WithAttributes(attribute.String("ip_version", ipVersion)))
@@ -138,11 +168,12 @@ func CheckHost(ctx context.Context, ip *netip.Addr, cfg *checkconfig.Config, tra
 				Status: &apiv2.ServerStatus{
 					NoResponse: true,
 				},
+				Packet: packet,
 			}
 			r.Status.SetIP(ip)
 			if resp != nil {
 				r.Response = resp
-				r.Status = ntpResponseToApiStatus(ip, resp)
+				r.Status = ntpResponseToApiStatus(ip, resp, packet)
 				// ignore the offset if there also was an error
 				r.Status.Offset = nil
 			}
@@ -165,7 +196,14 @@ func CheckHost(ctx context.Context, ip *netip.Addr, cfg *checkconfig.Config, tra
 			continue
 		}
 
-		status := ntpResponseToApiStatus(ip, resp)
+		status := ntpResponseToApiStatus(ip, resp, packet)
+
+		r := &response{
+			Status:   status,
+			Response: resp,
+			Packet:   packet,
+		}
+		responses = append(responses, r)
 
 		if isNTPQueryDebugEnabled() {
 			log.DebugContext(ctx, "ntp query", "host", ip.String(), "iteration", i, "rtt", resp.RTT.String(), "offset", resp.ClockOffset, "error", err)
@@ -177,6 +215,7 @@ func CheckHost(ctx context.Context, ip *netip.Addr, cfg *checkconfig.Config, tra
 				if resp.KissCode == "RATE" {
 					status.Offset = nil
 				}
+				addSamples(responses, r)
 				return status, resp, fmt.Errorf("%s", resp.KissCode)
 			}
 
@@ -187,39 +226,122 @@ func CheckHost(ctx context.Context, ip *netip.Addr, cfg *checkconfig.Config, tra
 				refText = refText + ", " + refIDStr
 			}
 
+			addSamples(responses, r)
 			return status, resp,
 				fmt.Errorf("bad stratum %d (referenceID: %s)",
 					resp.Stratum, refText)
 		}
 
 		if resp.Stratum > 10 {
+			addSamples(responses, r)
 			return status, resp, fmt.Errorf("bad stratum %d", resp.Stratum)
 		}
+	}
 
-		packets := ntpCaptureBuffer.ResponsePackets()
-		if len(packets) > 1 {
-			log.WarnContext(ctx, "got more than one packet for a response")
-		}
-		var packet *apiv2.NTPPacket
-		if len(packets) > 0 {
-			packet = packets[0]
+	markOutliers(responses)
+	best := selectBest(responses)
+
+	addSamples(responses, best)
+
+	// errLog := ""
+	// if len(best.Error) > 0 {
+	// 	errLog = fmt.Sprintf(" err: %q", best.Error)
+	// }
+	// log.Printf("best result for %s - offset: %s rtt: %s%s",
+	// 	ip.String(), best.Offset.AsDuration(), best.RTT.AsDuration(), errLog)
+
+	return best.Status, best.Response, best.Error
+}
+
+func ntpResponseToApiStatus(ip *netip.Addr, resp *ntp.Response, packet *apiv2.NTPPacket) *apiv2.ServerStatus {
+	// log.Printf("Leap: %d", resp.Leap)
+	status := &apiv2.ServerStatus{
+		Ts:             timestamppb.Now(),
+		Offset:         durationpb.New(resp.ClockOffset),
+		Stratum:        int32(resp.Stratum),
+		Leap:           int32(resp.Leap),
+		Rtt:            durationpb.New(resp.RTT),
+		NoResponse:     false,
+		Precision:      durationpb.New(resp.Precision),
+		ReferenceId:    resp.ReferenceID,
+		RootDelay:      durationpb.New(resp.RootDelay),
+		RootDispersion: durationpb.New(resp.RootDispersion),
+		ReferenceTime:  timestamppb.New(resp.ReferenceTime),
+		T3:             timestamppb.New(resp.Time),
+	}
+	// the ntp library doesn't return the server receive time
+	if packet != nil && len(packet.Data) >= 48 {
+		status.T2 = timestamppb.New(ntpTimestamp(packet.Data[32:40]))
+	}
+
+	status.RootDistance = durationpb.New(resp.RootDistance)
+
+	// a zero reference time means the server has never been synchronized,
+	// there's no meaningful age for it then.
+	if packet == nil || len(packet.Data) < 24 || binary.BigEndian.Uint64(packet.Data[16:24]) != 0 {
+		status.ReferenceAge = durationpb.New(resp.Time.Sub(resp.ReferenceTime))
+	}
+	status.SetIP(ip)
+	return status
+}
+
+// ntpTimestamp decodes a 64-bit NTP timestamp. Like the ntp library
+// it assumes era 1 (2036+) for timestamps that would be before 1970.
+func ntpTimestamp(b []byte) time.Time {
+	const ntpEpochOffset = 2208988800 // seconds from 1900 to 1970
+
+	ts := binary.BigEndian.Uint64(b)
+	sec := int64(ts >> 32)
+	frac := int64(ts & 0xffffffff)
+	if sec < ntpEpochOffset {
+		sec += 1 << 32
+	}
+	return time.Unix(sec-ntpEpochOffset, (frac*1e9)>>32).UTC()
+}
+
+// markOutliers flags the responses with an offset too far from the
+// median offset. It needs at least three valid offsets to do anything.
+func markOutliers(responses []*response) {
+	valid := []*response{}
+	for _, r := range responses {
+		if r.Error == nil && r.Status != nil && r.Status.Offset != nil {
+			valid = append(valid, r)
 		}
+	}
+	if len(valid) < 3 {
+		return
+	}
 
-		ntpCaptureBuffer.Clear()
+	offsets := make([]time.Duration, len(valid))
+	for i, r := range valid {
+		offsets[i] = r.Status.Offset.AsDuration()
+	}
+	median := medianDuration(offsets)
 
-		responses = append(responses, &response{
-			Status:   status,
-			Response: resp,
-			Packet:   packet,
-		})
+	deviations := make([]time.Duration, len(valid))
+	for i, o := range offsets {
+		deviations[i] = (o - median).Abs()
 	}
+	threshold := max(outlierMADs*medianDuration(deviations), outlierMinDeviation)
 
-	var best *response
+	for i, r := range valid {
+		r.Outlier = deviations[i] > threshold
+	}
+}
 
-	// log.Debug("collection done, now find the best result", "ip", ip.String(), "count", len(responses))
+func medianDuration(d []time.Duration) time.Duration {
+	d = slices.Clone(d)
+	slices.Sort(d)
+	n := len(d)
+	if n%2 == 1 {
+		return d[n/2]
+	}
+	return (d[n/2-1] + d[n/2]) / 2
+}
 
-	// todo: if there are more than 2 (3?) samples with an offset, throw
-	// away the offset outlier(s)
+// selectBest picks the response the status should be based on
+func selectBest(responses []*response) *response {
+	var best *response
 
 	for _, r := range responses {
 
@@ -244,7 +366,15 @@ func CheckHost(ctx context.Context, ip *netip.Addr, cfg *checkconfig.Config, tra
 			}
 		}
 
-		// Priority 3: Among equivalent response types, compare RTT (only if both have valid RTT)
+		// Priority 3: Among valid responses, skip offset outliers
+		if r.Error == nil && best.Error == nil && r.Outlier != best.Outlier {
+			if best.Outlier {
+				best = r
+			}
+			continue
+		}
+
+		// Priority 4: Among equivalent response types, compare RTT (only if both have valid RTT)
 		if r.Error == nil && best.Error == nil {
 			// Both are valid responses - compare RTT
 			if r.Status.Rtt != nil && best.Status.Rtt != nil &&
@@ -261,29 +391,41 @@ func CheckHost(ctx context.Context, ip *netip.Addr, cfg *checkconfig.Config, tra
 		}
 	}
 
-	// errLog := ""
-	// if len(best.Error) > 0 {
-	// 	errLog = fmt.Sprintf(" err: %q", best.Error)
-	// }
-	// log.Printf("best result for %s - offset: %s rtt: %s%s",
-	// 	ip.String(), best.Offset.AsDuration(), best.RTT.AsDuration(), errLog)
-
-	return best.Status, best.Response, best.Error
+	return best
 }
 
-func ntpResponseToApiStatus(ip *netip.Addr, resp *ntp.Response) *apiv2.ServerStatus {
-	// log.Printf("Leap: %d", resp.Leap)
-	status := &apiv2.ServerStatus{
-		Ts:         timestamppb.Now(),
-		Offset:     durationpb.New(resp.ClockOffset),
-		Stratum:    int32(resp.Stratum),
-		Leap:       int32(resp.Leap),
-		Rtt:        durationpb.New(resp.RTT),
-		NoResponse: false,
-		// Responses:
+// addSamples sets the result and packets for all the responses on
+// the selected status, so the server gets every query that was made.
+func addSamples(responses []*response, selected *response) {
+	if selected == nil || selected.Status == nil {
+		return
+	}
+	status := selected.Status
+	status.Responses = []*apiv2.NTPPacket{}
+	status.SelectedResponse = -1
+	status.Samples = make([]*apiv2.Sample, 0, len(responses))
+	for _, r := range responses {
+		sample := &apiv2.Sample{
+			Outlier: r.Outlier,
+		}
+		if r.Status != nil {
+			sample.Offset = r.Status.Offset
+			sample.Rtt = r.Status.Rtt
+			sample.NoResponse = r.Status.NoResponse
+		}
+		if r.Error != nil {
+			sample.Error = r.Error.Error()
+		}
+		status.Samples = append(status.Samples, sample)
+
+		if r.Packet == nil {
+			continue
+		}
+		if r == selected {
+			status.SelectedResponse = int32(len(status.Responses))
+		}
+		status.Responses = append(status.Responses, r.Packet)
 	}
-	status.SetIP(ip)
-	return status
 }
 
 func referenceIDString(refid uint32) string {
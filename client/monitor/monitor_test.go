@@ -144,45 +144,7 @@ func TestResponseSelection(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Simulate the selection logic from CheckHost
-			var best *response
-
-			for _, r := range tt.responses {
-				if best == nil {
-					best = r
-					continue
-				}
-
-				// Priority 1: Always prefer responses without errors
-				if r.Error == nil && best.Error != nil {
-					best = r
-					continue
-				}
-
-				// Priority 2: Among responses with errors, prefer partial responses over complete timeouts
-				if r.Error != nil && best.Error != nil {
-					if !r.Status.NoResponse && best.Status.NoResponse {
-						best = r
-						continue
-					}
-				}
-
-				// Priority 3: Among equivalent response types, compare RTT (only if both have valid RTT)
-				if r.Error == nil && best.Error == nil {
-					// Both are valid responses - compare RTT
-					if r.Status.Rtt != nil && best.Status.Rtt != nil &&
-						r.Status.Rtt.AsDuration() < best.Status.Rtt.AsDuration() {
-						best = r
-					}
-				} else if r.Error != nil && best.Error != nil &&
-					r.Status.NoResponse == best.Status.NoResponse {
-					// Both have same error type - compare RTT if available
-					if r.Status.Rtt != nil && best.Status.Rtt != nil &&
-						r.Status.Rtt.AsDuration() < best.Status.Rtt.AsDuration() {
-						best = r
-					}
-				}
-			}
+			best := selectBest(tt.responses)
 
 			// Verify the selected response matches expectations
 			if (best.Error != nil) != tt.expected.hasError {
@@ -225,20 +187,7 @@ func TestResponseSelectionEdgeCases(t *testing.T) {
 			},
 		}
 
-		var best *response
-		for _, r := range responses {
-			if best == nil {
-				best = r
-				continue
-			}
-
-			if r.Error == nil && best.Error == nil {
-				if r.Status.Rtt != nil && best.Status.Rtt != nil &&
-					r.Status.Rtt.AsDuration() < best.Status.Rtt.AsDuration() {
-					best = r
-				}
-			}
-		}
+		best := selectBest(responses)
 
 		// Should not panic - the logic should prefer non-nil RTT only if doing actual comparison
 		// Since the first response has nil RTT, it becomes best and stays best
@@ -250,17 +199,68 @@ func TestResponseSelectionEdgeCases(t *testing.T) {
 
 	t.Run("empty responses slice", func(t *testing.T) {
 		var responses []*response
-		var best *response
+		best := selectBest(responses)
+
+		if best != nil {
+			t.Error("Expected best to remain nil for empty responses")
+		}
+	})
+}
+
+func TestOutlierRejection(t *testing.T) {
+	newResponse := func(offsetMs, rttMs int) *response {
+		r := createTestResponse(false, "", false, rttMs)
+		r.Status.Offset = durationpb.New(time.Duration(offsetMs) * time.Millisecond)
+		return r
+	}
+
+	t.Run("outlier with lowest RTT is skipped", func(t *testing.T) {
+		responses := []*response{
+			newResponse(1, 30),
+			newResponse(250, 10),
+			newResponse(2, 20),
+		}
+		markOutliers(responses)
+
+		if !responses[1].Outlier {
+			t.Errorf("expected 250ms offset to be an outlier")
+		}
+		if responses[0].Outlier || responses[2].Outlier {
+			t.Errorf("unexpected outliers")
+		}
+
+		if best := selectBest(responses); best != responses[2] {
+			t.Errorf("expected lowest RTT non-outlier to be selected")
+		}
+	})
+
+	t.Run("spread out offsets are kept", func(t *testing.T) {
+		responses := []*response{
+			newResponse(0, 30),
+			newResponse(50, 10),
+			newResponse(100, 20),
+		}
+		markOutliers(responses)
 
-		for _, r := range responses {
-			if best == nil {
-				best = r
-				continue
+		for i, r := range responses {
+			if r.Outlier {
+				t.Errorf("response %d unexpectedly an outlier", i)
 			}
 		}
+	})
 
-		if best != nil {
-			t.Error("Expected best to remain nil for empty responses")
+	t.Run("too few samples", func(t *testing.T) {
+		responses := []*response{
+			newResponse(1, 30),
+			newResponse(500, 10),
+			createTestResponse(true, "network: i/o timeout", true, 0),
+		}
+		markOutliers(responses)
+
+		for i, r := range responses {
+			if r.Outlier {
+				t.Errorf("response %d unexpectedly an outlier", i)
+			}
 		}
 	})
 }
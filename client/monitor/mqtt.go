@@ -17,6 +17,7 @@ import (
 	"go.ntppool.org/common/tracing"
 	"go.ntppool.org/monitor/api"
 	"go.ntppool.org/monitor/client/config/checkconfig"
+	"go.ntppool.org/monitor/client/scheduler"
 	"go.ntppool.org/monitor/mqttcm"
 )
 
@@ -24,11 +25,12 @@ type mqclient struct {
 	mq     *autopaho.ConnectionManager
 	topics *mqttcm.MQTTTopics
 	conf   checkconfig.ConfigGetter
+	sched  *scheduler.Scheduler
 	log    *slog.Logger
 }
 
-func NewMQClient(log *slog.Logger, topics *mqttcm.MQTTTopics, conf checkconfig.ConfigGetter) *mqclient {
-	return &mqclient{topics: topics, conf: conf, log: log}
+func NewMQClient(log *slog.Logger, topics *mqttcm.MQTTTopics, conf checkconfig.ConfigGetter, sched *scheduler.Scheduler) *mqclient {
+	return &mqclient{topics: topics, conf: conf, sched: sched, log: log}
 }
 
 func (mqc *mqclient) SetMQ(mq *autopaho.ConnectionManager) {
@@ -41,6 +43,8 @@ func (mqc *mqclient) Handler(m *paho.Publish) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	ctx = scheduler.NewContext(ctx, mqc.sched)
+
 	log.Debug("mqtt client message", "topic", m.Topic, "payload", m.Payload, "properties", m.Properties)
 
 	tracePropagator := otel.GetTextMapPropagator()
@@ -98,10 +102,25 @@ func (mqc *mqclient) Handler(m *paho.Publish) {
 		}
 		cfg.Samples = 1
 
-		_, resp, err := CheckHost(ctx, &ip, cfg)
+		status, resp, err := CheckHost(ctx, &ip, cfg)
 		r := &api.NTPResponse{
 			NTP: resp,
 		}
+		if resp != nil && status != nil {
+			r.Header = &api.NTPHeader{
+				Precision:      status.Precision.AsDuration(),
+				ReferenceID:    status.ReferenceIDString(),
+				RootDelay:      status.RootDelay.AsDuration(),
+				RootDispersion: status.RootDispersion.AsDuration(),
+				ReferenceTime:  status.ReferenceTime.AsTime(),
+			}
+			if status.T2 != nil {
+				r.Header.T2 = status.T2.AsTime()
+			}
+			if status.T3 != nil {
+				r.Header.T3 = status.T3.AsTime()
+			}
+		}
 		if err != nil {
 			log.Info("ntp check error", "err", err)
 			r.Error = err.Error()
@@ -0,0 +1,111 @@
+// Package scheduler limits how much NTP traffic the agent generates.
+//
+// The monitor batches for IPv4 and IPv6 share a bounded number of
+// check slots and start their checks paced out, and every NTP query
+// (including the MQTT and local sanity checks) takes a token from a
+// global packets-per-second bucket, so agents on small links don't
+// send bursts that look like abuse.
+package scheduler
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	DefaultConcurrency = 10
+	DefaultQueryRate   = 20 // queries per second
+	DefaultPacing      = 100 * time.Millisecond
+)
+
+type Config struct {
+	// Concurrency is how many servers can be checked at the same time
+	Concurrency int
+	// QueryRate is the maximum NTP queries per second
+	QueryRate float64
+	// Pacing is the minimum time between starting checks in a batch
+	Pacing time.Duration
+}
+
+type Scheduler struct {
+	slots   chan struct{}
+	limiter *rate.Limiter
+	pacing  time.Duration
+}
+
+type contextKey struct{}
+
+// New returns a scheduler with the specified limits; zero values
+// get the defaults.
+func New(cfg Config) *Scheduler {
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = DefaultConcurrency
+	}
+	if cfg.QueryRate <= 0 {
+		cfg.QueryRate = DefaultQueryRate
+	}
+	if cfg.Pacing < 0 {
+		cfg.Pacing = 0
+	}
+
+	burst := int(math.Ceil(cfg.QueryRate))
+
+	return &Scheduler{
+		slots:   make(chan struct{}, cfg.Concurrency),
+		limiter: rate.NewLimiter(rate.Limit(cfg.QueryRate), burst),
+		pacing:  cfg.Pacing,
+	}
+}
+
+// NewContext returns a context with the scheduler
+func NewContext(ctx context.Context, s *Scheduler) context.Context {
+	return context.WithValue(ctx, contextKey{}, s)
+}
+
+// FromContext returns the scheduler from the context, or nil if
+// there isn't one. All methods work (without limits) on a nil
+// scheduler.
+func FromContext(ctx context.Context) *Scheduler {
+	s, _ := ctx.Value(contextKey{}).(*Scheduler)
+	return s
+}
+
+// Acquire blocks until a check slot is available. The returned
+// function must be called to release the slot.
+func (s *Scheduler) Acquire(ctx context.Context) (func(), error) {
+	if s == nil {
+		return func() {}, nil
+	}
+	select {
+	case s.slots <- struct{}{}:
+		return func() { <-s.slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// WaitQuery blocks until another NTP query can be sent
+func (s *Scheduler) WaitQuery(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	return s.limiter.Wait(ctx)
+}
+
+// Pace waits the pacing interval between starting checks in a batch
+func (s *Scheduler) Pace(ctx context.Context) error {
+	if s == nil || s.pacing == 0 {
+		return nil
+	}
+	t := time.NewTimer(s.pacing)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,77 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAcquire(t *testing.T) {
+	s := New(Config{Concurrency: 2})
+	ctx := context.Background()
+
+	r1, err := s.Acquire(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r2, err := s.Acquire(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := s.Acquire(tctx); err == nil {
+		t.Fatalf("expected third Acquire to block until the context expired")
+	}
+
+	r1()
+	r3, err := s.Acquire(ctx)
+	if err != nil {
+		t.Fatalf("expected Acquire to succeed after release: %s", err)
+	}
+	r2()
+	r3()
+}
+
+func TestWaitQuery(t *testing.T) {
+	s := New(Config{QueryRate: 50})
+	ctx := context.Background()
+
+	start := time.Now()
+	// the first 50 are the burst, the next 10 take ~200ms
+	for range 60 {
+		if err := s.WaitQuery(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d := time.Since(start); d < 150*time.Millisecond {
+		t.Errorf("queries were not rate limited, took %s", d)
+	}
+}
+
+func TestNilScheduler(t *testing.T) {
+	ctx := context.Background()
+	s := FromContext(ctx)
+	if s != nil {
+		t.Fatalf("expected nil scheduler")
+	}
+
+	release, err := s.Acquire(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+
+	if err := s.WaitQuery(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Pace(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	s = New(Config{})
+	if FromContext(NewContext(ctx, s)) != s {
+		t.Errorf("scheduler not returned from context")
+	}
+}
@@ -0,0 +1,257 @@
+// Package spool keeps result batches the agent couldn't submit on
+// disk so they can be replayed when the API is reachable again.
+//
+// The results in a batch carry the tickets the server signed for the
+// monitor, batch ID and server IP, so a spooled batch is submitted
+// unchanged. Batches are stored one per file, named by the batch ID
+// (a ULID) so they sort in the order they were issued.
+package spool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"google.golang.org/protobuf/proto"
+
+	apiv2 "go.ntppool.org/monitor/gen/monitor/v2"
+)
+
+const (
+	// DefaultMaxAge is a bit less than how old a batch the server
+	// accepts, to leave time for the replay.
+	DefaultMaxAge  = 3 * time.Hour
+	DefaultMaxSize = 20 << 20 // bytes
+
+	fileSuffix = ".pb"
+)
+
+// ErrRejected should be wrapped by the submit function when the
+// server refused a batch; the batch is then removed from the spool
+// instead of retried.
+var ErrRejected = errors.New("batch rejected")
+
+type Config struct {
+	// Dir is where the batches are stored; it's created if needed
+	Dir string
+	// MaxAge is how long a batch is kept (by the batch ID timestamp)
+	MaxAge time.Duration
+	// MaxSize is the maximum total size of the spooled batches; the
+	// oldest are removed to make room for new ones.
+	MaxSize int64
+}
+
+type Spool struct {
+	dir     string
+	maxAge  time.Duration
+	maxSize int64
+
+	mu sync.Mutex
+}
+
+type entry struct {
+	name string
+	id   ulid.ULID
+	size int64
+}
+
+// New returns a spool in cfg.Dir; zero limits get the defaults.
+func New(cfg Config) (*Spool, error) {
+	if cfg.Dir == "" {
+		return nil, fmt.Errorf("spool directory not set")
+	}
+	if cfg.MaxAge <= 0 {
+		cfg.MaxAge = DefaultMaxAge
+	}
+	if cfg.MaxSize <= 0 {
+		cfg.MaxSize = DefaultMaxSize
+	}
+
+	if err := os.MkdirAll(cfg.Dir, 0o700); err != nil {
+		return nil, fmt.Errorf("creating spool directory: %w", err)
+	}
+
+	return &Spool{
+		dir:     cfg.Dir,
+		maxAge:  cfg.MaxAge,
+		maxSize: cfg.MaxSize,
+	}, nil
+}
+
+// Add stores the batch, removing expired batches and the oldest
+// batches if the spool is over the size limit.
+func (s *Spool) Add(req *apiv2.SubmitResultsRequest) error {
+	id := ulid.ULID{}
+	if err := id.UnmarshalText(req.BatchId); err != nil {
+		return fmt.Errorf("invalid batch ID: %w", err)
+	}
+
+	data, err := proto.Marshal(req)
+	if err != nil {
+		return err
+	}
+	if int64(len(data)) > s.maxSize {
+		return fmt.Errorf("batch is larger than the spool (%d bytes)", len(data))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := s.entries()
+	if err != nil {
+		return err
+	}
+
+	var total int64
+	for _, e := range entries {
+		total += e.size
+	}
+	for len(entries) > 0 && total+int64(len(data)) > s.maxSize {
+		if err := s.remove(entries[0]); err != nil {
+			return err
+		}
+		total -= entries[0].size
+		entries = entries[1:]
+	}
+
+	// write to a temporary file first so a crash doesn't
+	// leave a partial batch to be replayed
+	tmp, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filepath.Join(s.dir, id.String()+fileSuffix))
+}
+
+// Replay submits the spooled batches, oldest first. Batches are
+// removed when submit succeeds or returns an error wrapping
+// ErrRejected; any other error stops the replay and the batch is
+// kept for next time. Replay returns the number of batches that
+// were submitted.
+func (s *Spool) Replay(ctx context.Context, submit func(context.Context, *apiv2.SubmitResultsRequest) error) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := s.entries()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	var rejected []error
+
+	for _, e := range entries {
+		if ctx.Err() != nil {
+			return count, ctx.Err()
+		}
+
+		data, err := os.ReadFile(filepath.Join(s.dir, e.name))
+		if err != nil {
+			return count, err
+		}
+
+		req := &apiv2.SubmitResultsRequest{}
+		if err := proto.Unmarshal(data, req); err != nil {
+			rejected = append(rejected, fmt.Errorf("%s: %w", e.name, err))
+			if err := s.remove(e); err != nil {
+				return count, err
+			}
+			continue
+		}
+
+		err = submit(ctx, req)
+		if err != nil && !errors.Is(err, ErrRejected) {
+			return count, err
+		}
+		if err != nil {
+			rejected = append(rejected, fmt.Errorf("%s: %w", e.id, err))
+		} else {
+			count++
+		}
+		if err := s.remove(e); err != nil {
+			return count, err
+		}
+	}
+
+	return count, errors.Join(rejected...)
+}
+
+// Len returns the number of spooled batches
+func (s *Spool) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := s.entries()
+	if err != nil {
+		return 0
+	}
+	return len(entries)
+}
+
+// entries returns the batches in the spool, oldest first, after
+// removing the expired ones.
+func (s *Spool) entries() ([]entry, error) {
+	files, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	expire := time.Now().Add(-s.maxAge)
+
+	entries := []entry{}
+	for _, f := range files {
+		name := f.Name()
+		if f.IsDir() || !strings.HasSuffix(name, fileSuffix) {
+			continue
+		}
+		id, err := ulid.ParseStrict(strings.TrimSuffix(name, fileSuffix))
+		if err != nil {
+			continue
+		}
+		info, err := f.Info()
+		if err != nil {
+			continue
+		}
+		e := entry{name: name, id: id, size: info.Size()}
+
+		if ulid.Time(id.Time()).Before(expire) {
+			if err := s.remove(e); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		entries = append(entries, e)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].id.Compare(entries[j].id) < 0
+	})
+
+	return entries, nil
+}
+
+func (s *Spool) remove(e entry) error {
+	err := os.Remove(filepath.Join(s.dir, e.name))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package spool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"google.golang.org/protobuf/proto"
+
+	apiv2 "go.ntppool.org/monitor/gen/monitor/v2"
+)
+
+func testBatch(t *testing.T, ts time.Time, results int) *apiv2.SubmitResultsRequest {
+	t.Helper()
+	id := ulid.MustNew(ulid.Timestamp(ts), ulid.DefaultEntropy()).String()
+	req := &apiv2.SubmitResultsRequest{
+		MonId:   "192.0.2.1",
+		Version: 5,
+		BatchId: []byte(id),
+	}
+	for range results {
+		req.List = append(req.List, &apiv2.ServerStatus{
+			Ticket: make([]byte, 64),
+			Error:  "network: i/o timeout",
+		})
+	}
+	return req
+}
+
+func TestReplayOrder(t *testing.T) {
+	s, err := New(Config{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	b1 := testBatch(t, now.Add(-20*time.Minute), 1)
+	b2 := testBatch(t, now.Add(-10*time.Minute), 1)
+	b3 := testBatch(t, now.Add(-5*time.Minute), 1)
+
+	for _, b := range []*apiv2.SubmitResultsRequest{b2, b3, b1} {
+		if err := s.Add(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx := context.Background()
+
+	// the API is still down after the first batch
+	submitted := []string{}
+	count, err := s.Replay(ctx, func(ctx context.Context, req *apiv2.SubmitResultsRequest) error {
+		if len(submitted) > 0 {
+			return fmt.Errorf("unavailable")
+		}
+		submitted = append(submitted, string(req.BatchId))
+		return nil
+	})
+	if err == nil || count != 1 {
+		t.Fatalf("expected one batch and an error, got %d, %v", count, err)
+	}
+	if submitted[0] != string(b1.BatchId) {
+		t.Errorf("expected the oldest batch first")
+	}
+	if s.Len() != 2 {
+		t.Fatalf("expected 2 batches left, got %d", s.Len())
+	}
+
+	// the server refuses the next one
+	submitted = []string{}
+	count, err = s.Replay(ctx, func(ctx context.Context, req *apiv2.SubmitResultsRequest) error {
+		if string(req.BatchId) == string(b2.BatchId) {
+			return fmt.Errorf("%w: bad signature", ErrRejected)
+		}
+		submitted = append(submitted, string(req.BatchId))
+		return nil
+	})
+	if !errors.Is(err, ErrRejected) || count != 1 {
+		t.Fatalf("expected one batch and the rejection, got %d, %v", count, err)
+	}
+	if len(submitted) != 1 || submitted[0] != string(b3.BatchId) {
+		t.Errorf("unexpected batches submitted: %v", submitted)
+	}
+	if s.Len() != 0 {
+		t.Errorf("expected an empty spool, got %d", s.Len())
+	}
+}
+
+func TestLimits(t *testing.T) {
+	now := time.Now()
+	dir := t.TempDir()
+
+	s, err := New(Config{Dir: dir, MaxAge: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add(testBatch(t, now.Add(-2*time.Hour), 1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add(testBatch(t, now, 1)); err != nil {
+		t.Fatal(err)
+	}
+	if s.Len() != 1 {
+		t.Errorf("expected the expired batch to be removed, got %d", s.Len())
+	}
+
+	// room for about two batches
+	size := int64(proto.Size(testBatch(t, now, 10)))
+	s, err = New(Config{Dir: t.TempDir(), MaxSize: 2 * size})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := testBatch(t, now.Add(-3*time.Minute), 10)
+	for _, b := range []*apiv2.SubmitResultsRequest{
+		first,
+		testBatch(t, now.Add(-2*time.Minute), 10),
+		testBatch(t, now.Add(-1*time.Minute), 10),
+	} {
+		if err := s.Add(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if s.Len() != 2 {
+		t.Fatalf("expected 2 batches, got %d", s.Len())
+	}
+	_, err = s.Replay(context.Background(), func(ctx context.Context, req *apiv2.SubmitResultsRequest) error {
+		if string(req.BatchId) == string(first.BatchId) {
+			t.Errorf("expected the oldest batch to be removed")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
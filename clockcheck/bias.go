@@ -0,0 +1,96 @@
+package clockcheck
+
+import (
+	"math"
+	"sort"
+)
+
+// offsetSample is the average offset a monitor measured for a server
+type offsetSample struct {
+	MonitorID uint32
+	ServerID  uint32
+	Offset    float64 // seconds
+}
+
+// Result is the clock check for one monitor
+type Result struct {
+	MonitorID uint32
+	// Bias is the median difference (in seconds) between the offsets
+	// the monitor measured and the median of the other monitors
+	// measuring the same servers
+	Bias float64
+	// Servers is how many servers the monitor could be compared on
+	Servers int
+	// Consistency is the share of those servers where the monitor
+	// was off by more than half of MaxBias in the direction of Bias
+	Consistency float64
+	Flagged     bool
+}
+
+// checkBias compares the offsets each monitor measured with what the
+// other monitors measured for the same servers. A server's own clock
+// error is the same for every monitor, so a monitor that's
+// consistently off in one direction most likely has a bad clock.
+func checkBias(samples []offsetSample, settings Settings) map[uint32]*Result {
+	byServer := map[uint32][]offsetSample{}
+	for _, s := range samples {
+		byServer[s.ServerID] = append(byServer[s.ServerID], s)
+	}
+
+	deviations := map[uint32][]float64{}
+
+	for _, list := range byServer {
+		if len(list) < settings.MinPeers+1 {
+			continue
+		}
+		for i, s := range list {
+			others := make([]float64, 0, len(list)-1)
+			for j, o := range list {
+				if i != j {
+					others = append(others, o.Offset)
+				}
+			}
+			deviations[s.MonitorID] = append(deviations[s.MonitorID], s.Offset-median(others))
+		}
+	}
+
+	maxBias := settings.MaxBias.Seconds()
+
+	results := map[uint32]*Result{}
+	for monitorID, devs := range deviations {
+		r := &Result{
+			MonitorID: monitorID,
+			Bias:      median(devs),
+			Servers:   len(devs),
+		}
+
+		consistent := 0
+		for _, d := range devs {
+			if math.Signbit(d) == math.Signbit(r.Bias) && math.Abs(d) > maxBias/2 {
+				consistent++
+			}
+		}
+		r.Consistency = float64(consistent) / float64(len(devs))
+
+		r.Flagged = r.Servers >= settings.MinServers &&
+			math.Abs(r.Bias) > maxBias &&
+			r.Consistency >= settings.Consistency
+
+		results[monitorID] = r
+	}
+
+	return results
+}
+
+// median returns the median of the values; the slice is sorted
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sort.Float64s(values)
+	mid := len(values) / 2
+	if len(values)%2 == 1 {
+		return values[mid]
+	}
+	return (values[mid-1] + values[mid]) / 2
+}
@@ -0,0 +1,66 @@
+package clockcheck
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCheckBias(t *testing.T) {
+	settings := Settings{}
+	settings.setDefaults()
+	settings.MinServers = 10
+
+	// monitor 1 to 4 agree, monitor 5 is 40ms ahead; each
+	// server has its own offset error
+	samples := []offsetSample{}
+	for server := uint32(1); server <= 20; server++ {
+		serverOffset := float64(server%7) * 0.003
+		for monitor := uint32(1); monitor <= 5; monitor++ {
+			offset := serverOffset + float64(monitor%2)*0.0005
+			if monitor == 5 {
+				offset -= 0.040
+			}
+			samples = append(samples, offsetSample{
+				MonitorID: monitor,
+				ServerID:  server,
+				Offset:    offset,
+			})
+		}
+	}
+
+	// a server only one other monitor measured isn't used
+	samples = append(samples,
+		offsetSample{MonitorID: 1, ServerID: 100, Offset: 2},
+		offsetSample{MonitorID: 2, ServerID: 100, Offset: -2},
+	)
+
+	results := checkBias(samples, settings)
+
+	if len(results) != 5 {
+		t.Fatalf("expected results for 5 monitors, got %d", len(results))
+	}
+
+	for id, r := range results {
+		if r.Servers != 20 {
+			t.Errorf("monitor %d: compared on %d servers, expected 20", id, r.Servers)
+		}
+		if id == 5 {
+			if !r.Flagged {
+				t.Errorf("monitor 5 should be flagged: %+v", r)
+			}
+			if math.Abs(r.Bias+0.040) > 0.001 {
+				t.Errorf("monitor 5 bias %f, expected about -0.040", r.Bias)
+			}
+			continue
+		}
+		if r.Flagged {
+			t.Errorf("monitor %d shouldn't be flagged: %+v", id, r)
+		}
+	}
+
+	// too few servers to flag
+	settings.MinServers = 30
+	if r := checkBias(samples, settings)[5]; r.Flagged {
+		t.Errorf("monitor 5 flagged with too few servers: %+v", r)
+	}
+}
@@ -0,0 +1,366 @@
+// Package clockcheck looks for monitors with a bad local clock by
+// comparing the offsets they measure with the offsets other monitors
+// measure for the same servers.
+//
+// The agent only checks its own clock against a few reference
+// servers before submitting results; a monitor with a drifting clock
+// otherwise lowers the score of every server it monitors. Monitors
+// with a consistent bias are flagged in the monitor_clock_checks
+// table and, if enabled in the "clockcheck" system setting, paused
+// with the reason recorded in the logs table.
+package clockcheck
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"go.ntppool.org/common/database"
+	"go.ntppool.org/common/logger"
+	"go.ntppool.org/common/metricsserver"
+	"go.ntppool.org/common/timeutil"
+	"go.ntppool.org/common/version"
+	"go.ntppool.org/monitor/ntpdb"
+)
+
+// Cmd provides the command structure for CLI integration
+type Cmd struct {
+	Server ServerCmd `cmd:"server" help:"run continuously"`
+	Run    OnceCmd   `cmd:"once" help:"run once"`
+}
+
+type (
+	ServerCmd struct {
+		MetricsPort int           `default:"9000" help:"Metrics server port" flag:"metrics-port"`
+		Interval    time.Duration `default:"15m" help:"Time between checks" flag:"interval"`
+	}
+	OnceCmd struct {
+		MetricsPort int `default:"9000" help:"Metrics server port" flag:"metrics-port"`
+	}
+)
+
+func (cmd ServerCmd) Run(ctx context.Context) error {
+	return Run(ctx, cmd.Interval, cmd.MetricsPort)
+}
+
+func (cmd OnceCmd) Run(ctx context.Context) error {
+	return Run(ctx, 0, cmd.MetricsPort)
+}
+
+// Settings are loaded from the "clockcheck" system setting
+type Settings struct {
+	// Lookback is how far back log scores are compared
+	Lookback timeutil.Duration `json:"lookback"`
+	// MaxBias is the largest bias accepted
+	MaxBias timeutil.Duration `json:"max_bias"`
+	// MinServers is how many servers a monitor must be compared on
+	// to be flagged
+	MinServers int `json:"min_servers"`
+	// MinPeers is how many other monitors must have measured a
+	// server for it to be used
+	MinPeers int `json:"min_peers"`
+	// Consistency is the share of servers the monitor must be off on
+	// in the same direction
+	Consistency float64 `json:"consistency"`
+
+	// Pause sets flagged monitors to paused once they have been
+	// flagged for PauseAfter; at most MaxPause monitors are paused
+	// per run.
+	Pause      bool              `json:"pause"`
+	PauseAfter timeutil.Duration `json:"pause_after"`
+	MaxPause   int               `json:"max_pause"`
+}
+
+func (s *Settings) setDefaults() {
+	if s.Lookback.Duration <= 0 {
+		s.Lookback = timeutil.Duration{Duration: 2 * time.Hour}
+	}
+	if s.MaxBias.Duration <= 0 {
+		s.MaxBias = timeutil.Duration{Duration: 25 * time.Millisecond}
+	}
+	if s.MinServers <= 0 {
+		s.MinServers = 20
+	}
+	if s.MinPeers <= 0 {
+		s.MinPeers = 3
+	}
+	if s.Consistency <= 0 || s.Consistency > 1 {
+		s.Consistency = 0.75
+	}
+	if s.PauseAfter.Duration <= 0 {
+		s.PauseAfter = timeutil.Duration{Duration: time.Hour}
+	}
+	if s.MaxPause <= 0 {
+		s.MaxPause = 1
+	}
+}
+
+// Run checks the monitor clocks every interval, or once if interval
+// is zero.
+func Run(ctx context.Context, interval time.Duration, metricsPort int) error {
+	log := logger.FromContext(ctx)
+
+	log.InfoContext(ctx, "clockcheck starting", "version", version.Version())
+
+	dbconn, err := ntpdb.OpenDB()
+	if err != nil {
+		return err
+	}
+
+	metricssrv := metricsserver.New()
+	version.RegisterMetric("clockcheck", metricssrv.Registry())
+	go func() {
+		if err := metricssrv.ListenAndServe(ctx, metricsPort); err != nil {
+			log.Error("metrics server error", "err", err)
+		}
+	}()
+
+	cc := NewChecker(dbconn, log, NewMetrics(metricssrv.Registry()))
+
+	for {
+		if err := cc.Run(ctx); err != nil {
+			if interval == 0 {
+				return err
+			}
+			log.ErrorContext(ctx, "clock check failed", "err", err)
+		}
+
+		if interval == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Metrics for the clock checks
+type Metrics struct {
+	Bias    *prometheus.GaugeVec
+	Flagged *prometheus.GaugeVec
+	Paused  *prometheus.CounterVec
+}
+
+// NewMetrics creates and registers the clock check metrics
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	labels := []string{"monitor", "ip_version"}
+
+	m := &Metrics{
+		Bias: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "clockcheck_monitor_bias_seconds",
+				Help: "Median offset difference between the monitor and other monitors",
+			},
+			labels,
+		),
+		Flagged: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "clockcheck_monitor_flagged",
+				Help: "Monitors flagged for a clock bias",
+			},
+			labels,
+		),
+		Paused: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "clockcheck_monitors_paused_total",
+				Help: "Monitors paused because of a clock bias",
+			},
+			labels,
+		),
+	}
+
+	reg.MustRegister(m.Bias, m.Flagged, m.Paused)
+
+	return m
+}
+
+// Checker runs the clock checks
+type Checker struct {
+	dbconn  *sql.DB
+	log     *slog.Logger
+	metrics *Metrics
+}
+
+// NewChecker returns a checker; metrics can be nil
+func NewChecker(dbconn *sql.DB, log *slog.Logger, metrics *Metrics) *Checker {
+	return &Checker{dbconn: dbconn, log: log, metrics: metrics}
+}
+
+// Run checks the clock of each active or testing monitor, records
+// the results and pauses monitors if enabled.
+func (cc *Checker) Run(ctx context.Context) error {
+	now := time.Now()
+	db := ntpdb.New(cc.dbconn)
+
+	settings := cc.settings(ctx, db)
+
+	monitors, err := db.GetActiveMonitors(ctx)
+	if err != nil {
+		return fmt.Errorf("loading monitors: %w", err)
+	}
+
+	rows, err := db.GetMonitorServerOffsets(ctx, now.Add(-settings.Lookback.Duration))
+	if err != nil {
+		return fmt.Errorf("loading offsets: %w", err)
+	}
+
+	samples := make([]offsetSample, 0, len(rows))
+	for _, row := range rows {
+		x := sql.NullFloat64{}
+		if err := x.Scan(row.Offset); err != nil || !x.Valid {
+			continue
+		}
+		samples = append(samples, offsetSample{
+			MonitorID: row.MonitorID,
+			ServerID:  row.ServerID,
+			Offset:    x.Float64,
+		})
+	}
+
+	results := checkBias(samples, settings)
+
+	previous, err := db.GetMonitorClockChecks(ctx)
+	if err != nil {
+		return fmt.Errorf("loading previous checks: %w", err)
+	}
+	flaggedSince := map[uint32]sql.NullTime{}
+	for _, p := range previous {
+		flaggedSince[p.MonitorID] = p.FlaggedSince
+	}
+
+	if cc.metrics != nil {
+		cc.metrics.Bias.Reset()
+		cc.metrics.Flagged.Reset()
+	}
+
+	return database.WithTransaction(ctx, db, func(ctx context.Context, db ntpdb.QuerierTx) error {
+		paused := 0
+
+		for _, monitor := range monitors {
+			r, ok := results[monitor.ID]
+			if !ok {
+				continue
+			}
+
+			log := cc.log.With("monitor_id", monitor.ID, "tls_name", monitor.TlsName.String)
+
+			since := sql.NullTime{}
+			if r.Flagged {
+				since = flaggedSince[monitor.ID]
+				if !since.Valid {
+					since = sql.NullTime{Time: now, Valid: true}
+				}
+				log.WarnContext(ctx, "monitor clock bias",
+					"bias", time.Duration(r.Bias*float64(time.Second)),
+					"servers", r.Servers,
+					"consistency", r.Consistency,
+					"flagged_since", since.Time,
+				)
+			}
+
+			if cc.metrics != nil {
+				labels := []string{monitor.TlsName.String, monitor.IpVersion.MonitorsIpVersion.String()}
+				cc.metrics.Bias.WithLabelValues(labels...).Set(r.Bias)
+				flagged := 0.0
+				if r.Flagged {
+					flagged = 1
+				}
+				cc.metrics.Flagged.WithLabelValues(labels...).Set(flagged)
+			}
+
+			if err := db.UpdateMonitorClockCheck(ctx, ntpdb.UpdateMonitorClockCheckParams{
+				MonitorID:    monitor.ID,
+				CheckedOn:    now,
+				Bias:         r.Bias,
+				Servers:      uint32(r.Servers),
+				Consistency:  r.Consistency,
+				FlaggedSince: since,
+			}); err != nil {
+				return err
+			}
+
+			if !r.Flagged || !settings.Pause || now.Sub(since.Time) < settings.PauseAfter.Duration {
+				continue
+			}
+			if paused >= settings.MaxPause {
+				log.InfoContext(ctx, "not pausing monitor, already paused the maximum this run")
+				continue
+			}
+
+			if err := cc.pause(ctx, db, monitor, r, since.Time); err != nil {
+				return err
+			}
+			paused++
+			log.WarnContext(ctx, "paused monitor for clock bias")
+
+			if cc.metrics != nil {
+				cc.metrics.Paused.WithLabelValues(monitor.TlsName.String, monitor.IpVersion.MonitorsIpVersion.String()).Inc()
+			}
+		}
+
+		return nil
+	})
+}
+
+// pause sets the monitor to paused and records why in the logs table
+func (cc *Checker) pause(ctx context.Context, db ntpdb.QuerierTx, monitor ntpdb.Monitor, r *Result, since time.Time) error {
+	if err := db.UpdateMonitorStatus(ctx, ntpdb.UpdateMonitorStatusParams{
+		Status: ntpdb.MonitorsStatusPaused,
+		ID:     monitor.ID,
+	}); err != nil {
+		return err
+	}
+
+	bias := time.Duration(r.Bias * float64(time.Second)).Round(time.Microsecond)
+
+	reason := fmt.Sprintf("clock bias of %s compared to other monitors on %d servers (%.0f%% consistent) since %s",
+		bias, r.Servers, r.Consistency*100, since.UTC().Format(time.RFC3339))
+
+	changes, err := json.Marshal(map[string]any{
+		"monitor_id": monitor.ID,
+		"tls_name":   monitor.TlsName.String,
+		"status":     []string{string(monitor.Status), string(ntpdb.MonitorsStatusPaused)},
+		"bias":       math.Round(r.Bias*1e6) / 1e6,
+		"servers":    r.Servers,
+		"reason":     reason,
+	})
+	if err != nil {
+		return err
+	}
+
+	return db.InsertLog(ctx, ntpdb.InsertLogParams{
+		AccountID: monitor.AccountID,
+		Type:      sql.NullString{String: "monitor-clock", Valid: true},
+		Message:   sql.NullString{String: fmt.Sprintf("Paused monitor %s: %s", monitor.TlsName.String, reason), Valid: true},
+		Changes:   sql.NullString{String: string(changes), Valid: true},
+	})
+}
+
+func (cc *Checker) settings(ctx context.Context, db ntpdb.Querier) Settings {
+	var settings Settings
+
+	settingsStr, err := db.GetSystemSetting(ctx, "clockcheck")
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		cc.log.WarnContext(ctx, "could not fetch clockcheck settings", "err", err)
+	}
+	if len(settingsStr) > 0 {
+		if err := json.Unmarshal([]byte(settingsStr), &settings); err != nil {
+			cc.log.WarnContext(ctx, "could not unmarshal clockcheck settings", "err", err)
+			settings = Settings{}
+		}
+	}
+
+	settings.setDefaults()
+
+	return settings
+}
@@ -16,6 +16,10 @@ func (s *Server) IP() *netip.Addr {
 	return bytesToIP(s.IpBytes)
 }
 
+func (r *SubmitTracerouteRequest) GetIP() *netip.Addr {
+	return bytesToIP(r.GetIpBytes())
+}
+
 func bytesToIP(b []byte) *netip.Addr {
 	// log.Printf("ip bytes length: %d", len(b))
 	if len(b) == 4 {
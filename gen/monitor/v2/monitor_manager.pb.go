@@ -23,6 +23,119 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type KernelSyncState int32
+
+const (
+	KernelSyncState_KERNEL_SYNC_STATE_UNSPECIFIED    KernelSyncState = 0 // not available on the platform
+	KernelSyncState_KERNEL_SYNC_STATE_SYNCHRONIZED   KernelSyncState = 1
+	KernelSyncState_KERNEL_SYNC_STATE_UNSYNCHRONIZED KernelSyncState = 2
+)
+
+// Enum value maps for KernelSyncState.
+var (
+	KernelSyncState_name = map[int32]string{
+		0: "KERNEL_SYNC_STATE_UNSPECIFIED",
+		1: "KERNEL_SYNC_STATE_SYNCHRONIZED",
+		2: "KERNEL_SYNC_STATE_UNSYNCHRONIZED",
+	}
+	KernelSyncState_value = map[string]int32{
+		"KERNEL_SYNC_STATE_UNSPECIFIED":    0,
+		"KERNEL_SYNC_STATE_SYNCHRONIZED":   1,
+		"KERNEL_SYNC_STATE_UNSYNCHRONIZED": 2,
+	}
+)
+
+func (x KernelSyncState) Enum() *KernelSyncState {
+	p := new(KernelSyncState)
+	*p = x
+	return p
+}
+
+func (x KernelSyncState) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (KernelSyncState) Descriptor() protoreflect.EnumDescriptor {
+	return file_monitor_v2_monitor_manager_proto_enumTypes[0].Descriptor()
+}
+
+func (KernelSyncState) Type() protoreflect.EnumType {
+	return &file_monitor_v2_monitor_manager_proto_enumTypes[0]
+}
+
+func (x KernelSyncState) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use KernelSyncState.Descriptor instead.
+func (KernelSyncState) EnumDescriptor() ([]byte, []int) {
+	return file_monitor_v2_monitor_manager_proto_rawDescGZIP(), []int{0}
+}
+
+type ResultStatus int32
+
+const (
+	ResultStatus_RESULT_STATUS_UNSPECIFIED    ResultStatus = 0
+	ResultStatus_RESULT_STATUS_ACCEPTED       ResultStatus = 1
+	ResultStatus_RESULT_STATUS_BAD_SIGNATURE  ResultStatus = 2
+	ResultStatus_RESULT_STATUS_UNKNOWN_SERVER ResultStatus = 3
+	ResultStatus_RESULT_STATUS_INTERNAL_ERROR ResultStatus = 4
+	// not scored because the monitor appeared to have a network outage
+	ResultStatus_RESULT_STATUS_QUARANTINED ResultStatus = 5
+	// not scored because the monitor's clock uncertainty is too large
+	// compared with the offset
+	ResultStatus_RESULT_STATUS_CLOCK_UNCERTAIN ResultStatus = 6
+)
+
+// Enum value maps for ResultStatus.
+var (
+	ResultStatus_name = map[int32]string{
+		0: "RESULT_STATUS_UNSPECIFIED",
+		1: "RESULT_STATUS_ACCEPTED",
+		2: "RESULT_STATUS_BAD_SIGNATURE",
+		3: "RESULT_STATUS_UNKNOWN_SERVER",
+		4: "RESULT_STATUS_INTERNAL_ERROR",
+		5: "RESULT_STATUS_QUARANTINED",
+		6: "RESULT_STATUS_CLOCK_UNCERTAIN",
+	}
+	ResultStatus_value = map[string]int32{
+		"RESULT_STATUS_UNSPECIFIED":     0,
+		"RESULT_STATUS_ACCEPTED":        1,
+		"RESULT_STATUS_BAD_SIGNATURE":   2,
+		"RESULT_STATUS_UNKNOWN_SERVER":  3,
+		"RESULT_STATUS_INTERNAL_ERROR":  4,
+		"RESULT_STATUS_QUARANTINED":     5,
+		"RESULT_STATUS_CLOCK_UNCERTAIN": 6,
+	}
+)
+
+func (x ResultStatus) Enum() *ResultStatus {
+	p := new(ResultStatus)
+	*p = x
+	return p
+}
+
+func (x ResultStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ResultStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_monitor_v2_monitor_manager_proto_enumTypes[1].Descriptor()
+}
+
+func (ResultStatus) Type() protoreflect.EnumType {
+	return &file_monitor_v2_monitor_manager_proto_enumTypes[1]
+}
+
+func (x ResultStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ResultStatus.Descriptor instead.
+func (ResultStatus) EnumDescriptor() ([]byte, []int) {
+	return file_monitor_v2_monitor_manager_proto_rawDescGZIP(), []int{1}
+}
+
 type GetConfigRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	MonId         string                 `protobuf:"bytes,1,opt,name=mon_id,json=monId,proto3" json:"mon_id,omitempty"`
@@ -393,11 +506,13 @@ func (x *GetServersResponse) GetServers() []*Server {
 }
 
 type SubmitResultsRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Version       int32                  `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
-	MonId         string                 `protobuf:"bytes,2,opt,name=mon_id,json=monId,proto3" json:"mon_id,omitempty"`
-	List          []*ServerStatus        `protobuf:"bytes,3,rep,name=list,proto3" json:"list,omitempty"`
-	BatchId       []byte                 `protobuf:"bytes,4,opt,name=batch_id,json=batchId,proto3" json:"batch_id,omitempty"`
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Version int32                  `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	MonId   string                 `protobuf:"bytes,2,opt,name=mon_id,json=monId,proto3" json:"mon_id,omitempty"`
+	List    []*ServerStatus        `protobuf:"bytes,3,rep,name=list,proto3" json:"list,omitempty"`
+	BatchId []byte                 `protobuf:"bytes,4,opt,name=batch_id,json=batchId,proto3" json:"batch_id,omitempty"`
+	// the monitor's estimate of its own clock when the batch was run
+	Clock         *ClockQuality `protobuf:"bytes,5,opt,name=clock,proto3" json:"clock,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -460,16 +575,121 @@ func (x *SubmitResultsRequest) GetBatchId() []byte {
 	return nil
 }
 
+func (x *SubmitResultsRequest) GetClock() *ClockQuality {
+	if x != nil {
+		return x.Clock
+	}
+	return nil
+}
+
+// ClockQuality is the monitor's local clock estimate from the
+// BaseChecks and the kernel.
+type ClockQuality struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Ts     *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=ts,proto3" json:"ts,omitempty"`         // when the BaseChecks ran
+	Offset *durationpb.Duration   `protobuf:"bytes,2,opt,name=offset,proto3" json:"offset,omitempty"` // median BaseCheck offset
+	// largest difference between a BaseCheck offset and the median
+	Dispersion *durationpb.Duration `protobuf:"bytes,3,opt,name=dispersion,proto3" json:"dispersion,omitempty"`
+	Samples    int32                `protobuf:"varint,4,opt,name=samples,proto3" json:"samples,omitempty"` // BaseChecks with a response
+	// from adjtimex(2)
+	KernelSync     KernelSyncState      `protobuf:"varint,5,opt,name=kernel_sync,json=kernelSync,proto3,enum=monitor.v2.KernelSyncState" json:"kernel_sync,omitempty"`
+	KernelMaxError *durationpb.Duration `protobuf:"bytes,6,opt,name=kernel_max_error,json=kernelMaxError,proto3" json:"kernel_max_error,omitempty"`
+	KernelEstError *durationpb.Duration `protobuf:"bytes,7,opt,name=kernel_est_error,json=kernelEstError,proto3" json:"kernel_est_error,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ClockQuality) Reset() {
+	*x = ClockQuality{}
+	mi := &file_monitor_v2_monitor_manager_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClockQuality) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClockQuality) ProtoMessage() {}
+
+func (x *ClockQuality) ProtoReflect() protoreflect.Message {
+	mi := &file_monitor_v2_monitor_manager_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClockQuality.ProtoReflect.Descriptor instead.
+func (*ClockQuality) Descriptor() ([]byte, []int) {
+	return file_monitor_v2_monitor_manager_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ClockQuality) GetTs() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Ts
+	}
+	return nil
+}
+
+func (x *ClockQuality) GetOffset() *durationpb.Duration {
+	if x != nil {
+		return x.Offset
+	}
+	return nil
+}
+
+func (x *ClockQuality) GetDispersion() *durationpb.Duration {
+	if x != nil {
+		return x.Dispersion
+	}
+	return nil
+}
+
+func (x *ClockQuality) GetSamples() int32 {
+	if x != nil {
+		return x.Samples
+	}
+	return 0
+}
+
+func (x *ClockQuality) GetKernelSync() KernelSyncState {
+	if x != nil {
+		return x.KernelSync
+	}
+	return KernelSyncState_KERNEL_SYNC_STATE_UNSPECIFIED
+}
+
+func (x *ClockQuality) GetKernelMaxError() *durationpb.Duration {
+	if x != nil {
+		return x.KernelMaxError
+	}
+	return nil
+}
+
+func (x *ClockQuality) GetKernelEstError() *durationpb.Duration {
+	if x != nil {
+		return x.KernelEstError
+	}
+	return nil
+}
+
 type SubmitResultsResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Ok    bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	// status of each result, in the order they were submitted
+	Results       []ResultStatus `protobuf:"varint,2,rep,packed,name=results,proto3,enum=monitor.v2.ResultStatus" json:"results,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *SubmitResultsResponse) Reset() {
 	*x = SubmitResultsResponse{}
-	mi := &file_monitor_v2_monitor_manager_proto_msgTypes[7]
+	mi := &file_monitor_v2_monitor_manager_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -481,7 +701,7 @@ func (x *SubmitResultsResponse) String() string {
 func (*SubmitResultsResponse) ProtoMessage() {}
 
 func (x *SubmitResultsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_monitor_v2_monitor_manager_proto_msgTypes[7]
+	mi := &file_monitor_v2_monitor_manager_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -494,7 +714,7 @@ func (x *SubmitResultsResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SubmitResultsResponse.ProtoReflect.Descriptor instead.
 func (*SubmitResultsResponse) Descriptor() ([]byte, []int) {
-	return file_monitor_v2_monitor_manager_proto_rawDescGZIP(), []int{7}
+	return file_monitor_v2_monitor_manager_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *SubmitResultsResponse) GetOk() bool {
@@ -504,20 +724,168 @@ func (x *SubmitResultsResponse) GetOk() bool {
 	return false
 }
 
+func (x *SubmitResultsResponse) GetResults() []ResultStatus {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type SubmitTracerouteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MonId         string                 `protobuf:"bytes,1,opt,name=mon_id,json=monId,proto3" json:"mon_id,omitempty"`
+	BatchId       []byte                 `protobuf:"bytes,2,opt,name=batch_id,json=batchId,proto3" json:"batch_id,omitempty"` // batch the server was sent in, for the ticket
+	Ticket        []byte                 `protobuf:"bytes,3,opt,name=ticket,proto3" json:"ticket,omitempty"`
+	IpBytes       []byte                 `protobuf:"bytes,4,opt,name=ip_bytes,json=ipBytes,proto3" json:"ip_bytes,omitempty"`
+	Ts            *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=ts,proto3" json:"ts,omitempty"`
+	Output        []byte                 `protobuf:"bytes,6,opt,name=output,proto3" json:"output,omitempty"`
+	Error         string                 `protobuf:"bytes,7,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubmitTracerouteRequest) Reset() {
+	*x = SubmitTracerouteRequest{}
+	mi := &file_monitor_v2_monitor_manager_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmitTracerouteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitTracerouteRequest) ProtoMessage() {}
+
+func (x *SubmitTracerouteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_monitor_v2_monitor_manager_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitTracerouteRequest.ProtoReflect.Descriptor instead.
+func (*SubmitTracerouteRequest) Descriptor() ([]byte, []int) {
+	return file_monitor_v2_monitor_manager_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *SubmitTracerouteRequest) GetMonId() string {
+	if x != nil {
+		return x.MonId
+	}
+	return ""
+}
+
+func (x *SubmitTracerouteRequest) GetBatchId() []byte {
+	if x != nil {
+		return x.BatchId
+	}
+	return nil
+}
+
+func (x *SubmitTracerouteRequest) GetTicket() []byte {
+	if x != nil {
+		return x.Ticket
+	}
+	return nil
+}
+
+func (x *SubmitTracerouteRequest) GetIpBytes() []byte {
+	if x != nil {
+		return x.IpBytes
+	}
+	return nil
+}
+
+func (x *SubmitTracerouteRequest) GetTs() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Ts
+	}
+	return nil
+}
+
+func (x *SubmitTracerouteRequest) GetOutput() []byte {
+	if x != nil {
+		return x.Output
+	}
+	return nil
+}
+
+func (x *SubmitTracerouteRequest) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type SubmitTracerouteResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubmitTracerouteResponse) Reset() {
+	*x = SubmitTracerouteResponse{}
+	mi := &file_monitor_v2_monitor_manager_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmitTracerouteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitTracerouteResponse) ProtoMessage() {}
+
+func (x *SubmitTracerouteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_monitor_v2_monitor_manager_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitTracerouteResponse.ProtoReflect.Descriptor instead.
+func (*SubmitTracerouteResponse) Descriptor() ([]byte, []int) {
+	return file_monitor_v2_monitor_manager_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *SubmitTracerouteResponse) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+// NTPPacket is one query/response exchange with an NTP server. The
+// source and destination are from the perspective of the query; t1
+// and t4 are the local transmit and receive times (t2 and t3 are in
+// the response data).
 type NTPPacket struct {
 	state              protoimpl.MessageState `protogen:"open.v1"`
 	SourceIpBytes      []byte                 `protobuf:"bytes,1,opt,name=source_ip_bytes,json=sourceIpBytes,proto3" json:"source_ip_bytes,omitempty"`
 	DestinationIpBytes []byte                 `protobuf:"bytes,2,opt,name=destination_ip_bytes,json=destinationIpBytes,proto3" json:"destination_ip_bytes,omitempty"`
 	T1                 *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=t1,proto3" json:"t1,omitempty"`
 	T4                 *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=t4,proto3" json:"t4,omitempty"`
-	Data               []byte                 `protobuf:"bytes,5,opt,name=data,proto3" json:"data,omitempty"`
+	Data               []byte                 `protobuf:"bytes,5,opt,name=data,proto3" json:"data,omitempty"`                            // response packet, empty if there was no response
+	QueryData          []byte                 `protobuf:"bytes,6,opt,name=query_data,json=queryData,proto3" json:"query_data,omitempty"` // query packet as sent
 	unknownFields      protoimpl.UnknownFields
 	sizeCache          protoimpl.SizeCache
 }
 
 func (x *NTPPacket) Reset() {
 	*x = NTPPacket{}
-	mi := &file_monitor_v2_monitor_manager_proto_msgTypes[8]
+	mi := &file_monitor_v2_monitor_manager_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -529,7 +897,7 @@ func (x *NTPPacket) String() string {
 func (*NTPPacket) ProtoMessage() {}
 
 func (x *NTPPacket) ProtoReflect() protoreflect.Message {
-	mi := &file_monitor_v2_monitor_manager_proto_msgTypes[8]
+	mi := &file_monitor_v2_monitor_manager_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -542,7 +910,7 @@ func (x *NTPPacket) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use NTPPacket.ProtoReflect.Descriptor instead.
 func (*NTPPacket) Descriptor() ([]byte, []int) {
-	return file_monitor_v2_monitor_manager_proto_rawDescGZIP(), []int{8}
+	return file_monitor_v2_monitor_manager_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *NTPPacket) GetSourceIpBytes() []byte {
@@ -580,25 +948,50 @@ func (x *NTPPacket) GetData() []byte {
 	return nil
 }
 
+func (x *NTPPacket) GetQueryData() []byte {
+	if x != nil {
+		return x.QueryData
+	}
+	return nil
+}
+
 type ServerStatus struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	TestId        []byte                 `protobuf:"bytes,1,opt,name=test_id,json=testId,proto3" json:"test_id,omitempty"`
-	Ticket        []byte                 `protobuf:"bytes,2,opt,name=ticket,proto3" json:"ticket,omitempty"`
-	IpBytes       []byte                 `protobuf:"bytes,3,opt,name=ip_bytes,json=ipBytes,proto3" json:"ip_bytes,omitempty"`
-	Error         string                 `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
-	NoResponse    bool                   `protobuf:"varint,5,opt,name=no_response,json=noResponse,proto3" json:"no_response,omitempty"`
-	Ts            *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=ts,proto3" json:"ts,omitempty"`
-	Offset        *durationpb.Duration   `protobuf:"bytes,7,opt,name=offset,proto3" json:"offset,omitempty"`
-	Rtt           *durationpb.Duration   `protobuf:"bytes,8,opt,name=rtt,proto3" json:"rtt,omitempty"`
-	Stratum       int32                  `protobuf:"varint,9,opt,name=stratum,proto3" json:"stratum,omitempty"`
-	Leap          int32                  `protobuf:"varint,10,opt,name=leap,proto3" json:"leap,omitempty"`
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	TestId     []byte                 `protobuf:"bytes,1,opt,name=test_id,json=testId,proto3" json:"test_id,omitempty"`
+	Ticket     []byte                 `protobuf:"bytes,2,opt,name=ticket,proto3" json:"ticket,omitempty"`
+	IpBytes    []byte                 `protobuf:"bytes,3,opt,name=ip_bytes,json=ipBytes,proto3" json:"ip_bytes,omitempty"`
+	Error      string                 `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	NoResponse bool                   `protobuf:"varint,5,opt,name=no_response,json=noResponse,proto3" json:"no_response,omitempty"`
+	Ts         *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=ts,proto3" json:"ts,omitempty"`
+	Offset     *durationpb.Duration   `protobuf:"bytes,7,opt,name=offset,proto3" json:"offset,omitempty"`
+	Rtt        *durationpb.Duration   `protobuf:"bytes,8,opt,name=rtt,proto3" json:"rtt,omitempty"`
+	Stratum    int32                  `protobuf:"varint,9,opt,name=stratum,proto3" json:"stratum,omitempty"`
+	Leap       int32                  `protobuf:"varint,10,opt,name=leap,proto3" json:"leap,omitempty"`
+	// all the queries made for this check (version 5+)
+	Responses []*NTPPacket `protobuf:"bytes,11,rep,name=responses,proto3" json:"responses,omitempty"`
+	// index in responses of the one the status is based on, -1 for none
+	SelectedResponse int32 `protobuf:"varint,12,opt,name=selected_response,json=selectedResponse,proto3" json:"selected_response,omitempty"`
+	// from the NTP header of the selected response
+	Precision      *durationpb.Duration   `protobuf:"bytes,13,opt,name=precision,proto3" json:"precision,omitempty"`
+	ReferenceId    uint32                 `protobuf:"varint,14,opt,name=reference_id,json=referenceId,proto3" json:"reference_id,omitempty"`
+	RootDelay      *durationpb.Duration   `protobuf:"bytes,15,opt,name=root_delay,json=rootDelay,proto3" json:"root_delay,omitempty"`
+	RootDispersion *durationpb.Duration   `protobuf:"bytes,16,opt,name=root_dispersion,json=rootDispersion,proto3" json:"root_dispersion,omitempty"`
+	ReferenceTime  *timestamppb.Timestamp `protobuf:"bytes,17,opt,name=reference_time,json=referenceTime,proto3" json:"reference_time,omitempty"`
+	T2             *timestamppb.Timestamp `protobuf:"bytes,18,opt,name=t2,proto3" json:"t2,omitempty"` // server receive time
+	T3             *timestamppb.Timestamp `protobuf:"bytes,19,opt,name=t3,proto3" json:"t3,omitempty"` // server transmit time
+	// RFC 5905 root distance (root delay/2 + root dispersion + rtt/2)
+	RootDistance *durationpb.Duration `protobuf:"bytes,20,opt,name=root_distance,json=rootDistance,proto3" json:"root_distance,omitempty"`
+	// time since the server last updated its clock (t3 - reference time)
+	ReferenceAge *durationpb.Duration `protobuf:"bytes,21,opt,name=reference_age,json=referenceAge,proto3" json:"reference_age,omitempty"`
+	// the result of each query, in the order they were made
+	Samples       []*Sample `protobuf:"bytes,22,rep,name=samples,proto3" json:"samples,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ServerStatus) Reset() {
 	*x = ServerStatus{}
-	mi := &file_monitor_v2_monitor_manager_proto_msgTypes[9]
+	mi := &file_monitor_v2_monitor_manager_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -610,7 +1003,7 @@ func (x *ServerStatus) String() string {
 func (*ServerStatus) ProtoMessage() {}
 
 func (x *ServerStatus) ProtoReflect() protoreflect.Message {
-	mi := &file_monitor_v2_monitor_manager_proto_msgTypes[9]
+	mi := &file_monitor_v2_monitor_manager_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -623,7 +1016,7 @@ func (x *ServerStatus) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ServerStatus.ProtoReflect.Descriptor instead.
 func (*ServerStatus) Descriptor() ([]byte, []int) {
-	return file_monitor_v2_monitor_manager_proto_rawDescGZIP(), []int{9}
+	return file_monitor_v2_monitor_manager_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *ServerStatus) GetTestId() []byte {
@@ -696,6 +1089,167 @@ func (x *ServerStatus) GetLeap() int32 {
 	return 0
 }
 
+func (x *ServerStatus) GetResponses() []*NTPPacket {
+	if x != nil {
+		return x.Responses
+	}
+	return nil
+}
+
+func (x *ServerStatus) GetSelectedResponse() int32 {
+	if x != nil {
+		return x.SelectedResponse
+	}
+	return 0
+}
+
+func (x *ServerStatus) GetPrecision() *durationpb.Duration {
+	if x != nil {
+		return x.Precision
+	}
+	return nil
+}
+
+func (x *ServerStatus) GetReferenceId() uint32 {
+	if x != nil {
+		return x.ReferenceId
+	}
+	return 0
+}
+
+func (x *ServerStatus) GetRootDelay() *durationpb.Duration {
+	if x != nil {
+		return x.RootDelay
+	}
+	return nil
+}
+
+func (x *ServerStatus) GetRootDispersion() *durationpb.Duration {
+	if x != nil {
+		return x.RootDispersion
+	}
+	return nil
+}
+
+func (x *ServerStatus) GetReferenceTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ReferenceTime
+	}
+	return nil
+}
+
+func (x *ServerStatus) GetT2() *timestamppb.Timestamp {
+	if x != nil {
+		return x.T2
+	}
+	return nil
+}
+
+func (x *ServerStatus) GetT3() *timestamppb.Timestamp {
+	if x != nil {
+		return x.T3
+	}
+	return nil
+}
+
+func (x *ServerStatus) GetRootDistance() *durationpb.Duration {
+	if x != nil {
+		return x.RootDistance
+	}
+	return nil
+}
+
+func (x *ServerStatus) GetReferenceAge() *durationpb.Duration {
+	if x != nil {
+		return x.ReferenceAge
+	}
+	return nil
+}
+
+func (x *ServerStatus) GetSamples() []*Sample {
+	if x != nil {
+		return x.Samples
+	}
+	return nil
+}
+
+type Sample struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Offset     *durationpb.Duration   `protobuf:"bytes,1,opt,name=offset,proto3" json:"offset,omitempty"`
+	Rtt        *durationpb.Duration   `protobuf:"bytes,2,opt,name=rtt,proto3" json:"rtt,omitempty"`
+	Error      string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	NoResponse bool                   `protobuf:"varint,4,opt,name=no_response,json=noResponse,proto3" json:"no_response,omitempty"`
+	// offset too far from the other samples to be used
+	Outlier       bool `protobuf:"varint,5,opt,name=outlier,proto3" json:"outlier,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Sample) Reset() {
+	*x = Sample{}
+	mi := &file_monitor_v2_monitor_manager_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Sample) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Sample) ProtoMessage() {}
+
+func (x *Sample) ProtoReflect() protoreflect.Message {
+	mi := &file_monitor_v2_monitor_manager_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Sample.ProtoReflect.Descriptor instead.
+func (*Sample) Descriptor() ([]byte, []int) {
+	return file_monitor_v2_monitor_manager_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *Sample) GetOffset() *durationpb.Duration {
+	if x != nil {
+		return x.Offset
+	}
+	return nil
+}
+
+func (x *Sample) GetRtt() *durationpb.Duration {
+	if x != nil {
+		return x.Rtt
+	}
+	return nil
+}
+
+func (x *Sample) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *Sample) GetNoResponse() bool {
+	if x != nil {
+		return x.NoResponse
+	}
+	return false
+}
+
+func (x *Sample) GetOutlier() bool {
+	if x != nil {
+		return x.Outlier
+	}
+	return false
+}
+
 var File_monitor_v2_monitor_manager_proto protoreflect.FileDescriptor
 
 const file_monitor_v2_monitor_manager_proto_rawDesc = "" +
@@ -730,20 +1284,45 @@ const file_monitor_v2_monitor_manager_proto_rawDesc = "" +
 	"\x12GetServersResponse\x12\x19\n" +
 	"\bbatch_id\x18\x01 \x01(\fR\abatchId\x125\n" +
 	"\x06config\x18\x02 \x01(\v2\x1d.monitor.v2.GetConfigResponseR\x06config\x12,\n" +
-	"\aservers\x18\x03 \x03(\v2\x12.monitor.v2.ServerR\aservers\"\x90\x01\n" +
+	"\aservers\x18\x03 \x03(\v2\x12.monitor.v2.ServerR\aservers\"\xc0\x01\n" +
 	"\x14SubmitResultsRequest\x12\x18\n" +
 	"\aversion\x18\x01 \x01(\x05R\aversion\x12\x15\n" +
 	"\x06mon_id\x18\x02 \x01(\tR\x05monId\x12,\n" +
 	"\x04list\x18\x03 \x03(\v2\x18.monitor.v2.ServerStatusR\x04list\x12\x19\n" +
-	"\bbatch_id\x18\x04 \x01(\fR\abatchId\"'\n" +
+	"\bbatch_id\x18\x04 \x01(\fR\abatchId\x12.\n" +
+	"\x05clock\x18\x05 \x01(\v2\x18.monitor.v2.ClockQualityR\x05clock\"\x8a\x03\n" +
+	"\fClockQuality\x12*\n" +
+	"\x02ts\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x02ts\x121\n" +
+	"\x06offset\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\x06offset\x129\n" +
+	"\n" +
+	"dispersion\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\n" +
+	"dispersion\x12\x18\n" +
+	"\asamples\x18\x04 \x01(\x05R\asamples\x12<\n" +
+	"\vkernel_sync\x18\x05 \x01(\x0e2\x1b.monitor.v2.KernelSyncStateR\n" +
+	"kernelSync\x12C\n" +
+	"\x10kernel_max_error\x18\x06 \x01(\v2\x19.google.protobuf.DurationR\x0ekernelMaxError\x12C\n" +
+	"\x10kernel_est_error\x18\a \x01(\v2\x19.google.protobuf.DurationR\x0ekernelEstError\"[\n" +
 	"\x15SubmitResultsResponse\x12\x0e\n" +
-	"\x02ok\x18\x01 \x01(\bR\x02ok\"\xd1\x01\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x122\n" +
+	"\aresults\x18\x02 \x03(\x0e2\x18.monitor.v2.ResultStatusR\aresults\"\xd8\x01\n" +
+	"\x17SubmitTracerouteRequest\x12\x15\n" +
+	"\x06mon_id\x18\x01 \x01(\tR\x05monId\x12\x19\n" +
+	"\bbatch_id\x18\x02 \x01(\fR\abatchId\x12\x16\n" +
+	"\x06ticket\x18\x03 \x01(\fR\x06ticket\x12\x19\n" +
+	"\bip_bytes\x18\x04 \x01(\fR\aipBytes\x12*\n" +
+	"\x02ts\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\x02ts\x12\x16\n" +
+	"\x06output\x18\x06 \x01(\fR\x06output\x12\x14\n" +
+	"\x05error\x18\a \x01(\tR\x05error\"*\n" +
+	"\x18SubmitTracerouteResponse\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\"\xf0\x01\n" +
 	"\tNTPPacket\x12&\n" +
 	"\x0fsource_ip_bytes\x18\x01 \x01(\fR\rsourceIpBytes\x120\n" +
 	"\x14destination_ip_bytes\x18\x02 \x01(\fR\x12destinationIpBytes\x12*\n" +
 	"\x02t1\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x02t1\x12*\n" +
 	"\x02t4\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x02t4\x12\x12\n" +
-	"\x04data\x18\x05 \x01(\fR\x04data\"\xcb\x02\n" +
+	"\x04data\x18\x05 \x01(\fR\x04data\x12\x1d\n" +
+	"\n" +
+	"query_data\x18\x06 \x01(\fR\tqueryData\"\xd0\a\n" +
 	"\fServerStatus\x12\x17\n" +
 	"\atest_id\x18\x01 \x01(\fR\x06testId\x12\x16\n" +
 	"\x06ticket\x18\x02 \x01(\fR\x06ticket\x12\x19\n" +
@@ -756,12 +1335,45 @@ const file_monitor_v2_monitor_manager_proto_rawDesc = "" +
 	"\x03rtt\x18\b \x01(\v2\x19.google.protobuf.DurationR\x03rtt\x12\x18\n" +
 	"\astratum\x18\t \x01(\x05R\astratum\x12\x12\n" +
 	"\x04leap\x18\n" +
-	" \x01(\x05R\x04leap2\x83\x02\n" +
+	" \x01(\x05R\x04leap\x123\n" +
+	"\tresponses\x18\v \x03(\v2\x15.monitor.v2.NTPPacketR\tresponses\x12+\n" +
+	"\x11selected_response\x18\f \x01(\x05R\x10selectedResponse\x127\n" +
+	"\tprecision\x18\r \x01(\v2\x19.google.protobuf.DurationR\tprecision\x12!\n" +
+	"\freference_id\x18\x0e \x01(\rR\vreferenceId\x128\n" +
+	"\n" +
+	"root_delay\x18\x0f \x01(\v2\x19.google.protobuf.DurationR\trootDelay\x12B\n" +
+	"\x0froot_dispersion\x18\x10 \x01(\v2\x19.google.protobuf.DurationR\x0erootDispersion\x12A\n" +
+	"\x0ereference_time\x18\x11 \x01(\v2\x1a.google.protobuf.TimestampR\rreferenceTime\x12*\n" +
+	"\x02t2\x18\x12 \x01(\v2\x1a.google.protobuf.TimestampR\x02t2\x12*\n" +
+	"\x02t3\x18\x13 \x01(\v2\x1a.google.protobuf.TimestampR\x02t3\x12>\n" +
+	"\rroot_distance\x18\x14 \x01(\v2\x19.google.protobuf.DurationR\frootDistance\x12>\n" +
+	"\rreference_age\x18\x15 \x01(\v2\x19.google.protobuf.DurationR\freferenceAge\x12,\n" +
+	"\asamples\x18\x16 \x03(\v2\x12.monitor.v2.SampleR\asamples\"\xb9\x01\n" +
+	"\x06Sample\x121\n" +
+	"\x06offset\x18\x01 \x01(\v2\x19.google.protobuf.DurationR\x06offset\x12+\n" +
+	"\x03rtt\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\x03rtt\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\x12\x1f\n" +
+	"\vno_response\x18\x04 \x01(\bR\n" +
+	"noResponse\x12\x18\n" +
+	"\aoutlier\x18\x05 \x01(\bR\aoutlier*~\n" +
+	"\x0fKernelSyncState\x12!\n" +
+	"\x1dKERNEL_SYNC_STATE_UNSPECIFIED\x10\x00\x12\"\n" +
+	"\x1eKERNEL_SYNC_STATE_SYNCHRONIZED\x10\x01\x12$\n" +
+	" KERNEL_SYNC_STATE_UNSYNCHRONIZED\x10\x02*\xf0\x01\n" +
+	"\fResultStatus\x12\x1d\n" +
+	"\x19RESULT_STATUS_UNSPECIFIED\x10\x00\x12\x1a\n" +
+	"\x16RESULT_STATUS_ACCEPTED\x10\x01\x12\x1f\n" +
+	"\x1bRESULT_STATUS_BAD_SIGNATURE\x10\x02\x12 \n" +
+	"\x1cRESULT_STATUS_UNKNOWN_SERVER\x10\x03\x12 \n" +
+	"\x1cRESULT_STATUS_INTERNAL_ERROR\x10\x04\x12\x1d\n" +
+	"\x19RESULT_STATUS_QUARANTINED\x10\x05\x12!\n" +
+	"\x1dRESULT_STATUS_CLOCK_UNCERTAIN\x10\x062\xe4\x02\n" +
 	"\x0eMonitorService\x12J\n" +
 	"\tGetConfig\x12\x1c.monitor.v2.GetConfigRequest\x1a\x1d.monitor.v2.GetConfigResponse\"\x00\x12M\n" +
 	"\n" +
 	"GetServers\x12\x1d.monitor.v2.GetServersRequest\x1a\x1e.monitor.v2.GetServersResponse\"\x00\x12V\n" +
-	"\rSubmitResults\x12 .monitor.v2.SubmitResultsRequest\x1a!.monitor.v2.SubmitResultsResponse\"\x00B\x9f\x01\n" +
+	"\rSubmitResults\x12 .monitor.v2.SubmitResultsRequest\x1a!.monitor.v2.SubmitResultsResponse\"\x00\x12_\n" +
+	"\x10SubmitTraceroute\x12#.monitor.v2.SubmitTracerouteRequest\x1a$.monitor.v2.SubmitTracerouteResponse\"\x00B\x9f\x01\n" +
 	"\x0ecom.monitor.v2B\x13MonitorManagerProtoP\x01Z/go.ntppool.org/monitor/gen/monitor/v2;monitorv2\xa2\x02\x03MXX\xaa\x02\n" +
 	"Monitor.V2\xca\x02\n" +
 	"Monitor\\V2\xe2\x02\x16Monitor\\V2\\GPBMetadata\xea\x02\vMonitor::V2b\x06proto3"
@@ -778,42 +1390,72 @@ func file_monitor_v2_monitor_manager_proto_rawDescGZIP() []byte {
 	return file_monitor_v2_monitor_manager_proto_rawDescData
 }
 
-var file_monitor_v2_monitor_manager_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_monitor_v2_monitor_manager_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_monitor_v2_monitor_manager_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
 var file_monitor_v2_monitor_manager_proto_goTypes = []any{
-	(*GetConfigRequest)(nil),      // 0: monitor.v2.GetConfigRequest
-	(*GetServersRequest)(nil),     // 1: monitor.v2.GetServersRequest
-	(*GetConfigResponse)(nil),     // 2: monitor.v2.GetConfigResponse
-	(*MQTTConfig)(nil),            // 3: monitor.v2.MQTTConfig
-	(*Server)(nil),                // 4: monitor.v2.Server
-	(*GetServersResponse)(nil),    // 5: monitor.v2.GetServersResponse
-	(*SubmitResultsRequest)(nil),  // 6: monitor.v2.SubmitResultsRequest
-	(*SubmitResultsResponse)(nil), // 7: monitor.v2.SubmitResultsResponse
-	(*NTPPacket)(nil),             // 8: monitor.v2.NTPPacket
-	(*ServerStatus)(nil),          // 9: monitor.v2.ServerStatus
-	(*timestamppb.Timestamp)(nil), // 10: google.protobuf.Timestamp
-	(*durationpb.Duration)(nil),   // 11: google.protobuf.Duration
+	(KernelSyncState)(0),             // 0: monitor.v2.KernelSyncState
+	(ResultStatus)(0),                // 1: monitor.v2.ResultStatus
+	(*GetConfigRequest)(nil),         // 2: monitor.v2.GetConfigRequest
+	(*GetServersRequest)(nil),        // 3: monitor.v2.GetServersRequest
+	(*GetConfigResponse)(nil),        // 4: monitor.v2.GetConfigResponse
+	(*MQTTConfig)(nil),               // 5: monitor.v2.MQTTConfig
+	(*Server)(nil),                   // 6: monitor.v2.Server
+	(*GetServersResponse)(nil),       // 7: monitor.v2.GetServersResponse
+	(*SubmitResultsRequest)(nil),     // 8: monitor.v2.SubmitResultsRequest
+	(*ClockQuality)(nil),             // 9: monitor.v2.ClockQuality
+	(*SubmitResultsResponse)(nil),    // 10: monitor.v2.SubmitResultsResponse
+	(*SubmitTracerouteRequest)(nil),  // 11: monitor.v2.SubmitTracerouteRequest
+	(*SubmitTracerouteResponse)(nil), // 12: monitor.v2.SubmitTracerouteResponse
+	(*NTPPacket)(nil),                // 13: monitor.v2.NTPPacket
+	(*ServerStatus)(nil),             // 14: monitor.v2.ServerStatus
+	(*Sample)(nil),                   // 15: monitor.v2.Sample
+	(*timestamppb.Timestamp)(nil),    // 16: google.protobuf.Timestamp
+	(*durationpb.Duration)(nil),      // 17: google.protobuf.Duration
 }
 var file_monitor_v2_monitor_manager_proto_depIdxs = []int32{
-	3,  // 0: monitor.v2.GetConfigResponse.mqtt_config:type_name -> monitor.v2.MQTTConfig
-	2,  // 1: monitor.v2.GetServersResponse.config:type_name -> monitor.v2.GetConfigResponse
-	4,  // 2: monitor.v2.GetServersResponse.servers:type_name -> monitor.v2.Server
-	9,  // 3: monitor.v2.SubmitResultsRequest.list:type_name -> monitor.v2.ServerStatus
-	10, // 4: monitor.v2.NTPPacket.t1:type_name -> google.protobuf.Timestamp
-	10, // 5: monitor.v2.NTPPacket.t4:type_name -> google.protobuf.Timestamp
-	10, // 6: monitor.v2.ServerStatus.ts:type_name -> google.protobuf.Timestamp
-	11, // 7: monitor.v2.ServerStatus.offset:type_name -> google.protobuf.Duration
-	11, // 8: monitor.v2.ServerStatus.rtt:type_name -> google.protobuf.Duration
-	0,  // 9: monitor.v2.MonitorService.GetConfig:input_type -> monitor.v2.GetConfigRequest
-	1,  // 10: monitor.v2.MonitorService.GetServers:input_type -> monitor.v2.GetServersRequest
-	6,  // 11: monitor.v2.MonitorService.SubmitResults:input_type -> monitor.v2.SubmitResultsRequest
-	2,  // 12: monitor.v2.MonitorService.GetConfig:output_type -> monitor.v2.GetConfigResponse
-	5,  // 13: monitor.v2.MonitorService.GetServers:output_type -> monitor.v2.GetServersResponse
-	7,  // 14: monitor.v2.MonitorService.SubmitResults:output_type -> monitor.v2.SubmitResultsResponse
-	12, // [12:15] is the sub-list for method output_type
-	9,  // [9:12] is the sub-list for method input_type
-	9,  // [9:9] is the sub-list for extension type_name
-	9,  // [9:9] is the sub-list for extension extendee
-	0,  // [0:9] is the sub-list for field type_name
+	5,  // 0: monitor.v2.GetConfigResponse.mqtt_config:type_name -> monitor.v2.MQTTConfig
+	4,  // 1: monitor.v2.GetServersResponse.config:type_name -> monitor.v2.GetConfigResponse
+	6,  // 2: monitor.v2.GetServersResponse.servers:type_name -> monitor.v2.Server
+	14, // 3: monitor.v2.SubmitResultsRequest.list:type_name -> monitor.v2.ServerStatus
+	9,  // 4: monitor.v2.SubmitResultsRequest.clock:type_name -> monitor.v2.ClockQuality
+	16, // 5: monitor.v2.ClockQuality.ts:type_name -> google.protobuf.Timestamp
+	17, // 6: monitor.v2.ClockQuality.offset:type_name -> google.protobuf.Duration
+	17, // 7: monitor.v2.ClockQuality.dispersion:type_name -> google.protobuf.Duration
+	0,  // 8: monitor.v2.ClockQuality.kernel_sync:type_name -> monitor.v2.KernelSyncState
+	17, // 9: monitor.v2.ClockQuality.kernel_max_error:type_name -> google.protobuf.Duration
+	17, // 10: monitor.v2.ClockQuality.kernel_est_error:type_name -> google.protobuf.Duration
+	1,  // 11: monitor.v2.SubmitResultsResponse.results:type_name -> monitor.v2.ResultStatus
+	16, // 12: monitor.v2.SubmitTracerouteRequest.ts:type_name -> google.protobuf.Timestamp
+	16, // 13: monitor.v2.NTPPacket.t1:type_name -> google.protobuf.Timestamp
+	16, // 14: monitor.v2.NTPPacket.t4:type_name -> google.protobuf.Timestamp
+	16, // 15: monitor.v2.ServerStatus.ts:type_name -> google.protobuf.Timestamp
+	17, // 16: monitor.v2.ServerStatus.offset:type_name -> google.protobuf.Duration
+	17, // 17: monitor.v2.ServerStatus.rtt:type_name -> google.protobuf.Duration
+	13, // 18: monitor.v2.ServerStatus.responses:type_name -> monitor.v2.NTPPacket
+	17, // 19: monitor.v2.ServerStatus.precision:type_name -> google.protobuf.Duration
+	17, // 20: monitor.v2.ServerStatus.root_delay:type_name -> google.protobuf.Duration
+	17, // 21: monitor.v2.ServerStatus.root_dispersion:type_name -> google.protobuf.Duration
+	16, // 22: monitor.v2.ServerStatus.reference_time:type_name -> google.protobuf.Timestamp
+	16, // 23: monitor.v2.ServerStatus.t2:type_name -> google.protobuf.Timestamp
+	16, // 24: monitor.v2.ServerStatus.t3:type_name -> google.protobuf.Timestamp
+	17, // 25: monitor.v2.ServerStatus.root_distance:type_name -> google.protobuf.Duration
+	17, // 26: monitor.v2.ServerStatus.reference_age:type_name -> google.protobuf.Duration
+	15, // 27: monitor.v2.ServerStatus.samples:type_name -> monitor.v2.Sample
+	17, // 28: monitor.v2.Sample.offset:type_name -> google.protobuf.Duration
+	17, // 29: monitor.v2.Sample.rtt:type_name -> google.protobuf.Duration
+	2,  // 30: monitor.v2.MonitorService.GetConfig:input_type -> monitor.v2.GetConfigRequest
+	3,  // 31: monitor.v2.MonitorService.GetServers:input_type -> monitor.v2.GetServersRequest
+	8,  // 32: monitor.v2.MonitorService.SubmitResults:input_type -> monitor.v2.SubmitResultsRequest
+	11, // 33: monitor.v2.MonitorService.SubmitTraceroute:input_type -> monitor.v2.SubmitTracerouteRequest
+	4,  // 34: monitor.v2.MonitorService.GetConfig:output_type -> monitor.v2.GetConfigResponse
+	7,  // 35: monitor.v2.MonitorService.GetServers:output_type -> monitor.v2.GetServersResponse
+	10, // 36: monitor.v2.MonitorService.SubmitResults:output_type -> monitor.v2.SubmitResultsResponse
+	12, // 37: monitor.v2.MonitorService.SubmitTraceroute:output_type -> monitor.v2.SubmitTracerouteResponse
+	34, // [34:38] is the sub-list for method output_type
+	30, // [30:34] is the sub-list for method input_type
+	30, // [30:30] is the sub-list for extension type_name
+	30, // [30:30] is the sub-list for extension extendee
+	0,  // [0:30] is the sub-list for field type_name
 }
 
 func init() { file_monitor_v2_monitor_manager_proto_init() }
@@ -826,13 +1468,14 @@ func file_monitor_v2_monitor_manager_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_monitor_v2_monitor_manager_proto_rawDesc), len(file_monitor_v2_monitor_manager_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   10,
+			NumEnums:      2,
+			NumMessages:   14,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_monitor_v2_monitor_manager_proto_goTypes,
 		DependencyIndexes: file_monitor_v2_monitor_manager_proto_depIdxs,
+		EnumInfos:         file_monitor_v2_monitor_manager_proto_enumTypes,
 		MessageInfos:      file_monitor_v2_monitor_manager_proto_msgTypes,
 	}.Build()
 	File_monitor_v2_monitor_manager_proto = out.File
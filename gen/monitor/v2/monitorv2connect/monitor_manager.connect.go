@@ -42,6 +42,9 @@ const (
 	// MonitorServiceSubmitResultsProcedure is the fully-qualified name of the MonitorService's
 	// SubmitResults RPC.
 	MonitorServiceSubmitResultsProcedure = "/monitor.v2.MonitorService/SubmitResults"
+	// MonitorServiceSubmitTracerouteProcedure is the fully-qualified name of the MonitorService's
+	// SubmitTraceroute RPC.
+	MonitorServiceSubmitTracerouteProcedure = "/monitor.v2.MonitorService/SubmitTraceroute"
 )
 
 // MonitorServiceClient is a client for the monitor.v2.MonitorService service.
@@ -51,6 +54,9 @@ type MonitorServiceClient interface {
 	GetServers(context.Context, *connect.Request[v2.GetServersRequest]) (*connect.Response[v2.GetServersResponse], error)
 	// SubmitResults returns the specified list ServerStatus to the monitoring server
 	SubmitResults(context.Context, *connect.Request[v2.SubmitResultsRequest]) (*connect.Response[v2.SubmitResultsResponse], error)
+	// SubmitTraceroute returns the traceroute output for a server
+	// that was sent with trace set
+	SubmitTraceroute(context.Context, *connect.Request[v2.SubmitTracerouteRequest]) (*connect.Response[v2.SubmitTracerouteResponse], error)
 }
 
 // NewMonitorServiceClient constructs a client for the monitor.v2.MonitorService service. By
@@ -82,14 +88,21 @@ func NewMonitorServiceClient(httpClient connect.HTTPClient, baseURL string, opts
 			connect.WithSchema(monitorServiceMethods.ByName("SubmitResults")),
 			connect.WithClientOptions(opts...),
 		),
+		submitTraceroute: connect.NewClient[v2.SubmitTracerouteRequest, v2.SubmitTracerouteResponse](
+			httpClient,
+			baseURL+MonitorServiceSubmitTracerouteProcedure,
+			connect.WithSchema(monitorServiceMethods.ByName("SubmitTraceroute")),
+			connect.WithClientOptions(opts...),
+		),
 	}
 }
 
 // monitorServiceClient implements MonitorServiceClient.
 type monitorServiceClient struct {
-	getConfig     *connect.Client[v2.GetConfigRequest, v2.GetConfigResponse]
-	getServers    *connect.Client[v2.GetServersRequest, v2.GetServersResponse]
-	submitResults *connect.Client[v2.SubmitResultsRequest, v2.SubmitResultsResponse]
+	getConfig        *connect.Client[v2.GetConfigRequest, v2.GetConfigResponse]
+	getServers       *connect.Client[v2.GetServersRequest, v2.GetServersResponse]
+	submitResults    *connect.Client[v2.SubmitResultsRequest, v2.SubmitResultsResponse]
+	submitTraceroute *connect.Client[v2.SubmitTracerouteRequest, v2.SubmitTracerouteResponse]
 }
 
 // GetConfig calls monitor.v2.MonitorService.GetConfig.
@@ -107,6 +120,11 @@ func (c *monitorServiceClient) SubmitResults(ctx context.Context, req *connect.R
 	return c.submitResults.CallUnary(ctx, req)
 }
 
+// SubmitTraceroute calls monitor.v2.MonitorService.SubmitTraceroute.
+func (c *monitorServiceClient) SubmitTraceroute(ctx context.Context, req *connect.Request[v2.SubmitTracerouteRequest]) (*connect.Response[v2.SubmitTracerouteResponse], error) {
+	return c.submitTraceroute.CallUnary(ctx, req)
+}
+
 // MonitorServiceHandler is an implementation of the monitor.v2.MonitorService service.
 type MonitorServiceHandler interface {
 	GetConfig(context.Context, *connect.Request[v2.GetConfigRequest]) (*connect.Response[v2.GetConfigResponse], error)
@@ -114,6 +132,9 @@ type MonitorServiceHandler interface {
 	GetServers(context.Context, *connect.Request[v2.GetServersRequest]) (*connect.Response[v2.GetServersResponse], error)
 	// SubmitResults returns the specified list ServerStatus to the monitoring server
 	SubmitResults(context.Context, *connect.Request[v2.SubmitResultsRequest]) (*connect.Response[v2.SubmitResultsResponse], error)
+	// SubmitTraceroute returns the traceroute output for a server
+	// that was sent with trace set
+	SubmitTraceroute(context.Context, *connect.Request[v2.SubmitTracerouteRequest]) (*connect.Response[v2.SubmitTracerouteResponse], error)
 }
 
 // NewMonitorServiceHandler builds an HTTP handler from the service implementation. It returns the
@@ -141,6 +162,12 @@ func NewMonitorServiceHandler(svc MonitorServiceHandler, opts ...connect.Handler
 		connect.WithSchema(monitorServiceMethods.ByName("SubmitResults")),
 		connect.WithHandlerOptions(opts...),
 	)
+	monitorServiceSubmitTracerouteHandler := connect.NewUnaryHandler(
+		MonitorServiceSubmitTracerouteProcedure,
+		svc.SubmitTraceroute,
+		connect.WithSchema(monitorServiceMethods.ByName("SubmitTraceroute")),
+		connect.WithHandlerOptions(opts...),
+	)
 	return "/monitor.v2.MonitorService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
 		case MonitorServiceGetConfigProcedure:
@@ -149,6 +176,8 @@ func NewMonitorServiceHandler(svc MonitorServiceHandler, opts ...connect.Handler
 			monitorServiceGetServersHandler.ServeHTTP(w, r)
 		case MonitorServiceSubmitResultsProcedure:
 			monitorServiceSubmitResultsHandler.ServeHTTP(w, r)
+		case MonitorServiceSubmitTracerouteProcedure:
+			monitorServiceSubmitTracerouteHandler.ServeHTTP(w, r)
 		default:
 			http.NotFound(w, r)
 		}
@@ -169,3 +198,7 @@ func (UnimplementedMonitorServiceHandler) GetServers(context.Context, *connect.R
 func (UnimplementedMonitorServiceHandler) SubmitResults(context.Context, *connect.Request[v2.SubmitResultsRequest]) (*connect.Response[v2.SubmitResultsResponse], error) {
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("monitor.v2.MonitorService.SubmitResults is not implemented"))
 }
+
+func (UnimplementedMonitorServiceHandler) SubmitTraceroute(context.Context, *connect.Request[v2.SubmitTracerouteRequest]) (*connect.Response[v2.SubmitTracerouteResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("monitor.v2.MonitorService.SubmitTraceroute is not implemented"))
+}
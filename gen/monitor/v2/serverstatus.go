@@ -1,8 +1,12 @@
 package monitorv2
 
 import (
+	"encoding/binary"
+	"fmt"
 	"net/netip"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"go.ntppool.org/common/logger"
 )
@@ -27,3 +31,21 @@ func (ss *ServerStatus) SetIP(ip *netip.Addr) {
 func (ss *ServerStatus) GetIP() *netip.Addr {
 	return bytesToIP(ss.IpBytes)
 }
+
+// ReferenceIDString formats the reference ID as text for stratum 0
+// and 1 (kiss codes and reference clocks) and as an IPv4 address (or
+// IPv6 address hash) for higher strata.
+func (ss *ServerStatus) ReferenceIDString() string {
+	if ss.ReferenceId == 0 {
+		return ""
+	}
+	b := binary.BigEndian.AppendUint32(nil, ss.ReferenceId)
+	if ss.Stratum > 1 {
+		return netip.AddrFrom4([4]byte(b)).String()
+	}
+	str := strings.TrimRight(string(b), "\x00")
+	if !utf8.ValidString(str) {
+		return fmt.Sprintf("%#x", ss.ReferenceId)
+	}
+	return str
+}
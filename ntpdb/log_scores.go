@@ -4,6 +4,12 @@ import "time"
 
 // maintained in monitor/ntpdb
 
+// MaxBatchAge is how old a batch can be when it's submitted. Agents
+// spool batches they couldn't submit and replay them later, so a
+// batch can arrive after newer ones; those are accepted within this
+// window and stored after log scores with later timestamps.
+const MaxBatchAge = 4 * time.Hour
+
 type LogScoreAttributes struct {
 	Leap       int8   `json:"leap,omitempty"`
 	Stratum    int8   `json:"stratum,omitempty"`
@@ -11,6 +17,37 @@ type LogScoreAttributes struct {
 	Error      string `json:"error,omitempty"`
 	Warning    string `json:"warning,omitempty"`
 
+	// from the NTP response header; durations in seconds
+	Precision      float64    `json:"precision,omitempty"`
+	RefID          string     `json:"refid,omitempty"`
+	RootDelay      float64    `json:"root_delay,omitempty"`
+	RootDispersion float64    `json:"root_dispersion,omitempty"`
+	ReferenceTime  *time.Time `json:"reference_time,omitempty"`
+	T2             *time.Time `json:"t2,omitempty"`
+	T3             *time.Time `json:"t3,omitempty"`
+
+	// from the samples in the check; jitter in seconds
+	LossRate float64 `json:"loss_rate,omitempty"`
+	Jitter   float64 `json:"jitter,omitempty"`
+
+	// batch the result was submitted in
+	BatchID string `json:"batch_id,omitempty"`
+	// consensus offset spread (seconds) and how many monitors it's from
+	Spread   float64 `json:"spread,omitempty"`
+	Monitors int     `json:"monitors,omitempty"`
+
+	// statusscore policy version; 0 (omitted) for the built-in policy
+	PolicyVersion int `json:"policy_version,omitempty"`
+	// step added to the running score (the timeout step can be
+	// weighted) and the max score it was capped at, so the score
+	// can be replayed; not recorded for older results
+	ScoreStep *float64 `json:"score_step,omitempty"`
+	MaxScore  *float64 `json:"max_score,omitempty"`
+
+	// timeout from a batch with a monitor side outage; stored but
+	// not scored
+	Quarantined bool `json:"quarantined,omitempty"`
+
 	FromLSID int `json:"from_ls_id,omitempty"`
 	FromSSID int `json:"from_ss_id,omitempty"`
 }
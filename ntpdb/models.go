@@ -275,6 +275,15 @@ type Monitor struct {
 	IsCurrent     sql.NullBool          `json:"is_current"`
 }
 
+type MonitorClockCheck struct {
+	MonitorID    uint32       `json:"monitor_id"`
+	CheckedOn    time.Time    `json:"checked_on"`
+	Bias         float64      `json:"bias"`
+	Servers      uint32       `json:"servers"`
+	Consistency  float64      `json:"consistency"`
+	FlaggedSince sql.NullTime `json:"flagged_since"`
+}
+
 type Server struct {
 	ID             uint32           `json:"id"`
 	Ip             string           `json:"ip"`
@@ -295,6 +304,15 @@ type Server struct {
 	Flags          string           `json:"flags"`
 }
 
+type ServerOffset struct {
+	ServerID   uint32    `json:"server_id"`
+	Ts         time.Time `json:"ts"`
+	Offset     float64   `json:"offset"`
+	Spread     float64   `json:"spread"`
+	Monitors   uint16    `json:"monitors"`
+	ModifiedOn time.Time `json:"modified_on"`
+}
+
 type ServerScore struct {
 	ID                       uint64             `json:"id"`
 	MonitorID                uint32             `json:"monitor_id"`
@@ -310,4 +328,5 @@ type ServerScore struct {
 	ConstraintViolationSince sql.NullTime       `json:"constraint_violation_since"`
 	LastConstraintCheck      sql.NullTime       `json:"last_constraint_check"`
 	PauseReason              sql.NullString     `json:"pause_reason"`
+	LeaseExpires             sql.NullTime       `json:"lease_expires"`
 }
@@ -7,6 +7,7 @@ package ntpdb
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -35,6 +36,52 @@ func NewQuerierTxWithTracing(base QuerierTx, instance string, spanDecorator ...f
 	return d
 }
 
+// AddMonitorLeasesIssued implements QuerierTx
+func (_d QuerierTxWithTracing) AddMonitorLeasesIssued(ctx context.Context, arg AddMonitorLeasesIssuedParams) (err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.AddMonitorLeasesIssued")
+	defer func() {
+		if _d._spanDecorator != nil {
+			_d._spanDecorator(_span, map[string]interface{}{
+				"ctx": ctx,
+				"arg": arg}, map[string]interface{}{
+				"err": err})
+		} else if err != nil {
+			_span.RecordError(err)
+			_span.SetStatus(_codes.Error, err.Error())
+			_span.SetAttributes(
+				attribute.String("event", "error"),
+				attribute.String("message", err.Error()),
+			)
+		}
+
+		_span.End()
+	}()
+	return _d.QuerierTx.AddMonitorLeasesIssued(ctx, arg)
+}
+
+// AddMonitorLeasesReturned implements QuerierTx
+func (_d QuerierTxWithTracing) AddMonitorLeasesReturned(ctx context.Context, arg AddMonitorLeasesReturnedParams) (err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.AddMonitorLeasesReturned")
+	defer func() {
+		if _d._spanDecorator != nil {
+			_d._spanDecorator(_span, map[string]interface{}{
+				"ctx": ctx,
+				"arg": arg}, map[string]interface{}{
+				"err": err})
+		} else if err != nil {
+			_span.RecordError(err)
+			_span.SetStatus(_codes.Error, err.Error())
+			_span.SetAttributes(
+				attribute.String("event", "error"),
+				attribute.String("message", err.Error()),
+			)
+		}
+
+		_span.End()
+	}()
+	return _d.QuerierTx.AddMonitorLeasesReturned(ctx, arg)
+}
+
 // Begin implements QuerierTx
 func (_d QuerierTxWithTracing) Begin(ctx context.Context) (q1 QuerierTx, err error) {
 	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.Begin")
@@ -81,6 +128,29 @@ func (_d QuerierTxWithTracing) ClearServerScoreConstraintViolation(ctx context.C
 	return _d.QuerierTx.ClearServerScoreConstraintViolation(ctx, arg)
 }
 
+// ClearServerScoreLease implements QuerierTx
+func (_d QuerierTxWithTracing) ClearServerScoreLease(ctx context.Context, id uint64) (err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.ClearServerScoreLease")
+	defer func() {
+		if _d._spanDecorator != nil {
+			_d._spanDecorator(_span, map[string]interface{}{
+				"ctx": ctx,
+				"id":  id}, map[string]interface{}{
+				"err": err})
+		} else if err != nil {
+			_span.RecordError(err)
+			_span.SetStatus(_codes.Error, err.Error())
+			_span.SetAttributes(
+				attribute.String("event", "error"),
+				attribute.String("message", err.Error()),
+			)
+		}
+
+		_span.End()
+	}()
+	return _d.QuerierTx.ClearServerScoreLease(ctx, id)
+}
+
 // Commit implements QuerierTx
 func (_d QuerierTxWithTracing) Commit(ctx context.Context) (err error) {
 	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.Commit")
@@ -103,6 +173,29 @@ func (_d QuerierTxWithTracing) Commit(ctx context.Context) (err error) {
 	return _d.QuerierTx.Commit(ctx)
 }
 
+// DeleteMonitorBatches implements QuerierTx
+func (_d QuerierTxWithTracing) DeleteMonitorBatches(ctx context.Context, arg DeleteMonitorBatchesParams) (err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.DeleteMonitorBatches")
+	defer func() {
+		if _d._spanDecorator != nil {
+			_d._spanDecorator(_span, map[string]interface{}{
+				"ctx": ctx,
+				"arg": arg}, map[string]interface{}{
+				"err": err})
+		} else if err != nil {
+			_span.RecordError(err)
+			_span.SetStatus(_codes.Error, err.Error())
+			_span.SetAttributes(
+				attribute.String("event", "error"),
+				attribute.String("message", err.Error()),
+			)
+		}
+
+		_span.End()
+	}()
+	return _d.QuerierTx.DeleteMonitorBatches(ctx, arg)
+}
+
 // DeleteServerScore implements QuerierTx
 func (_d QuerierTxWithTracing) DeleteServerScore(ctx context.Context, arg DeleteServerScoreParams) (err error) {
 	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.DeleteServerScore")
@@ -126,6 +219,77 @@ func (_d QuerierTxWithTracing) DeleteServerScore(ctx context.Context, arg Delete
 	return _d.QuerierTx.DeleteServerScore(ctx, arg)
 }
 
+// GetActiveMonitors implements QuerierTx
+func (_d QuerierTxWithTracing) GetActiveMonitors(ctx context.Context) (ma1 []Monitor, err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetActiveMonitors")
+	defer func() {
+		if _d._spanDecorator != nil {
+			_d._spanDecorator(_span, map[string]interface{}{
+				"ctx": ctx}, map[string]interface{}{
+				"ma1": ma1,
+				"err": err})
+		} else if err != nil {
+			_span.RecordError(err)
+			_span.SetStatus(_codes.Error, err.Error())
+			_span.SetAttributes(
+				attribute.String("event", "error"),
+				attribute.String("message", err.Error()),
+			)
+		}
+
+		_span.End()
+	}()
+	return _d.QuerierTx.GetActiveMonitors(ctx)
+}
+
+// GetAvailableMonitors implements QuerierTx
+func (_d QuerierTxWithTracing) GetAvailableMonitors(ctx context.Context, arg GetAvailableMonitorsParams) (ga1 []GetAvailableMonitorsRow, err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetAvailableMonitors")
+	defer func() {
+		if _d._spanDecorator != nil {
+			_d._spanDecorator(_span, map[string]interface{}{
+				"ctx": ctx,
+				"arg": arg}, map[string]interface{}{
+				"ga1": ga1,
+				"err": err})
+		} else if err != nil {
+			_span.RecordError(err)
+			_span.SetStatus(_codes.Error, err.Error())
+			_span.SetAttributes(
+				attribute.String("event", "error"),
+				attribute.String("message", err.Error()),
+			)
+		}
+
+		_span.End()
+	}()
+	return _d.QuerierTx.GetAvailableMonitors(ctx, arg)
+}
+
+// GetFirstLogScoreID implements QuerierTx
+func (_d QuerierTxWithTracing) GetFirstLogScoreID(ctx context.Context, ts time.Time) (u1 uint64, err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetFirstLogScoreID")
+	defer func() {
+		if _d._spanDecorator != nil {
+			_d._spanDecorator(_span, map[string]interface{}{
+				"ctx": ctx,
+				"ts":  ts}, map[string]interface{}{
+				"u1":  u1,
+				"err": err})
+		} else if err != nil {
+			_span.RecordError(err)
+			_span.SetStatus(_codes.Error, err.Error())
+			_span.SetAttributes(
+				attribute.String("event", "error"),
+				attribute.String("message", err.Error()),
+			)
+		}
+
+		_span.End()
+	}()
+	return _d.QuerierTx.GetFirstLogScoreID(ctx, ts)
+}
+
 // GetMinLogScoreID implements QuerierTx
 func (_d QuerierTxWithTracing) GetMinLogScoreID(ctx context.Context) (u1 uint64, err error) {
 	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetMinLogScoreID")
@@ -149,6 +313,101 @@ func (_d QuerierTxWithTracing) GetMinLogScoreID(ctx context.Context) (u1 uint64,
 	return _d.QuerierTx.GetMinLogScoreID(ctx)
 }
 
+// GetMonitorBatchResults implements QuerierTx
+func (_d QuerierTxWithTracing) GetMonitorBatchResults(ctx context.Context, arg GetMonitorBatchResultsParams) (ba1 []byte, err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetMonitorBatchResults")
+	defer func() {
+		if _d._spanDecorator != nil {
+			_d._spanDecorator(_span, map[string]interface{}{
+				"ctx": ctx,
+				"arg": arg}, map[string]interface{}{
+				"ba1": ba1,
+				"err": err})
+		} else if err != nil {
+			_span.RecordError(err)
+			_span.SetStatus(_codes.Error, err.Error())
+			_span.SetAttributes(
+				attribute.String("event", "error"),
+				attribute.String("message", err.Error()),
+			)
+		}
+
+		_span.End()
+	}()
+	return _d.QuerierTx.GetMonitorBatchResults(ctx, arg)
+}
+
+// GetMonitorByID implements QuerierTx
+func (_d QuerierTxWithTracing) GetMonitorByID(ctx context.Context, id uint32) (m1 Monitor, err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetMonitorByID")
+	defer func() {
+		if _d._spanDecorator != nil {
+			_d._spanDecorator(_span, map[string]interface{}{
+				"ctx": ctx,
+				"id":  id}, map[string]interface{}{
+				"m1":  m1,
+				"err": err})
+		} else if err != nil {
+			_span.RecordError(err)
+			_span.SetStatus(_codes.Error, err.Error())
+			_span.SetAttributes(
+				attribute.String("event", "error"),
+				attribute.String("message", err.Error()),
+			)
+		}
+
+		_span.End()
+	}()
+	return _d.QuerierTx.GetMonitorByID(ctx, id)
+}
+
+// GetMonitorClockChecks implements QuerierTx
+func (_d QuerierTxWithTracing) GetMonitorClockChecks(ctx context.Context) (ma1 []MonitorClockCheck, err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetMonitorClockChecks")
+	defer func() {
+		if _d._spanDecorator != nil {
+			_d._spanDecorator(_span, map[string]interface{}{
+				"ctx": ctx}, map[string]interface{}{
+				"ma1": ma1,
+				"err": err})
+		} else if err != nil {
+			_span.RecordError(err)
+			_span.SetStatus(_codes.Error, err.Error())
+			_span.SetAttributes(
+				attribute.String("event", "error"),
+				attribute.String("message", err.Error()),
+			)
+		}
+
+		_span.End()
+	}()
+	return _d.QuerierTx.GetMonitorClockChecks(ctx)
+}
+
+// GetMonitorLeaseStats implements QuerierTx
+func (_d QuerierTxWithTracing) GetMonitorLeaseStats(ctx context.Context, arg GetMonitorLeaseStatsParams) (g1 GetMonitorLeaseStatsRow, err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetMonitorLeaseStats")
+	defer func() {
+		if _d._spanDecorator != nil {
+			_d._spanDecorator(_span, map[string]interface{}{
+				"ctx": ctx,
+				"arg": arg}, map[string]interface{}{
+				"g1":  g1,
+				"err": err})
+		} else if err != nil {
+			_span.RecordError(err)
+			_span.SetStatus(_codes.Error, err.Error())
+			_span.SetAttributes(
+				attribute.String("event", "error"),
+				attribute.String("message", err.Error()),
+			)
+		}
+
+		_span.End()
+	}()
+	return _d.QuerierTx.GetMonitorLeaseStats(ctx, arg)
+}
+
 // GetMonitorPriority implements QuerierTx
 func (_d QuerierTxWithTracing) GetMonitorPriority(ctx context.Context, serverID uint32) (ga1 []GetMonitorPriorityRow, err error) {
 	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetMonitorPriority")
@@ -173,15 +432,564 @@ func (_d QuerierTxWithTracing) GetMonitorPriority(ctx context.Context, serverID
 	return _d.QuerierTx.GetMonitorPriority(ctx, serverID)
 }
 
-// GetMonitorTLSNameIP implements QuerierTx
-func (_d QuerierTxWithTracing) GetMonitorTLSNameIP(ctx context.Context, arg GetMonitorTLSNameIPParams) (g1 GetMonitorTLSNameIPRow, err error) {
-	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetMonitorTLSNameIP")
+// GetMonitorResponseCounts implements QuerierTx
+func (_d QuerierTxWithTracing) GetMonitorResponseCounts(ctx context.Context, arg GetMonitorResponseCountsParams) (g1 GetMonitorResponseCountsRow, err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetMonitorResponseCounts")
+	defer func() {
+		if _d._spanDecorator != nil {
+			_d._spanDecorator(_span, map[string]interface{}{
+				"ctx": ctx,
+				"arg": arg}, map[string]interface{}{
+				"g1":  g1,
+				"err": err})
+		} else if err != nil {
+			_span.RecordError(err)
+			_span.SetStatus(_codes.Error, err.Error())
+			_span.SetAttributes(
+				attribute.String("event", "error"),
+				attribute.String("message", err.Error()),
+			)
+		}
+
+		_span.End()
+	}()
+	return _d.QuerierTx.GetMonitorResponseCounts(ctx, arg)
+}
+
+// GetMonitorServerOffsets implements QuerierTx
+func (_d QuerierTxWithTracing) GetMonitorServerOffsets(ctx context.Context, ts time.Time) (ga1 []GetMonitorServerOffsetsRow, err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetMonitorServerOffsets")
+	defer func() {
+		if _d._spanDecorator != nil {
+			_d._spanDecorator(_span, map[string]interface{}{
+				"ctx": ctx,
+				"ts":  ts}, map[string]interface{}{
+				"ga1": ga1,
+				"err": err})
+		} else if err != nil {
+			_span.RecordError(err)
+			_span.SetStatus(_codes.Error, err.Error())
+			_span.SetAttributes(
+				attribute.String("event", "error"),
+				attribute.String("message", err.Error()),
+			)
+		}
+
+		_span.End()
+	}()
+	return _d.QuerierTx.GetMonitorServerOffsets(ctx, ts)
+}
+
+// GetMonitorTLSNameIP implements QuerierTx
+func (_d QuerierTxWithTracing) GetMonitorTLSNameIP(ctx context.Context, arg GetMonitorTLSNameIPParams) (g1 GetMonitorTLSNameIPRow, err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetMonitorTLSNameIP")
+	defer func() {
+		if _d._spanDecorator != nil {
+			_d._spanDecorator(_span, map[string]interface{}{
+				"ctx": ctx,
+				"arg": arg}, map[string]interface{}{
+				"g1":  g1,
+				"err": err})
+		} else if err != nil {
+			_span.RecordError(err)
+			_span.SetStatus(_codes.Error, err.Error())
+			_span.SetAttributes(
+				attribute.String("event", "error"),
+				attribute.String("message", err.Error()),
+			)
+		}
+
+		_span.End()
+	}()
+	return _d.QuerierTx.GetMonitorTLSNameIP(ctx, arg)
+}
+
+// GetMonitorsTLSName implements QuerierTx
+func (_d QuerierTxWithTracing) GetMonitorsTLSName(ctx context.Context, tlsName sql.NullString) (ma1 []Monitor, err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetMonitorsTLSName")
+	defer func() {
+		if _d._spanDecorator != nil {
+			_d._spanDecorator(_span, map[string]interface{}{
+				"ctx":     ctx,
+				"tlsName": tlsName}, map[string]interface{}{
+				"ma1": ma1,
+				"err": err})
+		} else if err != nil {
+			_span.RecordError(err)
+			_span.SetStatus(_codes.Error, err.Error())
+			_span.SetAttributes(
+				attribute.String("event", "error"),
+				attribute.String("message", err.Error()),
+			)
+		}
+
+		_span.End()
+	}()
+	return _d.QuerierTx.GetMonitorsTLSName(ctx, tlsName)
+}
+
+// GetPeerResponseCounts implements QuerierTx
+func (_d QuerierTxWithTracing) GetPeerResponseCounts(ctx context.Context, arg GetPeerResponseCountsParams) (g1 GetPeerResponseCountsRow, err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetPeerResponseCounts")
+	defer func() {
+		if _d._spanDecorator != nil {
+			_d._spanDecorator(_span, map[string]interface{}{
+				"ctx": ctx,
+				"arg": arg}, map[string]interface{}{
+				"g1":  g1,
+				"err": err})
+		} else if err != nil {
+			_span.RecordError(err)
+			_span.SetStatus(_codes.Error, err.Error())
+			_span.SetAttributes(
+				attribute.String("event", "error"),
+				attribute.String("message", err.Error()),
+			)
+		}
+
+		_span.End()
+	}()
+	return _d.QuerierTx.GetPeerResponseCounts(ctx, arg)
+}
+
+// GetReplayBaseLogScore implements QuerierTx
+func (_d QuerierTxWithTracing) GetReplayBaseLogScore(ctx context.Context, arg GetReplayBaseLogScoreParams) (l1 LogScore, err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetReplayBaseLogScore")
+	defer func() {
+		if _d._spanDecorator != nil {
+			_d._spanDecorator(_span, map[string]interface{}{
+				"ctx": ctx,
+				"arg": arg}, map[string]interface{}{
+				"l1":  l1,
+				"err": err})
+		} else if err != nil {
+			_span.RecordError(err)
+			_span.SetStatus(_codes.Error, err.Error())
+			_span.SetAttributes(
+				attribute.String("event", "error"),
+				attribute.String("message", err.Error()),
+			)
+		}
+
+		_span.End()
+	}()
+	return _d.QuerierTx.GetReplayBaseLogScore(ctx, arg)
+}
+
+// GetReplayLogScores implements QuerierTx
+func (_d QuerierTxWithTracing) GetReplayLogScores(ctx context.Context, arg GetReplayLogScoresParams) (la1 []LogScore, err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetReplayLogScores")
+	defer func() {
+		if _d._spanDecorator != nil {
+			_d._spanDecorator(_span, map[string]interface{}{
+				"ctx": ctx,
+				"arg": arg}, map[string]interface{}{
+				"la1": la1,
+				"err": err})
+		} else if err != nil {
+			_span.RecordError(err)
+			_span.SetStatus(_codes.Error, err.Error())
+			_span.SetAttributes(
+				attribute.String("event", "error"),
+				attribute.String("message", err.Error()),
+			)
+		}
+
+		_span.End()
+	}()
+	return _d.QuerierTx.GetReplayLogScores(ctx, arg)
+}
+
+// GetScorerLogScores implements QuerierTx
+func (_d QuerierTxWithTracing) GetScorerLogScores(ctx context.Context, arg GetScorerLogScoresParams) (la1 []LogScore, err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetScorerLogScores")
+	defer func() {
+		if _d._spanDecorator != nil {
+			_d._spanDecorator(_span, map[string]interface{}{
+				"ctx": ctx,
+				"arg": arg}, map[string]interface{}{
+				"la1": la1,
+				"err": err})
+		} else if err != nil {
+			_span.RecordError(err)
+			_span.SetStatus(_codes.Error, err.Error())
+			_span.SetAttributes(
+				attribute.String("event", "error"),
+				attribute.String("message", err.Error()),
+			)
+		}
+
+		_span.End()
+	}()
+	return _d.QuerierTx.GetScorerLogScores(ctx, arg)
+}
+
+// GetScorerLogScoresByTime implements QuerierTx
+func (_d QuerierTxWithTracing) GetScorerLogScoresByTime(ctx context.Context, arg GetScorerLogScoresByTimeParams) (la1 []LogScore, err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetScorerLogScoresByTime")
+	defer func() {
+		if _d._spanDecorator != nil {
+			_d._spanDecorator(_span, map[string]interface{}{
+				"ctx": ctx,
+				"arg": arg}, map[string]interface{}{
+				"la1": la1,
+				"err": err})
+		} else if err != nil {
+			_span.RecordError(err)
+			_span.SetStatus(_codes.Error, err.Error())
+			_span.SetAttributes(
+				attribute.String("event", "error"),
+				attribute.String("message", err.Error()),
+			)
+		}
+
+		_span.End()
+	}()
+	return _d.QuerierTx.GetScorerLogScoresByTime(ctx, arg)
+}
+
+// GetScorerNextLogScoreID implements QuerierTx
+func (_d QuerierTxWithTracing) GetScorerNextLogScoreID(ctx context.Context, logScoreID uint64) (u1 uint64, err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetScorerNextLogScoreID")
+	defer func() {
+		if _d._spanDecorator != nil {
+			_d._spanDecorator(_span, map[string]interface{}{
+				"ctx":        ctx,
+				"logScoreID": logScoreID}, map[string]interface{}{
+				"u1":  u1,
+				"err": err})
+		} else if err != nil {
+			_span.RecordError(err)
+			_span.SetStatus(_codes.Error, err.Error())
+			_span.SetAttributes(
+				attribute.String("event", "error"),
+				attribute.String("message", err.Error()),
+			)
+		}
+
+		_span.End()
+	}()
+	return _d.QuerierTx.GetScorerNextLogScoreID(ctx, logScoreID)
+}
+
+// GetScorerRecentScores implements QuerierTx
+func (_d QuerierTxWithTracing) GetScorerRecentScores(ctx context.Context, arg GetScorerRecentScoresParams) (la1 []LogScore, err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetScorerRecentScores")
+	defer func() {
+		if _d._spanDecorator != nil {
+			_d._spanDecorator(_span, map[string]interface{}{
+				"ctx": ctx,
+				"arg": arg}, map[string]interface{}{
+				"la1": la1,
+				"err": err})
+		} else if err != nil {
+			_span.RecordError(err)
+			_span.SetStatus(_codes.Error, err.Error())
+			_span.SetAttributes(
+				attribute.String("event", "error"),
+				attribute.String("message", err.Error()),
+			)
+		}
+
+		_span.End()
+	}()
+	return _d.QuerierTx.GetScorerRecentScores(ctx, arg)
+}
+
+// GetScorerStatus implements QuerierTx
+func (_d QuerierTxWithTracing) GetScorerStatus(ctx context.Context) (ga1 []GetScorerStatusRow, err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetScorerStatus")
+	defer func() {
+		if _d._spanDecorator != nil {
+			_d._spanDecorator(_span, map[string]interface{}{
+				"ctx": ctx}, map[string]interface{}{
+				"ga1": ga1,
+				"err": err})
+		} else if err != nil {
+			_span.RecordError(err)
+			_span.SetStatus(_codes.Error, err.Error())
+			_span.SetAttributes(
+				attribute.String("event", "error"),
+				attribute.String("message", err.Error()),
+			)
+		}
+
+		_span.End()
+	}()
+	return _d.QuerierTx.GetScorerStatus(ctx)
+}
+
+// GetScorers implements QuerierTx
+func (_d QuerierTxWithTracing) GetScorers(ctx context.Context) (ga1 []GetScorersRow, err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetScorers")
+	defer func() {
+		if _d._spanDecorator != nil {
+			_d._spanDecorator(_span, map[string]interface{}{
+				"ctx": ctx}, map[string]interface{}{
+				"ga1": ga1,
+				"err": err})
+		} else if err != nil {
+			_span.RecordError(err)
+			_span.SetStatus(_codes.Error, err.Error())
+			_span.SetAttributes(
+				attribute.String("event", "error"),
+				attribute.String("message", err.Error()),
+			)
+		}
+
+		_span.End()
+	}()
+	return _d.QuerierTx.GetScorers(ctx)
+}
+
+// GetServer implements QuerierTx
+func (_d QuerierTxWithTracing) GetServer(ctx context.Context, id uint32) (s1 Server, err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetServer")
+	defer func() {
+		if _d._spanDecorator != nil {
+			_d._spanDecorator(_span, map[string]interface{}{
+				"ctx": ctx,
+				"id":  id}, map[string]interface{}{
+				"s1":  s1,
+				"err": err})
+		} else if err != nil {
+			_span.RecordError(err)
+			_span.SetStatus(_codes.Error, err.Error())
+			_span.SetAttributes(
+				attribute.String("event", "error"),
+				attribute.String("message", err.Error()),
+			)
+		}
+
+		_span.End()
+	}()
+	return _d.QuerierTx.GetServer(ctx, id)
+}
+
+// GetServerIP implements QuerierTx
+func (_d QuerierTxWithTracing) GetServerIP(ctx context.Context, ip string) (s1 Server, err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetServerIP")
+	defer func() {
+		if _d._spanDecorator != nil {
+			_d._spanDecorator(_span, map[string]interface{}{
+				"ctx": ctx,
+				"ip":  ip}, map[string]interface{}{
+				"s1":  s1,
+				"err": err})
+		} else if err != nil {
+			_span.RecordError(err)
+			_span.SetStatus(_codes.Error, err.Error())
+			_span.SetAttributes(
+				attribute.String("event", "error"),
+				attribute.String("message", err.Error()),
+			)
+		}
+
+		_span.End()
+	}()
+	return _d.QuerierTx.GetServerIP(ctx, ip)
+}
+
+// GetServerMonitorReviewConfig implements QuerierTx
+func (_d QuerierTxWithTracing) GetServerMonitorReviewConfig(ctx context.Context, serverID uint32) (s1 string, err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetServerMonitorReviewConfig")
+	defer func() {
+		if _d._spanDecorator != nil {
+			_d._spanDecorator(_span, map[string]interface{}{
+				"ctx":      ctx,
+				"serverID": serverID}, map[string]interface{}{
+				"s1":  s1,
+				"err": err})
+		} else if err != nil {
+			_span.RecordError(err)
+			_span.SetStatus(_codes.Error, err.Error())
+			_span.SetAttributes(
+				attribute.String("event", "error"),
+				attribute.String("message", err.Error()),
+			)
+		}
+
+		_span.End()
+	}()
+	return _d.QuerierTx.GetServerMonitorReviewConfig(ctx, serverID)
+}
+
+// GetServerOffset implements QuerierTx
+func (_d QuerierTxWithTracing) GetServerOffset(ctx context.Context, serverID uint32) (s1 ServerOffset, err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetServerOffset")
+	defer func() {
+		if _d._spanDecorator != nil {
+			_d._spanDecorator(_span, map[string]interface{}{
+				"ctx":      ctx,
+				"serverID": serverID}, map[string]interface{}{
+				"s1":  s1,
+				"err": err})
+		} else if err != nil {
+			_span.RecordError(err)
+			_span.SetStatus(_codes.Error, err.Error())
+			_span.SetAttributes(
+				attribute.String("event", "error"),
+				attribute.String("message", err.Error()),
+			)
+		}
+
+		_span.End()
+	}()
+	return _d.QuerierTx.GetServerOffset(ctx, serverID)
+}
+
+// GetServerScore implements QuerierTx
+func (_d QuerierTxWithTracing) GetServerScore(ctx context.Context, arg GetServerScoreParams) (s1 ServerScore, err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetServerScore")
+	defer func() {
+		if _d._spanDecorator != nil {
+			_d._spanDecorator(_span, map[string]interface{}{
+				"ctx": ctx,
+				"arg": arg}, map[string]interface{}{
+				"s1":  s1,
+				"err": err})
+		} else if err != nil {
+			_span.RecordError(err)
+			_span.SetStatus(_codes.Error, err.Error())
+			_span.SetAttributes(
+				attribute.String("event", "error"),
+				attribute.String("message", err.Error()),
+			)
+		}
+
+		_span.End()
+	}()
+	return _d.QuerierTx.GetServerScore(ctx, arg)
+}
+
+// GetServerScoreForUpdate implements QuerierTx
+func (_d QuerierTxWithTracing) GetServerScoreForUpdate(ctx context.Context, arg GetServerScoreForUpdateParams) (s1 ServerScore, err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetServerScoreForUpdate")
+	defer func() {
+		if _d._spanDecorator != nil {
+			_d._spanDecorator(_span, map[string]interface{}{
+				"ctx": ctx,
+				"arg": arg}, map[string]interface{}{
+				"s1":  s1,
+				"err": err})
+		} else if err != nil {
+			_span.RecordError(err)
+			_span.SetStatus(_codes.Error, err.Error())
+			_span.SetAttributes(
+				attribute.String("event", "error"),
+				attribute.String("message", err.Error()),
+			)
+		}
+
+		_span.End()
+	}()
+	return _d.QuerierTx.GetServerScoreForUpdate(ctx, arg)
+}
+
+// GetServerScoreLeases implements QuerierTx
+func (_d QuerierTxWithTracing) GetServerScoreLeases(ctx context.Context, arg GetServerScoreLeasesParams) (ga1 []GetServerScoreLeasesRow, err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetServerScoreLeases")
+	defer func() {
+		if _d._spanDecorator != nil {
+			_d._spanDecorator(_span, map[string]interface{}{
+				"ctx": ctx,
+				"arg": arg}, map[string]interface{}{
+				"ga1": ga1,
+				"err": err})
+		} else if err != nil {
+			_span.RecordError(err)
+			_span.SetStatus(_codes.Error, err.Error())
+			_span.SetAttributes(
+				attribute.String("event", "error"),
+				attribute.String("message", err.Error()),
+			)
+		}
+
+		_span.End()
+	}()
+	return _d.QuerierTx.GetServerScoreLeases(ctx, arg)
+}
+
+// GetServerScoreMonitors implements QuerierTx
+func (_d QuerierTxWithTracing) GetServerScoreMonitors(ctx context.Context, serverID uint32) (ga1 []GetServerScoreMonitorsRow, err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetServerScoreMonitors")
+	defer func() {
+		if _d._spanDecorator != nil {
+			_d._spanDecorator(_span, map[string]interface{}{
+				"ctx":      ctx,
+				"serverID": serverID}, map[string]interface{}{
+				"ga1": ga1,
+				"err": err})
+		} else if err != nil {
+			_span.RecordError(err)
+			_span.SetStatus(_codes.Error, err.Error())
+			_span.SetAttributes(
+				attribute.String("event", "error"),
+				attribute.String("message", err.Error()),
+			)
+		}
+
+		_span.End()
+	}()
+	return _d.QuerierTx.GetServerScoreMonitors(ctx, serverID)
+}
+
+// GetServerZoneShare implements QuerierTx
+func (_d QuerierTxWithTracing) GetServerZoneShare(ctx context.Context, id uint32) (g1 GetServerZoneShareRow, err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetServerZoneShare")
+	defer func() {
+		if _d._spanDecorator != nil {
+			_d._spanDecorator(_span, map[string]interface{}{
+				"ctx": ctx,
+				"id":  id}, map[string]interface{}{
+				"g1":  g1,
+				"err": err})
+		} else if err != nil {
+			_span.RecordError(err)
+			_span.SetStatus(_codes.Error, err.Error())
+			_span.SetAttributes(
+				attribute.String("event", "error"),
+				attribute.String("message", err.Error()),
+			)
+		}
+
+		_span.End()
+	}()
+	return _d.QuerierTx.GetServerZoneShare(ctx, id)
+}
+
+// GetServers implements QuerierTx
+func (_d QuerierTxWithTracing) GetServers(ctx context.Context, arg GetServersParams) (sa1 []Server, err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetServers")
+	defer func() {
+		if _d._spanDecorator != nil {
+			_d._spanDecorator(_span, map[string]interface{}{
+				"ctx": ctx,
+				"arg": arg}, map[string]interface{}{
+				"sa1": sa1,
+				"err": err})
+		} else if err != nil {
+			_span.RecordError(err)
+			_span.SetStatus(_codes.Error, err.Error())
+			_span.SetAttributes(
+				attribute.String("event", "error"),
+				attribute.String("message", err.Error()),
+			)
+		}
+
+		_span.End()
+	}()
+	return _d.QuerierTx.GetServers(ctx, arg)
+}
+
+// GetServersMonitorReview implements QuerierTx
+func (_d QuerierTxWithTracing) GetServersMonitorReview(ctx context.Context) (ua1 []uint32, err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetServersMonitorReview")
 	defer func() {
 		if _d._spanDecorator != nil {
 			_d._spanDecorator(_span, map[string]interface{}{
-				"ctx": ctx,
-				"arg": arg}, map[string]interface{}{
-				"g1":  g1,
+				"ctx": ctx}, map[string]interface{}{
+				"ua1": ua1,
 				"err": err})
 		} else if err != nil {
 			_span.RecordError(err)
@@ -194,18 +1002,18 @@ func (_d QuerierTxWithTracing) GetMonitorTLSNameIP(ctx context.Context, arg GetM
 
 		_span.End()
 	}()
-	return _d.QuerierTx.GetMonitorTLSNameIP(ctx, arg)
+	return _d.QuerierTx.GetServersMonitorReview(ctx)
 }
 
-// GetMonitorsTLSName implements QuerierTx
-func (_d QuerierTxWithTracing) GetMonitorsTLSName(ctx context.Context, tlsName sql.NullString) (ma1 []Monitor, err error) {
-	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetMonitorsTLSName")
+// GetSystemSetting implements QuerierTx
+func (_d QuerierTxWithTracing) GetSystemSetting(ctx context.Context, key string) (s1 string, err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetSystemSetting")
 	defer func() {
 		if _d._spanDecorator != nil {
 			_d._spanDecorator(_span, map[string]interface{}{
-				"ctx":     ctx,
-				"tlsName": tlsName}, map[string]interface{}{
-				"ma1": ma1,
+				"ctx": ctx,
+				"key": key}, map[string]interface{}{
+				"s1":  s1,
 				"err": err})
 		} else if err != nil {
 			_span.RecordError(err)
@@ -218,18 +1026,18 @@ func (_d QuerierTxWithTracing) GetMonitorsTLSName(ctx context.Context, tlsName s
 
 		_span.End()
 	}()
-	return _d.QuerierTx.GetMonitorsTLSName(ctx, tlsName)
+	return _d.QuerierTx.GetSystemSetting(ctx, key)
 }
 
-// GetScorerLogScores implements QuerierTx
-func (_d QuerierTxWithTracing) GetScorerLogScores(ctx context.Context, arg GetScorerLogScoresParams) (la1 []LogScore, err error) {
-	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetScorerLogScores")
+// GetTracerouteQueue implements QuerierTx
+func (_d QuerierTxWithTracing) GetTracerouteQueue(ctx context.Context, arg GetTracerouteQueueParams) (sa1 []Server, err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetTracerouteQueue")
 	defer func() {
 		if _d._spanDecorator != nil {
 			_d._spanDecorator(_span, map[string]interface{}{
 				"ctx": ctx,
 				"arg": arg}, map[string]interface{}{
-				"la1": la1,
+				"sa1": sa1,
 				"err": err})
 		} else if err != nil {
 			_span.RecordError(err)
@@ -242,18 +1050,18 @@ func (_d QuerierTxWithTracing) GetScorerLogScores(ctx context.Context, arg GetSc
 
 		_span.End()
 	}()
-	return _d.QuerierTx.GetScorerLogScores(ctx, arg)
+	return _d.QuerierTx.GetTracerouteQueue(ctx, arg)
 }
 
-// GetScorerNextLogScoreID implements QuerierTx
-func (_d QuerierTxWithTracing) GetScorerNextLogScoreID(ctx context.Context, logScoreID uint64) (u1 uint64, err error) {
-	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetScorerNextLogScoreID")
+// GetVoidedMonitorServers implements QuerierTx
+func (_d QuerierTxWithTracing) GetVoidedMonitorServers(ctx context.Context, invalidationID uint32) (ga1 []GetVoidedMonitorServersRow, err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetVoidedMonitorServers")
 	defer func() {
 		if _d._spanDecorator != nil {
 			_d._spanDecorator(_span, map[string]interface{}{
-				"ctx":        ctx,
-				"logScoreID": logScoreID}, map[string]interface{}{
-				"u1":  u1,
+				"ctx":            ctx,
+				"invalidationID": invalidationID}, map[string]interface{}{
+				"ga1": ga1,
 				"err": err})
 		} else if err != nil {
 			_span.RecordError(err)
@@ -266,18 +1074,17 @@ func (_d QuerierTxWithTracing) GetScorerNextLogScoreID(ctx context.Context, logS
 
 		_span.End()
 	}()
-	return _d.QuerierTx.GetScorerNextLogScoreID(ctx, logScoreID)
+	return _d.QuerierTx.GetVoidedMonitorServers(ctx, invalidationID)
 }
 
-// GetScorerRecentScores implements QuerierTx
-func (_d QuerierTxWithTracing) GetScorerRecentScores(ctx context.Context, arg GetScorerRecentScoresParams) (la1 []LogScore, err error) {
-	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetScorerRecentScores")
+// InsertLog implements QuerierTx
+func (_d QuerierTxWithTracing) InsertLog(ctx context.Context, arg InsertLogParams) (err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.InsertLog")
 	defer func() {
 		if _d._spanDecorator != nil {
 			_d._spanDecorator(_span, map[string]interface{}{
 				"ctx": ctx,
 				"arg": arg}, map[string]interface{}{
-				"la1": la1,
 				"err": err})
 		} else if err != nil {
 			_span.RecordError(err)
@@ -290,17 +1097,18 @@ func (_d QuerierTxWithTracing) GetScorerRecentScores(ctx context.Context, arg Ge
 
 		_span.End()
 	}()
-	return _d.QuerierTx.GetScorerRecentScores(ctx, arg)
+	return _d.QuerierTx.InsertLog(ctx, arg)
 }
 
-// GetScorerStatus implements QuerierTx
-func (_d QuerierTxWithTracing) GetScorerStatus(ctx context.Context) (ga1 []GetScorerStatusRow, err error) {
-	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetScorerStatus")
+// InsertLogScore implements QuerierTx
+func (_d QuerierTxWithTracing) InsertLogScore(ctx context.Context, arg InsertLogScoreParams) (r1 sql.Result, err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.InsertLogScore")
 	defer func() {
 		if _d._spanDecorator != nil {
 			_d._spanDecorator(_span, map[string]interface{}{
-				"ctx": ctx}, map[string]interface{}{
-				"ga1": ga1,
+				"ctx": ctx,
+				"arg": arg}, map[string]interface{}{
+				"r1":  r1,
 				"err": err})
 		} else if err != nil {
 			_span.RecordError(err)
@@ -313,17 +1121,18 @@ func (_d QuerierTxWithTracing) GetScorerStatus(ctx context.Context) (ga1 []GetSc
 
 		_span.End()
 	}()
-	return _d.QuerierTx.GetScorerStatus(ctx)
+	return _d.QuerierTx.InsertLogScore(ctx, arg)
 }
 
-// GetScorers implements QuerierTx
-func (_d QuerierTxWithTracing) GetScorers(ctx context.Context) (ga1 []GetScorersRow, err error) {
-	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetScorers")
+// InsertLogScoreInvalidation implements QuerierTx
+func (_d QuerierTxWithTracing) InsertLogScoreInvalidation(ctx context.Context, arg InsertLogScoreInvalidationParams) (r1 sql.Result, err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.InsertLogScoreInvalidation")
 	defer func() {
 		if _d._spanDecorator != nil {
 			_d._spanDecorator(_span, map[string]interface{}{
-				"ctx": ctx}, map[string]interface{}{
-				"ga1": ga1,
+				"ctx": ctx,
+				"arg": arg}, map[string]interface{}{
+				"r1":  r1,
 				"err": err})
 		} else if err != nil {
 			_span.RecordError(err)
@@ -336,18 +1145,17 @@ func (_d QuerierTxWithTracing) GetScorers(ctx context.Context) (ga1 []GetScorers
 
 		_span.End()
 	}()
-	return _d.QuerierTx.GetScorers(ctx)
+	return _d.QuerierTx.InsertLogScoreInvalidation(ctx, arg)
 }
 
-// GetServer implements QuerierTx
-func (_d QuerierTxWithTracing) GetServer(ctx context.Context, id uint32) (s1 Server, err error) {
-	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetServer")
+// InsertLogScorePacket implements QuerierTx
+func (_d QuerierTxWithTracing) InsertLogScorePacket(ctx context.Context, arg InsertLogScorePacketParams) (err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.InsertLogScorePacket")
 	defer func() {
 		if _d._spanDecorator != nil {
 			_d._spanDecorator(_span, map[string]interface{}{
 				"ctx": ctx,
-				"id":  id}, map[string]interface{}{
-				"s1":  s1,
+				"arg": arg}, map[string]interface{}{
 				"err": err})
 		} else if err != nil {
 			_span.RecordError(err)
@@ -360,18 +1168,17 @@ func (_d QuerierTxWithTracing) GetServer(ctx context.Context, id uint32) (s1 Ser
 
 		_span.End()
 	}()
-	return _d.QuerierTx.GetServer(ctx, id)
+	return _d.QuerierTx.InsertLogScorePacket(ctx, arg)
 }
 
-// GetServerIP implements QuerierTx
-func (_d QuerierTxWithTracing) GetServerIP(ctx context.Context, ip string) (s1 Server, err error) {
-	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetServerIP")
+// InsertMonitorBatch implements QuerierTx
+func (_d QuerierTxWithTracing) InsertMonitorBatch(ctx context.Context, arg InsertMonitorBatchParams) (err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.InsertMonitorBatch")
 	defer func() {
 		if _d._spanDecorator != nil {
 			_d._spanDecorator(_span, map[string]interface{}{
 				"ctx": ctx,
-				"ip":  ip}, map[string]interface{}{
-				"s1":  s1,
+				"arg": arg}, map[string]interface{}{
 				"err": err})
 		} else if err != nil {
 			_span.RecordError(err)
@@ -384,18 +1191,18 @@ func (_d QuerierTxWithTracing) GetServerIP(ctx context.Context, ip string) (s1 S
 
 		_span.End()
 	}()
-	return _d.QuerierTx.GetServerIP(ctx, ip)
+	return _d.QuerierTx.InsertMonitorBatch(ctx, arg)
 }
 
-// GetServerScore implements QuerierTx
-func (_d QuerierTxWithTracing) GetServerScore(ctx context.Context, arg GetServerScoreParams) (s1 ServerScore, err error) {
-	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetServerScore")
+// InsertScorer implements QuerierTx
+func (_d QuerierTxWithTracing) InsertScorer(ctx context.Context, arg InsertScorerParams) (r1 sql.Result, err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.InsertScorer")
 	defer func() {
 		if _d._spanDecorator != nil {
 			_d._spanDecorator(_span, map[string]interface{}{
 				"ctx": ctx,
 				"arg": arg}, map[string]interface{}{
-				"s1":  s1,
+				"r1":  r1,
 				"err": err})
 		} else if err != nil {
 			_span.RecordError(err)
@@ -408,18 +1215,17 @@ func (_d QuerierTxWithTracing) GetServerScore(ctx context.Context, arg GetServer
 
 		_span.End()
 	}()
-	return _d.QuerierTx.GetServerScore(ctx, arg)
+	return _d.QuerierTx.InsertScorer(ctx, arg)
 }
 
-// GetServers implements QuerierTx
-func (_d QuerierTxWithTracing) GetServers(ctx context.Context, arg GetServersParams) (sa1 []Server, err error) {
-	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetServers")
+// InsertScorerStatus implements QuerierTx
+func (_d QuerierTxWithTracing) InsertScorerStatus(ctx context.Context, arg InsertScorerStatusParams) (err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.InsertScorerStatus")
 	defer func() {
 		if _d._spanDecorator != nil {
 			_d._spanDecorator(_span, map[string]interface{}{
 				"ctx": ctx,
 				"arg": arg}, map[string]interface{}{
-				"sa1": sa1,
 				"err": err})
 		} else if err != nil {
 			_span.RecordError(err)
@@ -432,17 +1238,17 @@ func (_d QuerierTxWithTracing) GetServers(ctx context.Context, arg GetServersPar
 
 		_span.End()
 	}()
-	return _d.QuerierTx.GetServers(ctx, arg)
+	return _d.QuerierTx.InsertScorerStatus(ctx, arg)
 }
 
-// GetServersMonitorReview implements QuerierTx
-func (_d QuerierTxWithTracing) GetServersMonitorReview(ctx context.Context) (ua1 []uint32, err error) {
-	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetServersMonitorReview")
+// InsertServerScore implements QuerierTx
+func (_d QuerierTxWithTracing) InsertServerScore(ctx context.Context, arg InsertServerScoreParams) (err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.InsertServerScore")
 	defer func() {
 		if _d._spanDecorator != nil {
 			_d._spanDecorator(_span, map[string]interface{}{
-				"ctx": ctx}, map[string]interface{}{
-				"ua1": ua1,
+				"ctx": ctx,
+				"arg": arg}, map[string]interface{}{
 				"err": err})
 		} else if err != nil {
 			_span.RecordError(err)
@@ -455,18 +1261,17 @@ func (_d QuerierTxWithTracing) GetServersMonitorReview(ctx context.Context) (ua1
 
 		_span.End()
 	}()
-	return _d.QuerierTx.GetServersMonitorReview(ctx)
+	return _d.QuerierTx.InsertServerScore(ctx, arg)
 }
 
-// GetSystemSetting implements QuerierTx
-func (_d QuerierTxWithTracing) GetSystemSetting(ctx context.Context, key string) (s1 string, err error) {
-	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.GetSystemSetting")
+// InsertTraceroute implements QuerierTx
+func (_d QuerierTxWithTracing) InsertTraceroute(ctx context.Context, arg InsertTracerouteParams) (err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.InsertTraceroute")
 	defer func() {
 		if _d._spanDecorator != nil {
 			_d._spanDecorator(_span, map[string]interface{}{
 				"ctx": ctx,
-				"key": key}, map[string]interface{}{
-				"s1":  s1,
+				"arg": arg}, map[string]interface{}{
 				"err": err})
 		} else if err != nil {
 			_span.RecordError(err)
@@ -479,18 +1284,17 @@ func (_d QuerierTxWithTracing) GetSystemSetting(ctx context.Context, key string)
 
 		_span.End()
 	}()
-	return _d.QuerierTx.GetSystemSetting(ctx, key)
+	return _d.QuerierTx.InsertTraceroute(ctx, arg)
 }
 
-// InsertLogScore implements QuerierTx
-func (_d QuerierTxWithTracing) InsertLogScore(ctx context.Context, arg InsertLogScoreParams) (r1 sql.Result, err error) {
-	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.InsertLogScore")
+// QueueTraceroute implements QuerierTx
+func (_d QuerierTxWithTracing) QueueTraceroute(ctx context.Context, arg QueueTracerouteParams) (err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.QueueTraceroute")
 	defer func() {
 		if _d._spanDecorator != nil {
 			_d._spanDecorator(_span, map[string]interface{}{
 				"ctx": ctx,
 				"arg": arg}, map[string]interface{}{
-				"r1":  r1,
 				"err": err})
 		} else if err != nil {
 			_span.RecordError(err)
@@ -503,18 +1307,16 @@ func (_d QuerierTxWithTracing) InsertLogScore(ctx context.Context, arg InsertLog
 
 		_span.End()
 	}()
-	return _d.QuerierTx.InsertLogScore(ctx, arg)
+	return _d.QuerierTx.QueueTraceroute(ctx, arg)
 }
 
-// InsertScorer implements QuerierTx
-func (_d QuerierTxWithTracing) InsertScorer(ctx context.Context, arg InsertScorerParams) (r1 sql.Result, err error) {
-	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.InsertScorer")
+// Rollback implements QuerierTx
+func (_d QuerierTxWithTracing) Rollback(ctx context.Context) (err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.Rollback")
 	defer func() {
 		if _d._spanDecorator != nil {
 			_d._spanDecorator(_span, map[string]interface{}{
-				"ctx": ctx,
-				"arg": arg}, map[string]interface{}{
-				"r1":  r1,
+				"ctx": ctx}, map[string]interface{}{
 				"err": err})
 		} else if err != nil {
 			_span.RecordError(err)
@@ -527,12 +1329,12 @@ func (_d QuerierTxWithTracing) InsertScorer(ctx context.Context, arg InsertScore
 
 		_span.End()
 	}()
-	return _d.QuerierTx.InsertScorer(ctx, arg)
+	return _d.QuerierTx.Rollback(ctx)
 }
 
-// InsertScorerStatus implements QuerierTx
-func (_d QuerierTxWithTracing) InsertScorerStatus(ctx context.Context, arg InsertScorerStatusParams) (err error) {
-	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.InsertScorerStatus")
+// UpdateLogScoreScore implements QuerierTx
+func (_d QuerierTxWithTracing) UpdateLogScoreScore(ctx context.Context, arg UpdateLogScoreScoreParams) (err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.UpdateLogScoreScore")
 	defer func() {
 		if _d._spanDecorator != nil {
 			_d._spanDecorator(_span, map[string]interface{}{
@@ -550,12 +1352,12 @@ func (_d QuerierTxWithTracing) InsertScorerStatus(ctx context.Context, arg Inser
 
 		_span.End()
 	}()
-	return _d.QuerierTx.InsertScorerStatus(ctx, arg)
+	return _d.QuerierTx.UpdateLogScoreScore(ctx, arg)
 }
 
-// InsertServerScore implements QuerierTx
-func (_d QuerierTxWithTracing) InsertServerScore(ctx context.Context, arg InsertServerScoreParams) (err error) {
-	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.InsertServerScore")
+// UpdateMonitorClockCheck implements QuerierTx
+func (_d QuerierTxWithTracing) UpdateMonitorClockCheck(ctx context.Context, arg UpdateMonitorClockCheckParams) (err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.UpdateMonitorClockCheck")
 	defer func() {
 		if _d._spanDecorator != nil {
 			_d._spanDecorator(_span, map[string]interface{}{
@@ -573,16 +1375,17 @@ func (_d QuerierTxWithTracing) InsertServerScore(ctx context.Context, arg Insert
 
 		_span.End()
 	}()
-	return _d.QuerierTx.InsertServerScore(ctx, arg)
+	return _d.QuerierTx.UpdateMonitorClockCheck(ctx, arg)
 }
 
-// Rollback implements QuerierTx
-func (_d QuerierTxWithTracing) Rollback(ctx context.Context) (err error) {
-	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.Rollback")
+// UpdateMonitorSeen implements QuerierTx
+func (_d QuerierTxWithTracing) UpdateMonitorSeen(ctx context.Context, arg UpdateMonitorSeenParams) (err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.UpdateMonitorSeen")
 	defer func() {
 		if _d._spanDecorator != nil {
 			_d._spanDecorator(_span, map[string]interface{}{
-				"ctx": ctx}, map[string]interface{}{
+				"ctx": ctx,
+				"arg": arg}, map[string]interface{}{
 				"err": err})
 		} else if err != nil {
 			_span.RecordError(err)
@@ -595,12 +1398,12 @@ func (_d QuerierTxWithTracing) Rollback(ctx context.Context) (err error) {
 
 		_span.End()
 	}()
-	return _d.QuerierTx.Rollback(ctx)
+	return _d.QuerierTx.UpdateMonitorSeen(ctx, arg)
 }
 
-// UpdateMonitorSeen implements QuerierTx
-func (_d QuerierTxWithTracing) UpdateMonitorSeen(ctx context.Context, arg UpdateMonitorSeenParams) (err error) {
-	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.UpdateMonitorSeen")
+// UpdateMonitorStatus implements QuerierTx
+func (_d QuerierTxWithTracing) UpdateMonitorStatus(ctx context.Context, arg UpdateMonitorStatusParams) (err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.UpdateMonitorStatus")
 	defer func() {
 		if _d._spanDecorator != nil {
 			_d._spanDecorator(_span, map[string]interface{}{
@@ -618,7 +1421,7 @@ func (_d QuerierTxWithTracing) UpdateMonitorSeen(ctx context.Context, arg Update
 
 		_span.End()
 	}()
-	return _d.QuerierTx.UpdateMonitorSeen(ctx, arg)
+	return _d.QuerierTx.UpdateMonitorStatus(ctx, arg)
 }
 
 // UpdateMonitorSubmit implements QuerierTx
@@ -713,6 +1516,29 @@ func (_d QuerierTxWithTracing) UpdateServer(ctx context.Context, arg UpdateServe
 	return _d.QuerierTx.UpdateServer(ctx, arg)
 }
 
+// UpdateServerOffset implements QuerierTx
+func (_d QuerierTxWithTracing) UpdateServerOffset(ctx context.Context, arg UpdateServerOffsetParams) (err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.UpdateServerOffset")
+	defer func() {
+		if _d._spanDecorator != nil {
+			_d._spanDecorator(_span, map[string]interface{}{
+				"ctx": ctx,
+				"arg": arg}, map[string]interface{}{
+				"err": err})
+		} else if err != nil {
+			_span.RecordError(err)
+			_span.SetStatus(_codes.Error, err.Error())
+			_span.SetAttributes(
+				attribute.String("event", "error"),
+				attribute.String("message", err.Error()),
+			)
+		}
+
+		_span.End()
+	}()
+	return _d.QuerierTx.UpdateServerOffset(ctx, arg)
+}
+
 // UpdateServerScore implements QuerierTx
 func (_d QuerierTxWithTracing) UpdateServerScore(ctx context.Context, arg UpdateServerScoreParams) (err error) {
 	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.UpdateServerScore")
@@ -942,3 +1768,96 @@ func (_d QuerierTxWithTracing) UpdateServersMonitorReviewChanged(ctx context.Con
 	}()
 	return _d.QuerierTx.UpdateServersMonitorReviewChanged(ctx, arg)
 }
+
+// UpdateSystemSetting implements QuerierTx
+func (_d QuerierTxWithTracing) UpdateSystemSetting(ctx context.Context, arg UpdateSystemSettingParams) (err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.UpdateSystemSetting")
+	defer func() {
+		if _d._spanDecorator != nil {
+			_d._spanDecorator(_span, map[string]interface{}{
+				"ctx": ctx,
+				"arg": arg}, map[string]interface{}{
+				"err": err})
+		} else if err != nil {
+			_span.RecordError(err)
+			_span.SetStatus(_codes.Error, err.Error())
+			_span.SetAttributes(
+				attribute.String("event", "error"),
+				attribute.String("message", err.Error()),
+			)
+		}
+
+		_span.End()
+	}()
+	return _d.QuerierTx.UpdateSystemSetting(ctx, arg)
+}
+
+// UpdateTracerouteQueueDone implements QuerierTx
+func (_d QuerierTxWithTracing) UpdateTracerouteQueueDone(ctx context.Context, arg UpdateTracerouteQueueDoneParams) (err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.UpdateTracerouteQueueDone")
+	defer func() {
+		if _d._spanDecorator != nil {
+			_d._spanDecorator(_span, map[string]interface{}{
+				"ctx": ctx,
+				"arg": arg}, map[string]interface{}{
+				"err": err})
+		} else if err != nil {
+			_span.RecordError(err)
+			_span.SetStatus(_codes.Error, err.Error())
+			_span.SetAttributes(
+				attribute.String("event", "error"),
+				attribute.String("message", err.Error()),
+			)
+		}
+
+		_span.End()
+	}()
+	return _d.QuerierTx.UpdateTracerouteQueueDone(ctx, arg)
+}
+
+// UpdateTracerouteQueueSent implements QuerierTx
+func (_d QuerierTxWithTracing) UpdateTracerouteQueueSent(ctx context.Context, arg UpdateTracerouteQueueSentParams) (err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.UpdateTracerouteQueueSent")
+	defer func() {
+		if _d._spanDecorator != nil {
+			_d._spanDecorator(_span, map[string]interface{}{
+				"ctx": ctx,
+				"arg": arg}, map[string]interface{}{
+				"err": err})
+		} else if err != nil {
+			_span.RecordError(err)
+			_span.SetStatus(_codes.Error, err.Error())
+			_span.SetAttributes(
+				attribute.String("event", "error"),
+				attribute.String("message", err.Error()),
+			)
+		}
+
+		_span.End()
+	}()
+	return _d.QuerierTx.UpdateTracerouteQueueSent(ctx, arg)
+}
+
+// VoidLogScores implements QuerierTx
+func (_d QuerierTxWithTracing) VoidLogScores(ctx context.Context, arg VoidLogScoresParams) (r1 sql.Result, err error) {
+	ctx, _span := otel.Tracer(_d._instance).Start(ctx, "QuerierTx.VoidLogScores")
+	defer func() {
+		if _d._spanDecorator != nil {
+			_d._spanDecorator(_span, map[string]interface{}{
+				"ctx": ctx,
+				"arg": arg}, map[string]interface{}{
+				"r1":  r1,
+				"err": err})
+		} else if err != nil {
+			_span.RecordError(err)
+			_span.SetStatus(_codes.Error, err.Error())
+			_span.SetAttributes(
+				attribute.String("event", "error"),
+				attribute.String("message", err.Error()),
+			)
+		}
+
+		_span.End()
+	}()
+	return _d.QuerierTx.VoidLogScores(ctx, arg)
+}
@@ -7,18 +7,38 @@ package ntpdb
 import (
 	"context"
 	"database/sql"
+	"time"
 )
 
 type Querier interface {
+	AddMonitorLeasesIssued(ctx context.Context, arg AddMonitorLeasesIssuedParams) error
+	AddMonitorLeasesReturned(ctx context.Context, arg AddMonitorLeasesReturnedParams) error
 	ClearServerScoreConstraintViolation(ctx context.Context, arg ClearServerScoreConstraintViolationParams) error
+	ClearServerScoreLease(ctx context.Context, id uint64) error
+	DeleteMonitorBatches(ctx context.Context, arg DeleteMonitorBatchesParams) error
 	// Remove a monitor assignment from a server
 	DeleteServerScore(ctx context.Context, arg DeleteServerScoreParams) error
+	GetActiveMonitors(ctx context.Context) ([]Monitor, error)
+	GetAvailableMonitors(ctx context.Context, arg GetAvailableMonitorsParams) ([]GetAvailableMonitorsRow, error)
+	GetFirstLogScoreID(ctx context.Context, ts time.Time) (uint64, error)
 	// https://github.com/kyleconroy/sqlc/issues/1965
 	GetMinLogScoreID(ctx context.Context) (uint64, error)
+	GetMonitorBatchResults(ctx context.Context, arg GetMonitorBatchResultsParams) ([]byte, error)
+	GetMonitorByID(ctx context.Context, id uint32) (Monitor, error)
+	GetMonitorClockChecks(ctx context.Context) ([]MonitorClockCheck, error)
+	GetMonitorLeaseStats(ctx context.Context, arg GetMonitorLeaseStatsParams) (GetMonitorLeaseStatsRow, error)
 	GetMonitorPriority(ctx context.Context, serverID uint32) ([]GetMonitorPriorityRow, error)
+	GetMonitorResponseCounts(ctx context.Context, arg GetMonitorResponseCountsParams) (GetMonitorResponseCountsRow, error)
+	// Average offset each active or testing monitor measured for each server
+	GetMonitorServerOffsets(ctx context.Context, ts time.Time) ([]GetMonitorServerOffsetsRow, error)
 	GetMonitorTLSNameIP(ctx context.Context, arg GetMonitorTLSNameIPParams) (GetMonitorTLSNameIPRow, error)
 	GetMonitorsTLSName(ctx context.Context, tlsName sql.NullString) ([]Monitor, error)
+	// Results from other monitors for the servers, without quarantined timeouts
+	GetPeerResponseCounts(ctx context.Context, arg GetPeerResponseCountsParams) (GetPeerResponseCountsRow, error)
+	GetReplayBaseLogScore(ctx context.Context, arg GetReplayBaseLogScoreParams) (LogScore, error)
+	GetReplayLogScores(ctx context.Context, arg GetReplayLogScoresParams) ([]LogScore, error)
 	GetScorerLogScores(ctx context.Context, arg GetScorerLogScoresParams) ([]LogScore, error)
+	GetScorerLogScoresByTime(ctx context.Context, arg GetScorerLogScoresByTimeParams) ([]LogScore, error)
 	//   this is very slow when there's a backlog, so
 	//   only run it when there are no results to make
 	//   sure we don't get stuck behind a bunch of scoring
@@ -30,19 +50,38 @@ type Querier interface {
 	GetScorers(ctx context.Context) ([]GetScorersRow, error)
 	GetServer(ctx context.Context, id uint32) (Server, error)
 	GetServerIP(ctx context.Context, ip string) (Server, error)
+	GetServerMonitorReviewConfig(ctx context.Context, serverID uint32) (string, error)
+	GetServerOffset(ctx context.Context, serverID uint32) (ServerOffset, error)
 	GetServerScore(ctx context.Context, arg GetServerScoreParams) (ServerScore, error)
+	GetServerScoreForUpdate(ctx context.Context, arg GetServerScoreForUpdateParams) (ServerScore, error)
+	// Leases the monitor has for the servers with the IPs
+	GetServerScoreLeases(ctx context.Context, arg GetServerScoreLeasesParams) ([]GetServerScoreLeasesRow, error)
+	GetServerScoreMonitors(ctx context.Context, serverID uint32) ([]GetServerScoreMonitorsRow, error)
+	GetServerZoneShare(ctx context.Context, id uint32) (GetServerZoneShareRow, error)
 	GetServers(ctx context.Context, arg GetServersParams) ([]Server, error)
 	GetServersMonitorReview(ctx context.Context) ([]uint32, error)
 	GetSystemSetting(ctx context.Context, key string) (string, error)
+	GetTracerouteQueue(ctx context.Context, arg GetTracerouteQueueParams) ([]Server, error)
+	GetVoidedMonitorServers(ctx context.Context, invalidationID uint32) ([]GetVoidedMonitorServersRow, error)
+	InsertLog(ctx context.Context, arg InsertLogParams) error
 	InsertLogScore(ctx context.Context, arg InsertLogScoreParams) (sql.Result, error)
+	InsertLogScoreInvalidation(ctx context.Context, arg InsertLogScoreInvalidationParams) (sql.Result, error)
+	InsertLogScorePacket(ctx context.Context, arg InsertLogScorePacketParams) error
+	InsertMonitorBatch(ctx context.Context, arg InsertMonitorBatchParams) error
 	InsertScorer(ctx context.Context, arg InsertScorerParams) (sql.Result, error)
 	InsertScorerStatus(ctx context.Context, arg InsertScorerStatusParams) error
 	InsertServerScore(ctx context.Context, arg InsertServerScoreParams) error
+	InsertTraceroute(ctx context.Context, arg InsertTracerouteParams) error
+	QueueTraceroute(ctx context.Context, arg QueueTracerouteParams) error
+	UpdateLogScoreScore(ctx context.Context, arg UpdateLogScoreScoreParams) error
+	UpdateMonitorClockCheck(ctx context.Context, arg UpdateMonitorClockCheckParams) error
 	UpdateMonitorSeen(ctx context.Context, arg UpdateMonitorSeenParams) error
+	UpdateMonitorStatus(ctx context.Context, arg UpdateMonitorStatusParams) error
 	UpdateMonitorSubmit(ctx context.Context, arg UpdateMonitorSubmitParams) error
 	UpdateMonitorVersion(ctx context.Context, arg UpdateMonitorVersionParams) error
 	UpdateScorerStatus(ctx context.Context, arg UpdateScorerStatusParams) error
 	UpdateServer(ctx context.Context, arg UpdateServerParams) error
+	UpdateServerOffset(ctx context.Context, arg UpdateServerOffsetParams) error
 	UpdateServerScore(ctx context.Context, arg UpdateServerScoreParams) error
 	UpdateServerScoreConstraintViolation(ctx context.Context, arg UpdateServerScoreConstraintViolationParams) error
 	UpdateServerScoreLastConstraintCheck(ctx context.Context, arg UpdateServerScoreLastConstraintCheckParams) error
@@ -53,6 +92,10 @@ type Querier interface {
 	UpdateServerStratum(ctx context.Context, arg UpdateServerStratumParams) error
 	UpdateServersMonitorReview(ctx context.Context, arg UpdateServersMonitorReviewParams) error
 	UpdateServersMonitorReviewChanged(ctx context.Context, arg UpdateServersMonitorReviewChangedParams) error
+	UpdateSystemSetting(ctx context.Context, arg UpdateSystemSettingParams) error
+	UpdateTracerouteQueueDone(ctx context.Context, arg UpdateTracerouteQueueDoneParams) error
+	UpdateTracerouteQueueSent(ctx context.Context, arg UpdateTracerouteQueueSentParams) error
+	VoidLogScores(ctx context.Context, arg VoidLogScoresParams) (sql.Result, error)
 }
 
 var _ Querier = (*Queries)(nil)
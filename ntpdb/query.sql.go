@@ -13,6 +13,44 @@ import (
 	"time"
 )
 
+const addMonitorLeasesIssued = `-- name: AddMonitorLeasesIssued :exec
+INSERT INTO monitor_lease_stats
+  (monitor_id, date, issued)
+  VALUES (?, CURDATE(), ?)
+  ON DUPLICATE KEY UPDATE
+    issued = issued + VALUES(issued)
+`
+
+type AddMonitorLeasesIssuedParams struct {
+	MonitorID uint32 `json:"monitor_id"`
+	Issued    uint32 `json:"issued"`
+}
+
+func (q *Queries) AddMonitorLeasesIssued(ctx context.Context, arg AddMonitorLeasesIssuedParams) error {
+	_, err := q.db.ExecContext(ctx, addMonitorLeasesIssued, arg.MonitorID, arg.Issued)
+	return err
+}
+
+const addMonitorLeasesReturned = `-- name: AddMonitorLeasesReturned :exec
+INSERT INTO monitor_lease_stats
+  (monitor_id, date, returned, late)
+  VALUES (?, CURDATE(), ?, ?)
+  ON DUPLICATE KEY UPDATE
+    returned = returned + VALUES(returned),
+    late = late + VALUES(late)
+`
+
+type AddMonitorLeasesReturnedParams struct {
+	MonitorID uint32 `json:"monitor_id"`
+	Returned  uint32 `json:"returned"`
+	Late      uint32 `json:"late"`
+}
+
+func (q *Queries) AddMonitorLeasesReturned(ctx context.Context, arg AddMonitorLeasesReturnedParams) error {
+	_, err := q.db.ExecContext(ctx, addMonitorLeasesReturned, arg.MonitorID, arg.Returned, arg.Late)
+	return err
+}
+
 const clearServerScoreConstraintViolation = `-- name: ClearServerScoreConstraintViolation :exec
 UPDATE server_scores
 SET constraint_violation_type = NULL,
@@ -32,6 +70,32 @@ func (q *Queries) ClearServerScoreConstraintViolation(ctx context.Context, arg C
 	return err
 }
 
+const clearServerScoreLease = `-- name: ClearServerScoreLease :exec
+UPDATE server_scores
+  SET lease_expires = NULL
+  WHERE id = ?
+`
+
+func (q *Queries) ClearServerScoreLease(ctx context.Context, id uint64) error {
+	_, err := q.db.ExecContext(ctx, clearServerScoreLease, id)
+	return err
+}
+
+const deleteMonitorBatches = `-- name: DeleteMonitorBatches :exec
+DELETE FROM monitor_batches
+  WHERE monitor_id = ? AND batch_ts < ?
+`
+
+type DeleteMonitorBatchesParams struct {
+	MonitorID uint32    `json:"monitor_id"`
+	BatchTs   time.Time `json:"batch_ts"`
+}
+
+func (q *Queries) DeleteMonitorBatches(ctx context.Context, arg DeleteMonitorBatchesParams) error {
+	_, err := q.db.ExecContext(ctx, deleteMonitorBatches, arg.MonitorID, arg.BatchTs)
+	return err
+}
+
 const deleteServerScore = `-- name: DeleteServerScore :exec
 DELETE FROM server_scores
 WHERE server_id = ? AND monitor_id = ?
@@ -48,6 +112,135 @@ func (q *Queries) DeleteServerScore(ctx context.Context, arg DeleteServerScorePa
 	return err
 }
 
+const getActiveMonitors = `-- name: GetActiveMonitors :many
+SELECT id, id_token, type, user_id, account_id, hostname, location, ip, ip_version, tls_name, api_key, status, config, client_version, last_seen, last_submit, created_on, deleted_on, is_current FROM monitors
+WHERE type = 'monitor'
+  AND status IN ('active', 'testing')
+  AND is_current = 1
+  AND deleted_on is null
+`
+
+func (q *Queries) GetActiveMonitors(ctx context.Context) ([]Monitor, error) {
+	rows, err := q.db.QueryContext(ctx, getActiveMonitors)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Monitor
+	for rows.Next() {
+		var i Monitor
+		if err := rows.Scan(
+			&i.ID,
+			&i.IDToken,
+			&i.Type,
+			&i.UserID,
+			&i.AccountID,
+			&i.Hostname,
+			&i.Location,
+			&i.Ip,
+			&i.IpVersion,
+			&i.TlsName,
+			&i.ApiKey,
+			&i.Status,
+			&i.Config,
+			&i.ClientVersion,
+			&i.LastSeen,
+			&i.LastSubmit,
+			&i.CreatedOn,
+			&i.DeletedOn,
+			&i.IsCurrent,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAvailableMonitors = `-- name: GetAvailableMonitors :many
+select m.id, m.id_token, m.tls_name, m.account_id, m.ip as monitor_ip,
+    m.status as monitor_status, a.flags as account_flags,
+    (select count(*) from server_scores mss where mss.monitor_id = m.id) as server_count
+  from monitors m
+  left join accounts a on (m.account_id = a.id)
+  where m.type = 'monitor'
+    and m.status in ('active', 'testing')
+    and m.ip_version = ?
+    and m.last_seen > date_sub(now(), interval 1 hour)
+    and not exists (
+      select 1 from server_scores ss
+        where ss.server_id = ? and ss.monitor_id = m.id)
+  order by m.status = 'active' desc, server_count, m.id
+`
+
+type GetAvailableMonitorsParams struct {
+	IpVersion NullMonitorsIpVersion `json:"ip_version"`
+	ServerID  uint32                `json:"server_id"`
+}
+
+type GetAvailableMonitorsRow struct {
+	ID            uint32           `json:"id"`
+	IDToken       sql.NullString   `json:"id_token"`
+	TlsName       sql.NullString   `json:"tls_name"`
+	AccountID     sql.NullInt32    `json:"account_id"`
+	MonitorIp     sql.NullString   `json:"monitor_ip"`
+	MonitorStatus MonitorsStatus   `json:"monitor_status"`
+	AccountFlags  *json.RawMessage `json:"account_flags"`
+	ServerCount   int64            `json:"server_count"`
+}
+
+func (q *Queries) GetAvailableMonitors(ctx context.Context, arg GetAvailableMonitorsParams) ([]GetAvailableMonitorsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getAvailableMonitors, arg.IpVersion, arg.ServerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetAvailableMonitorsRow
+	for rows.Next() {
+		var i GetAvailableMonitorsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.IDToken,
+			&i.TlsName,
+			&i.AccountID,
+			&i.MonitorIp,
+			&i.MonitorStatus,
+			&i.AccountFlags,
+			&i.ServerCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFirstLogScoreID = `-- name: GetFirstLogScoreID :one
+SELECT id FROM log_scores
+  WHERE ts >= ?
+  ORDER BY ts, id
+  LIMIT 1
+`
+
+func (q *Queries) GetFirstLogScoreID(ctx context.Context, ts time.Time) (uint64, error) {
+	row := q.db.QueryRowContext(ctx, getFirstLogScoreID, ts)
+	var id uint64
+	err := row.Scan(&id)
+	return id, err
+}
+
 const getMinLogScoreID = `-- name: GetMinLogScoreID :one
 select id from log_scores order by id limit 1
 `
@@ -60,6 +253,114 @@ func (q *Queries) GetMinLogScoreID(ctx context.Context) (uint64, error) {
 	return id, err
 }
 
+const getMonitorBatchResults = `-- name: GetMonitorBatchResults :one
+SELECT results FROM monitor_batches
+  WHERE monitor_id = ? AND batch_id = ?
+`
+
+type GetMonitorBatchResultsParams struct {
+	MonitorID uint32 `json:"monitor_id"`
+	BatchID   string `json:"batch_id"`
+}
+
+func (q *Queries) GetMonitorBatchResults(ctx context.Context, arg GetMonitorBatchResultsParams) ([]byte, error) {
+	row := q.db.QueryRowContext(ctx, getMonitorBatchResults, arg.MonitorID, arg.BatchID)
+	var results []byte
+	err := row.Scan(&results)
+	return results, err
+}
+
+const getMonitorByID = `-- name: GetMonitorByID :one
+SELECT id, id_token, type, user_id, account_id, hostname, location, ip, ip_version, tls_name, api_key, status, config, client_version, last_seen, last_submit, created_on, deleted_on, is_current FROM monitors WHERE id = ?
+`
+
+func (q *Queries) GetMonitorByID(ctx context.Context, id uint32) (Monitor, error) {
+	row := q.db.QueryRowContext(ctx, getMonitorByID, id)
+	var i Monitor
+	err := row.Scan(
+		&i.ID,
+		&i.IDToken,
+		&i.Type,
+		&i.UserID,
+		&i.AccountID,
+		&i.Hostname,
+		&i.Location,
+		&i.Ip,
+		&i.IpVersion,
+		&i.TlsName,
+		&i.ApiKey,
+		&i.Status,
+		&i.Config,
+		&i.ClientVersion,
+		&i.LastSeen,
+		&i.LastSubmit,
+		&i.CreatedOn,
+		&i.DeletedOn,
+		&i.IsCurrent,
+	)
+	return i, err
+}
+
+const getMonitorClockChecks = `-- name: GetMonitorClockChecks :many
+SELECT monitor_id, checked_on, bias, servers, consistency, flagged_since FROM monitor_clock_checks
+`
+
+func (q *Queries) GetMonitorClockChecks(ctx context.Context) ([]MonitorClockCheck, error) {
+	rows, err := q.db.QueryContext(ctx, getMonitorClockChecks)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []MonitorClockCheck
+	for rows.Next() {
+		var i MonitorClockCheck
+		if err := rows.Scan(
+			&i.MonitorID,
+			&i.CheckedOn,
+			&i.Bias,
+			&i.Servers,
+			&i.Consistency,
+			&i.FlaggedSince,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMonitorLeaseStats = `-- name: GetMonitorLeaseStats :one
+SELECT CAST(COALESCE(SUM(issued), 0) AS SIGNED) AS issued,
+       CAST(COALESCE(SUM(returned), 0) AS SIGNED) AS returned,
+       CAST(COALESCE(SUM(late), 0) AS SIGNED) AS late
+  FROM monitor_lease_stats
+  WHERE monitor_id = ? AND date >= ?
+`
+
+type GetMonitorLeaseStatsParams struct {
+	MonitorID uint32    `json:"monitor_id"`
+	Date      time.Time `json:"date"`
+}
+
+type GetMonitorLeaseStatsRow struct {
+	Issued   int64 `json:"issued"`
+	Returned int64 `json:"returned"`
+	Late     int64 `json:"late"`
+}
+
+func (q *Queries) GetMonitorLeaseStats(ctx context.Context, arg GetMonitorLeaseStatsParams) (GetMonitorLeaseStatsRow, error) {
+	row := q.db.QueryRowContext(ctx, getMonitorLeaseStats, arg.MonitorID, arg.Date)
+	var i GetMonitorLeaseStatsRow
+	err := row.Scan(&i.Issued, &i.Returned, &i.Late)
+	return i, err
+}
+
 const getMonitorPriority = `-- name: GetMonitorPriority :many
 select m.id, m.id_token, m.tls_name, m.account_id, m.ip as monitor_ip,
     avg(ls.rtt) / 1000 as avg_rtt,
@@ -72,17 +373,26 @@ select m.id, m.id_token, m.tls_name, m.account_id, m.ip as monitor_ip,
     ss.constraint_violation_type,
     ss.constraint_violation_since,
     ss.last_constraint_check,
-    ss.pause_reason
+    ss.pause_reason,
+    (select if(sum(mls.issued) >= 50, sum(mls.returned) / sum(mls.issued), NULL)
+       from monitor_lease_stats mls
+       where mls.monitor_id = m.id
+         and mls.date >= date_sub(curdate(), interval 7 day)) as reliability,
+    avg(ls.offset) as avg_offset,
+    m.location
   from log_scores ls
   inner join monitors m
   left join server_scores ss on (ss.server_id = ls.server_id and ss.monitor_id = ls.monitor_id)
   left join accounts a on (m.account_id = a.id)
+  left join log_scores_voided v on (v.log_score_id = ls.id)
   where
     m.id = ls.monitor_id
   and ls.server_id = ?
   and m.type = 'monitor'
   and ls.ts > date_sub(now(), interval 24 hour)
-  group by m.id, m.id_token, m.tls_name, m.account_id, m.ip, m.status, ss.status, a.flags,
+  and v.log_score_id is null
+  and json_value(ls.attributes, '$.quarantined') is null
+  group by m.id, m.id_token, m.tls_name, m.account_id, m.ip, m.location, m.status, ss.status, a.flags,
            ss.constraint_violation_type, ss.constraint_violation_since, ss.last_constraint_check, ss.pause_reason
   order by healthy desc, monitor_priority, avg_step desc, avg_rtt
 `
@@ -105,6 +415,9 @@ type GetMonitorPriorityRow struct {
 	ConstraintViolationSince sql.NullTime           `json:"constraint_violation_since"`
 	LastConstraintCheck      sql.NullTime           `json:"last_constraint_check"`
 	PauseReason              sql.NullString         `json:"pause_reason"`
+	Reliability              interface{}            `json:"reliability"`
+	AvgOffset                interface{}            `json:"avg_offset"`
+	Location                 string                 `json:"location"`
 }
 
 func (q *Queries) GetMonitorPriority(ctx context.Context, serverID uint32) ([]GetMonitorPriorityRow, error) {
@@ -134,6 +447,85 @@ func (q *Queries) GetMonitorPriority(ctx context.Context, serverID uint32) ([]Ge
 			&i.ConstraintViolationSince,
 			&i.LastConstraintCheck,
 			&i.PauseReason,
+			&i.Reliability,
+			&i.AvgOffset,
+			&i.Location,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMonitorResponseCounts = `-- name: GetMonitorResponseCounts :one
+SELECT CAST(COUNT(*) AS SIGNED) AS total,
+       CAST(COALESCE(SUM(JSON_VALUE(attributes, '$.no_response') IS NULL), 0) AS SIGNED) AS responses
+  FROM log_scores
+  WHERE monitor_id = ? AND ts > ?
+    AND JSON_VALUE(attributes, '$.quarantined') IS NULL
+`
+
+type GetMonitorResponseCountsParams struct {
+	MonitorID sql.NullInt32 `json:"monitor_id"`
+	Ts        time.Time     `json:"ts"`
+}
+
+type GetMonitorResponseCountsRow struct {
+	Total     int64 `json:"total"`
+	Responses int64 `json:"responses"`
+}
+
+func (q *Queries) GetMonitorResponseCounts(ctx context.Context, arg GetMonitorResponseCountsParams) (GetMonitorResponseCountsRow, error) {
+	row := q.db.QueryRowContext(ctx, getMonitorResponseCounts, arg.MonitorID, arg.Ts)
+	var i GetMonitorResponseCountsRow
+	err := row.Scan(&i.Total, &i.Responses)
+	return i, err
+}
+
+const getMonitorServerOffsets = `-- name: GetMonitorServerOffsets :many
+SELECT m.id AS monitor_id, ls.server_id,
+       AVG(ls.offset) AS offset,
+       CAST(COUNT(*) AS SIGNED) AS samples
+  FROM log_scores ls
+  INNER JOIN monitors m ON (m.id = ls.monitor_id)
+  LEFT JOIN log_scores_voided v ON (v.log_score_id = ls.id)
+  WHERE m.type = 'monitor'
+    AND m.status IN ('active', 'testing')
+    AND ls.ts > ?
+    AND ls.offset IS NOT NULL
+    AND v.log_score_id IS NULL
+  GROUP BY m.id, ls.server_id
+`
+
+type GetMonitorServerOffsetsRow struct {
+	MonitorID uint32      `json:"monitor_id"`
+	ServerID  uint32      `json:"server_id"`
+	Offset    interface{} `json:"offset"`
+	Samples   int64       `json:"samples"`
+}
+
+// Average offset each active or testing monitor measured for each server
+func (q *Queries) GetMonitorServerOffsets(ctx context.Context, ts time.Time) ([]GetMonitorServerOffsetsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getMonitorServerOffsets, ts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetMonitorServerOffsetsRow
+	for rows.Next() {
+		var i GetMonitorServerOffsetsRow
+		if err := rows.Scan(
+			&i.MonitorID,
+			&i.ServerID,
+			&i.Offset,
+			&i.Samples,
 		); err != nil {
 			return nil, err
 		}
@@ -260,15 +652,147 @@ func (q *Queries) GetMonitorsTLSName(ctx context.Context, tlsName sql.NullString
 	return items, nil
 }
 
+const getPeerResponseCounts = `-- name: GetPeerResponseCounts :one
+SELECT CAST(COUNT(*) AS SIGNED) AS total,
+       CAST(COALESCE(SUM(JSON_VALUE(ls.attributes, '$.no_response') IS NULL), 0) AS SIGNED) AS responses
+  FROM log_scores ls
+  INNER JOIN servers s ON (s.id = ls.server_id)
+  INNER JOIN monitors m ON (m.id = ls.monitor_id)
+  WHERE s.ip IN (/*SLICE:ips*/?)
+    AND ls.monitor_id != ?
+    AND m.type = 'monitor'
+    AND ls.ts > ?
+    AND JSON_VALUE(ls.attributes, '$.quarantined') IS NULL
+`
+
+type GetPeerResponseCountsParams struct {
+	Ips       []string      `json:"ips"`
+	MonitorID sql.NullInt32 `json:"monitor_id"`
+	Since     time.Time     `json:"since"`
+}
+
+type GetPeerResponseCountsRow struct {
+	Total     int64 `json:"total"`
+	Responses int64 `json:"responses"`
+}
+
+// Results from other monitors for the servers, without quarantined timeouts
+func (q *Queries) GetPeerResponseCounts(ctx context.Context, arg GetPeerResponseCountsParams) (GetPeerResponseCountsRow, error) {
+	query := getPeerResponseCounts
+	var queryParams []interface{}
+	if len(arg.Ips) > 0 {
+		for _, v := range arg.Ips {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:ips*/?", strings.Repeat(",?", len(arg.Ips))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:ips*/?", "NULL", 1)
+	}
+	queryParams = append(queryParams, arg.MonitorID)
+	queryParams = append(queryParams, arg.Since)
+	row := q.db.QueryRowContext(ctx, query, queryParams...)
+	var i GetPeerResponseCountsRow
+	err := row.Scan(&i.Total, &i.Responses)
+	return i, err
+}
+
+const getReplayBaseLogScore = `-- name: GetReplayBaseLogScore :one
+SELECT ls.id, ls.monitor_id, ls.server_id, ls.ts, ls.score, ls.step, ls.offset, ls.rtt, ls.attributes FROM log_scores ls
+  LEFT JOIN log_scores_voided v ON (v.log_score_id = ls.id)
+  WHERE ls.monitor_id = ?
+    AND ls.server_id = ?
+    AND ls.ts < ?
+    AND v.log_score_id IS NULL
+    AND JSON_VALUE(ls.attributes, '$.quarantined') IS NULL
+  ORDER BY ls.ts DESC, ls.id DESC
+  LIMIT 1
+`
+
+type GetReplayBaseLogScoreParams struct {
+	MonitorID sql.NullInt32 `json:"monitor_id"`
+	ServerID  uint32        `json:"server_id"`
+	Ts        time.Time     `json:"ts"`
+}
+
+func (q *Queries) GetReplayBaseLogScore(ctx context.Context, arg GetReplayBaseLogScoreParams) (LogScore, error) {
+	row := q.db.QueryRowContext(ctx, getReplayBaseLogScore, arg.MonitorID, arg.ServerID, arg.Ts)
+	var i LogScore
+	err := row.Scan(
+		&i.ID,
+		&i.MonitorID,
+		&i.ServerID,
+		&i.Ts,
+		&i.Score,
+		&i.Step,
+		&i.Offset,
+		&i.Rtt,
+		&i.Attributes,
+	)
+	return i, err
+}
+
+const getReplayLogScores = `-- name: GetReplayLogScores :many
+SELECT ls.id, ls.monitor_id, ls.server_id, ls.ts, ls.score, ls.step, ls.offset, ls.rtt, ls.attributes FROM log_scores ls
+  LEFT JOIN log_scores_voided v ON (v.log_score_id = ls.id)
+  WHERE ls.monitor_id = ?
+    AND ls.server_id = ?
+    AND ls.ts >= ?
+    AND v.log_score_id IS NULL
+    AND JSON_VALUE(ls.attributes, '$.quarantined') IS NULL
+  ORDER BY ls.ts, ls.id
+`
+
+type GetReplayLogScoresParams struct {
+	MonitorID sql.NullInt32 `json:"monitor_id"`
+	ServerID  uint32        `json:"server_id"`
+	Ts        time.Time     `json:"ts"`
+}
+
+func (q *Queries) GetReplayLogScores(ctx context.Context, arg GetReplayLogScoresParams) ([]LogScore, error) {
+	rows, err := q.db.QueryContext(ctx, getReplayLogScores, arg.MonitorID, arg.ServerID, arg.Ts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LogScore
+	for rows.Next() {
+		var i LogScore
+		if err := rows.Scan(
+			&i.ID,
+			&i.MonitorID,
+			&i.ServerID,
+			&i.Ts,
+			&i.Score,
+			&i.Step,
+			&i.Offset,
+			&i.Rtt,
+			&i.Attributes,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getScorerLogScores = `-- name: GetScorerLogScores :many
 select ls.id, ls.monitor_id, ls.server_id, ls.ts, ls.score, ls.step, ls.offset, ls.rtt, ls.attributes from
-  log_scores ls use index (primary),
+  log_scores ls use index (primary)
+    left join log_scores_voided v on (v.log_score_id = ls.id),
   monitors m
 WHERE
   ls.id >  ? AND
   ls.id < (?+10000) AND
   m.type = 'monitor' AND
-  monitor_id = m.id
+  monitor_id = m.id AND
+  v.log_score_id IS NULL AND
+  JSON_VALUE(ls.attributes, '$.quarantined') IS NULL
 ORDER by ls.id
 LIMIT ?
 `
@@ -311,6 +835,53 @@ func (q *Queries) GetScorerLogScores(ctx context.Context, arg GetScorerLogScores
 	return items, nil
 }
 
+const getScorerLogScoresByTime = `-- name: GetScorerLogScoresByTime :many
+SELECT id, monitor_id, server_id, ts, score, step, offset, rtt, attributes FROM log_scores
+  WHERE monitor_id = ?
+    AND ts >= ?
+    AND ts <= ?
+  ORDER BY ts, id
+`
+
+type GetScorerLogScoresByTimeParams struct {
+	MonitorID sql.NullInt32 `json:"monitor_id"`
+	StartTs   time.Time     `json:"start_ts"`
+	EndTs     time.Time     `json:"end_ts"`
+}
+
+func (q *Queries) GetScorerLogScoresByTime(ctx context.Context, arg GetScorerLogScoresByTimeParams) ([]LogScore, error) {
+	rows, err := q.db.QueryContext(ctx, getScorerLogScoresByTime, arg.MonitorID, arg.StartTs, arg.EndTs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LogScore
+	for rows.Next() {
+		var i LogScore
+		if err := rows.Scan(
+			&i.ID,
+			&i.MonitorID,
+			&i.ServerID,
+			&i.Ts,
+			&i.Score,
+			&i.Step,
+			&i.Offset,
+			&i.Rtt,
+			&i.Attributes,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getScorerNextLogScoreID = `-- name: GetScorerNextLogScoreID :one
 select ls.id from
   log_scores ls use index (primary),
@@ -318,7 +889,8 @@ select ls.id from
 WHERE
   ls.id > ? AND
   m.type = 'monitor' AND
-  monitor_id = m.id
+  monitor_id = m.id AND
+  JSON_VALUE(ls.attributes, '$.quarantined') IS NULL
 ORDER by id
 limit 1
 `
@@ -340,10 +912,13 @@ const getScorerRecentScores = `-- name: GetScorerRecentScores :many
    from log_scores ls
    inner join
    (select ls2.monitor_id, max(ls2.ts) as sts
-      from log_scores ls2,
+      from log_scores ls2
+         left join log_scores_voided v on (v.log_score_id = ls2.id),
          monitors m,
          server_scores ss
       where ls2.server_id = ?
+         and v.log_score_id is null
+         and json_value(ls2.attributes, '$.quarantined') is null
          and ls2.monitor_id=m.id and m.type = 'monitor'
          and (ls2.monitor_id=ss.monitor_id and ls2.server_id=ss.server_id)
          and ss.status in (?,?)
@@ -554,37 +1129,219 @@ func (q *Queries) GetServerIP(ctx context.Context, ip string) (Server, error) {
 	return i, err
 }
 
-const getServerScore = `-- name: GetServerScore :one
-SELECT id, monitor_id, server_id, score_ts, score_raw, stratum, status, queue_ts, created_on, modified_on, constraint_violation_type, constraint_violation_since, last_constraint_check, pause_reason FROM server_scores
-  WHERE
-    server_id=? AND
-    monitor_id=?
+const getServerMonitorReviewConfig = `-- name: GetServerMonitorReviewConfig :one
+select config from servers_monitor_review where server_id = ?
+`
+
+func (q *Queries) GetServerMonitorReviewConfig(ctx context.Context, serverID uint32) (string, error) {
+	row := q.db.QueryRowContext(ctx, getServerMonitorReviewConfig, serverID)
+	var config string
+	err := row.Scan(&config)
+	return config, err
+}
+
+const getServerOffset = `-- name: GetServerOffset :one
+SELECT server_id, ts, ` + "`" + `offset` + "`" + `, spread, monitors, modified_on FROM server_offsets WHERE server_id = ?
+`
+
+func (q *Queries) GetServerOffset(ctx context.Context, serverID uint32) (ServerOffset, error) {
+	row := q.db.QueryRowContext(ctx, getServerOffset, serverID)
+	var i ServerOffset
+	err := row.Scan(
+		&i.ServerID,
+		&i.Ts,
+		&i.Offset,
+		&i.Spread,
+		&i.Monitors,
+		&i.ModifiedOn,
+	)
+	return i, err
+}
+
+const getServerScore = `-- name: GetServerScore :one
+SELECT id, monitor_id, server_id, score_ts, score_raw, stratum, status, queue_ts, created_on, modified_on, constraint_violation_type, constraint_violation_since, last_constraint_check, pause_reason, lease_expires FROM server_scores
+  WHERE
+    server_id=? AND
+    monitor_id=?
+`
+
+type GetServerScoreParams struct {
+	ServerID  uint32 `json:"server_id"`
+	MonitorID uint32 `json:"monitor_id"`
+}
+
+func (q *Queries) GetServerScore(ctx context.Context, arg GetServerScoreParams) (ServerScore, error) {
+	row := q.db.QueryRowContext(ctx, getServerScore, arg.ServerID, arg.MonitorID)
+	var i ServerScore
+	err := row.Scan(
+		&i.ID,
+		&i.MonitorID,
+		&i.ServerID,
+		&i.ScoreTs,
+		&i.ScoreRaw,
+		&i.Stratum,
+		&i.Status,
+		&i.QueueTs,
+		&i.CreatedOn,
+		&i.ModifiedOn,
+		&i.ConstraintViolationType,
+		&i.ConstraintViolationSince,
+		&i.LastConstraintCheck,
+		&i.PauseReason,
+		&i.LeaseExpires,
+	)
+	return i, err
+}
+
+const getServerScoreForUpdate = `-- name: GetServerScoreForUpdate :one
+SELECT id, monitor_id, server_id, score_ts, score_raw, stratum, status, queue_ts, created_on, modified_on, constraint_violation_type, constraint_violation_since, last_constraint_check, pause_reason, lease_expires FROM server_scores
+  WHERE
+    server_id=? AND
+    monitor_id=?
+  FOR UPDATE
+`
+
+type GetServerScoreForUpdateParams struct {
+	ServerID  uint32 `json:"server_id"`
+	MonitorID uint32 `json:"monitor_id"`
+}
+
+func (q *Queries) GetServerScoreForUpdate(ctx context.Context, arg GetServerScoreForUpdateParams) (ServerScore, error) {
+	row := q.db.QueryRowContext(ctx, getServerScoreForUpdate, arg.ServerID, arg.MonitorID)
+	var i ServerScore
+	err := row.Scan(
+		&i.ID,
+		&i.MonitorID,
+		&i.ServerID,
+		&i.ScoreTs,
+		&i.ScoreRaw,
+		&i.Stratum,
+		&i.Status,
+		&i.QueueTs,
+		&i.CreatedOn,
+		&i.ModifiedOn,
+		&i.ConstraintViolationType,
+		&i.ConstraintViolationSince,
+		&i.LastConstraintCheck,
+		&i.PauseReason,
+		&i.LeaseExpires,
+	)
+	return i, err
+}
+
+const getServerScoreLeases = `-- name: GetServerScoreLeases :many
+SELECT ss.id, ss.lease_expires
+  FROM server_scores ss
+  INNER JOIN servers s ON (s.id = ss.server_id)
+  WHERE ss.monitor_id = ?
+    AND s.ip IN (/*SLICE:ips*/?)
+    AND ss.lease_expires IS NOT NULL
+`
+
+type GetServerScoreLeasesParams struct {
+	MonitorID uint32   `json:"monitor_id"`
+	Ips       []string `json:"ips"`
+}
+
+type GetServerScoreLeasesRow struct {
+	ID           uint64       `json:"id"`
+	LeaseExpires sql.NullTime `json:"lease_expires"`
+}
+
+// Leases the monitor has for the servers with the IPs
+func (q *Queries) GetServerScoreLeases(ctx context.Context, arg GetServerScoreLeasesParams) ([]GetServerScoreLeasesRow, error) {
+	query := getServerScoreLeases
+	var queryParams []interface{}
+	queryParams = append(queryParams, arg.MonitorID)
+	if len(arg.Ips) > 0 {
+		for _, v := range arg.Ips {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:ips*/?", strings.Repeat(",?", len(arg.Ips))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:ips*/?", "NULL", 1)
+	}
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetServerScoreLeasesRow
+	for rows.Next() {
+		var i GetServerScoreLeasesRow
+		if err := rows.Scan(&i.ID, &i.LeaseExpires); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getServerScoreMonitors = `-- name: GetServerScoreMonitors :many
+select ss.monitor_id, ss.status, m.account_id
+  from server_scores ss
+  inner join monitors m on (m.id = ss.monitor_id)
+  where ss.server_id = ? and m.type = 'monitor'
 `
 
-type GetServerScoreParams struct {
-	ServerID  uint32 `json:"server_id"`
-	MonitorID uint32 `json:"monitor_id"`
+type GetServerScoreMonitorsRow struct {
+	MonitorID uint32             `json:"monitor_id"`
+	Status    ServerScoresStatus `json:"status"`
+	AccountID sql.NullInt32      `json:"account_id"`
 }
 
-func (q *Queries) GetServerScore(ctx context.Context, arg GetServerScoreParams) (ServerScore, error) {
-	row := q.db.QueryRowContext(ctx, getServerScore, arg.ServerID, arg.MonitorID)
-	var i ServerScore
-	err := row.Scan(
-		&i.ID,
-		&i.MonitorID,
-		&i.ServerID,
-		&i.ScoreTs,
-		&i.ScoreRaw,
-		&i.Stratum,
-		&i.Status,
-		&i.QueueTs,
-		&i.CreatedOn,
-		&i.ModifiedOn,
-		&i.ConstraintViolationType,
-		&i.ConstraintViolationSince,
-		&i.LastConstraintCheck,
-		&i.PauseReason,
-	)
+func (q *Queries) GetServerScoreMonitors(ctx context.Context, serverID uint32) ([]GetServerScoreMonitorsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getServerScoreMonitors, serverID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetServerScoreMonitorsRow
+	for rows.Next() {
+		var i GetServerScoreMonitorsRow
+		if err := rows.Scan(&i.MonitorID, &i.Status, &i.AccountID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getServerZoneShare = `-- name: GetServerZoneShare :one
+select
+    (select count(*) from server_zones sz where sz.server_id = s.id) as zones,
+    (select max(s.netspeed / zsc.netspeed_active)
+       from server_zones sz
+       inner join zone_server_counts zsc on (zsc.zone_id = sz.zone_id)
+       where sz.server_id = s.id
+         and zsc.ip_version = s.ip_version
+         and zsc.date = (select max(date) from zone_server_counts)
+         and zsc.netspeed_active > 0) as zone_share
+  from servers s
+  where s.id = ?
+`
+
+type GetServerZoneShareRow struct {
+	Zones     int64       `json:"zones"`
+	ZoneShare interface{} `json:"zone_share"`
+}
+
+func (q *Queries) GetServerZoneShare(ctx context.Context, id uint32) (GetServerZoneShareRow, error) {
+	row := q.db.QueryRowContext(ctx, getServerZoneShare, id)
+	var i GetServerZoneShareRow
+	err := row.Scan(&i.Zones, &i.ZoneShare)
 	return i, err
 }
 
@@ -600,11 +1357,12 @@ WHERE (monitor_id = ?
                AND ss.queue_ts < DATE_SUB( NOW(), INTERVAL ? second))
           OR (ss.score_raw > -90 AND ss.status = "testing"
               AND ss.queue_ts < DATE_SUB( NOW(), INTERVAL ? second))
-          OR (ss.queue_ts < DATE_SUB( NOW(), INTERVAL 120 minute)))
+          OR (ss.queue_ts < DATE_SUB( NOW(), INTERVAL 120 minute))
+          OR (ss.lease_expires < NOW()))
     AND (s.score_ts IS NULL OR
         (s.score_ts < DATE_SUB( NOW(), INTERVAL ? second) ))
     AND (deletion_on IS NULL or deletion_on > NOW()))
-ORDER BY ss.queue_ts
+ORDER BY IF(ss.lease_expires < NOW(), 0, 1), ss.queue_ts
 LIMIT  ?
 OFFSET ?
 `
@@ -709,6 +1467,127 @@ func (q *Queries) GetSystemSetting(ctx context.Context, key string) (string, err
 	return value, err
 }
 
+const getTracerouteQueue = `-- name: GetTracerouteQueue :many
+SELECT s.id, s.ip, s.ip_version, s.user_id, s.account_id, s.hostname, s.stratum, s.in_pool, s.in_server_list, s.netspeed, s.netspeed_target, s.created_on, s.updated_on, s.score_ts, s.score_raw, s.deletion_on, s.flags
+  FROM traceroute_queue tq
+  INNER JOIN servers s
+    ON (s.id=tq.server_id)
+  WHERE tq.monitor_id = ?
+    AND tq.queue_ts IS NOT NULL
+    AND (tq.sent_ts IS NULL OR tq.sent_ts < ?)
+    AND (s.deletion_on IS NULL OR s.deletion_on > NOW())
+  ORDER BY tq.queue_ts
+  LIMIT ?
+`
+
+type GetTracerouteQueueParams struct {
+	MonitorID  uint32       `json:"monitor_id"`
+	SentBefore sql.NullTime `json:"sent_before"`
+	Limit      int32        `json:"limit"`
+}
+
+func (q *Queries) GetTracerouteQueue(ctx context.Context, arg GetTracerouteQueueParams) ([]Server, error) {
+	rows, err := q.db.QueryContext(ctx, getTracerouteQueue, arg.MonitorID, arg.SentBefore, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Server
+	for rows.Next() {
+		var i Server
+		if err := rows.Scan(
+			&i.ID,
+			&i.Ip,
+			&i.IpVersion,
+			&i.UserID,
+			&i.AccountID,
+			&i.Hostname,
+			&i.Stratum,
+			&i.InPool,
+			&i.InServerList,
+			&i.Netspeed,
+			&i.NetspeedTarget,
+			&i.CreatedOn,
+			&i.UpdatedOn,
+			&i.ScoreTs,
+			&i.ScoreRaw,
+			&i.DeletionOn,
+			&i.Flags,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getVoidedMonitorServers = `-- name: GetVoidedMonitorServers :many
+SELECT DISTINCT ls.monitor_id, ls.server_id
+  FROM log_scores_voided v
+  INNER JOIN log_scores ls ON (ls.id = v.log_score_id)
+  WHERE v.invalidation_id = ?
+  ORDER BY ls.server_id, ls.monitor_id
+`
+
+type GetVoidedMonitorServersRow struct {
+	MonitorID sql.NullInt32 `json:"monitor_id"`
+	ServerID  uint32        `json:"server_id"`
+}
+
+func (q *Queries) GetVoidedMonitorServers(ctx context.Context, invalidationID uint32) ([]GetVoidedMonitorServersRow, error) {
+	rows, err := q.db.QueryContext(ctx, getVoidedMonitorServers, invalidationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetVoidedMonitorServersRow
+	for rows.Next() {
+		var i GetVoidedMonitorServersRow
+		if err := rows.Scan(&i.MonitorID, &i.ServerID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertLog = `-- name: InsertLog :exec
+INSERT INTO logs
+  (account_id, server_id, type, message, changes, created_on)
+  VALUES (?, ?, ?, ?, ?, NOW())
+`
+
+type InsertLogParams struct {
+	AccountID sql.NullInt32  `json:"account_id"`
+	ServerID  sql.NullInt32  `json:"server_id"`
+	Type      sql.NullString `json:"type"`
+	Message   sql.NullString `json:"message"`
+	Changes   sql.NullString `json:"changes"`
+}
+
+func (q *Queries) InsertLog(ctx context.Context, arg InsertLogParams) error {
+	_, err := q.db.ExecContext(ctx, insertLog,
+		arg.AccountID,
+		arg.ServerID,
+		arg.Type,
+		arg.Message,
+		arg.Changes,
+	)
+	return err
+}
+
 const insertLogScore = `-- name: InsertLogScore :execresult
 INSERT INTO log_scores
   (server_id, monitor_id, ts, score, step, offset, rtt, attributes)
@@ -739,6 +1618,99 @@ func (q *Queries) InsertLogScore(ctx context.Context, arg InsertLogScoreParams)
 	)
 }
 
+const insertLogScoreInvalidation = `-- name: InsertLogScoreInvalidation :execresult
+INSERT INTO log_score_invalidations
+  (monitor_id, batch_id, start_ts, end_ts, reason, created_on)
+  VALUES (?, ?, ?, ?, ?, NOW())
+`
+
+type InsertLogScoreInvalidationParams struct {
+	MonitorID sql.NullInt32  `json:"monitor_id"`
+	BatchID   sql.NullString `json:"batch_id"`
+	StartTs   time.Time      `json:"start_ts"`
+	EndTs     time.Time      `json:"end_ts"`
+	Reason    string         `json:"reason"`
+}
+
+func (q *Queries) InsertLogScoreInvalidation(ctx context.Context, arg InsertLogScoreInvalidationParams) (sql.Result, error) {
+	return q.db.ExecContext(ctx, insertLogScoreInvalidation,
+		arg.MonitorID,
+		arg.BatchID,
+		arg.StartTs,
+		arg.EndTs,
+		arg.Reason,
+	)
+}
+
+const insertLogScorePacket = `-- name: InsertLogScorePacket :exec
+INSERT INTO log_scores_packets
+  (log_score_id, sample, selected, source_ip, destination_ip,
+   t1, t4, query_data, response_data)
+  values (?, ?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+type InsertLogScorePacketParams struct {
+	LogScoreID    uint64         `json:"log_score_id"`
+	Sample        uint8          `json:"sample"`
+	Selected      bool           `json:"selected"`
+	SourceIp      sql.NullString `json:"source_ip"`
+	DestinationIp sql.NullString `json:"destination_ip"`
+	T1            sql.NullTime   `json:"t1"`
+	T4            sql.NullTime   `json:"t4"`
+	QueryData     []byte         `json:"query_data"`
+	ResponseData  []byte         `json:"response_data"`
+}
+
+func (q *Queries) InsertLogScorePacket(ctx context.Context, arg InsertLogScorePacketParams) error {
+	_, err := q.db.ExecContext(ctx, insertLogScorePacket,
+		arg.LogScoreID,
+		arg.Sample,
+		arg.Selected,
+		arg.SourceIp,
+		arg.DestinationIp,
+		arg.T1,
+		arg.T4,
+		arg.QueryData,
+		arg.ResponseData,
+	)
+	return err
+}
+
+const insertMonitorBatch = `-- name: InsertMonitorBatch :exec
+INSERT INTO monitor_batches
+  (monitor_id, batch_id, batch_ts, results,
+   clock_offset, clock_dispersion, clock_max_error, clock_synced, clock_flagged,
+   created_on)
+  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, NOW(6))
+`
+
+type InsertMonitorBatchParams struct {
+	MonitorID       uint32          `json:"monitor_id"`
+	BatchID         string          `json:"batch_id"`
+	BatchTs         time.Time       `json:"batch_ts"`
+	Results         []byte          `json:"results"`
+	ClockOffset     sql.NullFloat64 `json:"clock_offset"`
+	ClockDispersion sql.NullFloat64 `json:"clock_dispersion"`
+	ClockMaxError   sql.NullFloat64 `json:"clock_max_error"`
+	ClockSynced     sql.NullBool    `json:"clock_synced"`
+	ClockFlagged    bool            `json:"clock_flagged"`
+}
+
+func (q *Queries) InsertMonitorBatch(ctx context.Context, arg InsertMonitorBatchParams) error {
+	_, err := q.db.ExecContext(ctx, insertMonitorBatch,
+		arg.MonitorID,
+		arg.BatchID,
+		arg.BatchTs,
+		arg.Results,
+		arg.ClockOffset,
+		arg.ClockDispersion,
+		arg.ClockMaxError,
+		arg.ClockSynced,
+		arg.ClockFlagged,
+	)
+	return err
+}
+
 const insertScorer = `-- name: InsertScorer :execresult
 insert into monitors
    (type, user_id, account_id,
@@ -800,6 +1772,100 @@ func (q *Queries) InsertServerScore(ctx context.Context, arg InsertServerScorePa
 	return err
 }
 
+const insertTraceroute = `-- name: InsertTraceroute :exec
+INSERT INTO traceroutes
+  (server_id, monitor_id, ts, output, error)
+  VALUES (?, ?, ?, ?, ?)
+`
+
+type InsertTracerouteParams struct {
+	ServerID  uint32         `json:"server_id"`
+	MonitorID uint32         `json:"monitor_id"`
+	Ts        time.Time      `json:"ts"`
+	Output    sql.NullString `json:"output"`
+	Error     sql.NullString `json:"error"`
+}
+
+func (q *Queries) InsertTraceroute(ctx context.Context, arg InsertTracerouteParams) error {
+	_, err := q.db.ExecContext(ctx, insertTraceroute,
+		arg.ServerID,
+		arg.MonitorID,
+		arg.Ts,
+		arg.Output,
+		arg.Error,
+	)
+	return err
+}
+
+const queueTraceroute = `-- name: QueueTraceroute :exec
+INSERT INTO traceroute_queue
+  (server_id, monitor_id, queue_ts)
+  VALUES (?, ?, NOW())
+  ON DUPLICATE KEY UPDATE
+    queue_ts = IF(queue_ts IS NULL
+                  AND (last_traceroute IS NULL
+                       OR last_traceroute < ?),
+                  NOW(), queue_ts)
+`
+
+type QueueTracerouteParams struct {
+	ServerID             uint32       `json:"server_id"`
+	MonitorID            uint32       `json:"monitor_id"`
+	LastTracerouteBefore sql.NullTime `json:"last_traceroute_before"`
+}
+
+func (q *Queries) QueueTraceroute(ctx context.Context, arg QueueTracerouteParams) error {
+	_, err := q.db.ExecContext(ctx, queueTraceroute, arg.ServerID, arg.MonitorID, arg.LastTracerouteBefore)
+	return err
+}
+
+const updateLogScoreScore = `-- name: UpdateLogScoreScore :exec
+UPDATE log_scores SET score = ? WHERE id = ?
+`
+
+type UpdateLogScoreScoreParams struct {
+	Score float64 `json:"score"`
+	ID    uint64  `json:"id"`
+}
+
+func (q *Queries) UpdateLogScoreScore(ctx context.Context, arg UpdateLogScoreScoreParams) error {
+	_, err := q.db.ExecContext(ctx, updateLogScoreScore, arg.Score, arg.ID)
+	return err
+}
+
+const updateMonitorClockCheck = `-- name: UpdateMonitorClockCheck :exec
+INSERT INTO monitor_clock_checks
+  (monitor_id, checked_on, bias, servers, consistency, flagged_since)
+  VALUES (?, ?, ?, ?, ?, ?)
+  ON DUPLICATE KEY UPDATE
+    checked_on = VALUES(checked_on),
+    bias = VALUES(bias),
+    servers = VALUES(servers),
+    consistency = VALUES(consistency),
+    flagged_since = VALUES(flagged_since)
+`
+
+type UpdateMonitorClockCheckParams struct {
+	MonitorID    uint32       `json:"monitor_id"`
+	CheckedOn    time.Time    `json:"checked_on"`
+	Bias         float64      `json:"bias"`
+	Servers      uint32       `json:"servers"`
+	Consistency  float64      `json:"consistency"`
+	FlaggedSince sql.NullTime `json:"flagged_since"`
+}
+
+func (q *Queries) UpdateMonitorClockCheck(ctx context.Context, arg UpdateMonitorClockCheckParams) error {
+	_, err := q.db.ExecContext(ctx, updateMonitorClockCheck,
+		arg.MonitorID,
+		arg.CheckedOn,
+		arg.Bias,
+		arg.Servers,
+		arg.Consistency,
+		arg.FlaggedSince,
+	)
+	return err
+}
+
 const updateMonitorSeen = `-- name: UpdateMonitorSeen :exec
 UPDATE monitors
   SET last_seen = ?
@@ -816,6 +1882,22 @@ func (q *Queries) UpdateMonitorSeen(ctx context.Context, arg UpdateMonitorSeenPa
 	return err
 }
 
+const updateMonitorStatus = `-- name: UpdateMonitorStatus :exec
+UPDATE monitors
+  SET status = ?
+  WHERE id = ?
+`
+
+type UpdateMonitorStatusParams struct {
+	Status MonitorsStatus `json:"status"`
+	ID     uint32         `json:"id"`
+}
+
+func (q *Queries) UpdateMonitorStatus(ctx context.Context, arg UpdateMonitorStatusParams) error {
+	_, err := q.db.ExecContext(ctx, updateMonitorStatus, arg.Status, arg.ID)
+	return err
+}
+
 const updateMonitorSubmit = `-- name: UpdateMonitorSubmit :exec
 UPDATE monitors
   SET last_submit = ?, last_seen = ?
@@ -890,6 +1972,35 @@ func (q *Queries) UpdateServer(ctx context.Context, arg UpdateServerParams) erro
 	return err
 }
 
+const updateServerOffset = `-- name: UpdateServerOffset :exec
+INSERT INTO server_offsets (server_id, ts, offset, spread, monitors)
+  VALUES (?, ?, ?, ?, ?)
+  ON DUPLICATE KEY UPDATE
+    ts = VALUES(ts),
+    offset = VALUES(offset),
+    spread = VALUES(spread),
+    monitors = VALUES(monitors)
+`
+
+type UpdateServerOffsetParams struct {
+	ServerID uint32    `json:"server_id"`
+	Ts       time.Time `json:"ts"`
+	Offset   float64   `json:"offset"`
+	Spread   float64   `json:"spread"`
+	Monitors uint16    `json:"monitors"`
+}
+
+func (q *Queries) UpdateServerOffset(ctx context.Context, arg UpdateServerOffsetParams) error {
+	_, err := q.db.ExecContext(ctx, updateServerOffset,
+		arg.ServerID,
+		arg.Ts,
+		arg.Offset,
+		arg.Spread,
+		arg.Monitors,
+	)
+	return err
+}
+
 const updateServerScore = `-- name: UpdateServerScore :exec
 UPDATE server_scores
   SET score_ts  = ?,
@@ -968,7 +2079,8 @@ func (q *Queries) UpdateServerScorePauseReason(ctx context.Context, arg UpdateSe
 
 const updateServerScoreQueue = `-- name: UpdateServerScoreQueue :exec
 UPDATE server_scores
-  SET queue_ts  = ?
+  SET queue_ts  = ?,
+      lease_expires = ?
   WHERE
     monitor_id = ?
     AND server_id IN (/*SLICE:server_ids*/?)
@@ -977,15 +2089,17 @@ UPDATE server_scores
 `
 
 type UpdateServerScoreQueueParams struct {
-	QueueTs   sql.NullTime `json:"queue_ts"`
-	MonitorID uint32       `json:"monitor_id"`
-	ServerIds []uint32     `json:"server_ids"`
+	QueueTs      sql.NullTime `json:"queue_ts"`
+	LeaseExpires sql.NullTime `json:"lease_expires"`
+	MonitorID    uint32       `json:"monitor_id"`
+	ServerIds    []uint32     `json:"server_ids"`
 }
 
 func (q *Queries) UpdateServerScoreQueue(ctx context.Context, arg UpdateServerScoreQueueParams) error {
 	query := updateServerScoreQueue
 	var queryParams []interface{}
 	queryParams = append(queryParams, arg.QueueTs)
+	queryParams = append(queryParams, arg.LeaseExpires)
 	queryParams = append(queryParams, arg.MonitorID)
 	if len(arg.ServerIds) > 0 {
 		for _, v := range arg.ServerIds {
@@ -1084,3 +2198,98 @@ func (q *Queries) UpdateServersMonitorReviewChanged(ctx context.Context, arg Upd
 	_, err := q.db.ExecContext(ctx, updateServersMonitorReviewChanged, arg.NextReview, arg.ServerID)
 	return err
 }
+
+const updateSystemSetting = `-- name: UpdateSystemSetting :exec
+INSERT INTO system_settings (` + "`" + `key` + "`" + `, value, created_on)
+  VALUES (?, ?, NOW())
+  ON DUPLICATE KEY UPDATE value = VALUES(value)
+`
+
+type UpdateSystemSettingParams struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+func (q *Queries) UpdateSystemSetting(ctx context.Context, arg UpdateSystemSettingParams) error {
+	_, err := q.db.ExecContext(ctx, updateSystemSetting, arg.Key, arg.Value)
+	return err
+}
+
+const updateTracerouteQueueDone = `-- name: UpdateTracerouteQueueDone :exec
+UPDATE traceroute_queue
+  SET queue_ts = NULL, sent_ts = NULL, last_traceroute = ?
+  WHERE monitor_id = ? AND server_id = ?
+`
+
+type UpdateTracerouteQueueDoneParams struct {
+	LastTraceroute sql.NullTime `json:"last_traceroute"`
+	MonitorID      uint32       `json:"monitor_id"`
+	ServerID       uint32       `json:"server_id"`
+}
+
+func (q *Queries) UpdateTracerouteQueueDone(ctx context.Context, arg UpdateTracerouteQueueDoneParams) error {
+	_, err := q.db.ExecContext(ctx, updateTracerouteQueueDone, arg.LastTraceroute, arg.MonitorID, arg.ServerID)
+	return err
+}
+
+const updateTracerouteQueueSent = `-- name: UpdateTracerouteQueueSent :exec
+UPDATE traceroute_queue
+  SET sent_ts = ?
+  WHERE
+    monitor_id = ?
+    AND server_id IN (/*SLICE:server_ids*/?)
+`
+
+type UpdateTracerouteQueueSentParams struct {
+	SentTs    sql.NullTime `json:"sent_ts"`
+	MonitorID uint32       `json:"monitor_id"`
+	ServerIds []uint32     `json:"server_ids"`
+}
+
+func (q *Queries) UpdateTracerouteQueueSent(ctx context.Context, arg UpdateTracerouteQueueSentParams) error {
+	query := updateTracerouteQueueSent
+	var queryParams []interface{}
+	queryParams = append(queryParams, arg.SentTs)
+	queryParams = append(queryParams, arg.MonitorID)
+	if len(arg.ServerIds) > 0 {
+		for _, v := range arg.ServerIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:server_ids*/?", strings.Repeat(",?", len(arg.ServerIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:server_ids*/?", "NULL", 1)
+	}
+	_, err := q.db.ExecContext(ctx, query, queryParams...)
+	return err
+}
+
+const voidLogScores = `-- name: VoidLogScores :execresult
+INSERT IGNORE INTO log_scores_voided (log_score_id, invalidation_id)
+  SELECT ls.id, ?
+    FROM log_scores ls
+    INNER JOIN monitors m ON (m.id = ls.monitor_id AND m.type = 'monitor')
+    WHERE ls.ts >= ?
+      AND ls.ts <= ?
+      AND (ls.monitor_id = ? OR 0 = ?)
+      AND (JSON_VALUE(ls.attributes, '$.batch_id') = ? OR '' = ?)
+`
+
+type VoidLogScoresParams struct {
+	InvalidationID uint32    `json:"invalidation_id"`
+	StartTs        time.Time `json:"start_ts"`
+	EndTs          time.Time `json:"end_ts"`
+	MonitorID      uint32    `json:"monitor_id"`
+	BatchID        string    `json:"batch_id"`
+}
+
+func (q *Queries) VoidLogScores(ctx context.Context, arg VoidLogScoresParams) (sql.Result, error) {
+	return q.db.ExecContext(ctx, voidLogScores,
+		arg.InvalidationID,
+		arg.StartTs,
+		arg.EndTs,
+		arg.MonitorID,
+		arg.MonitorID,
+		arg.BatchID,
+		arg.BatchID,
+	)
+}
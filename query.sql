@@ -37,7 +37,8 @@ UPDATE server_scores
 
 -- name: UpdateServerScoreQueue :exec
 UPDATE server_scores
-  SET queue_ts  = sqlc.arg('queue_ts')
+  SET queue_ts  = sqlc.arg('queue_ts'),
+      lease_expires = sqlc.arg('lease_expires')
   WHERE
     monitor_id = ?
     AND server_id IN (sqlc.slice('server_ids'))
@@ -117,13 +118,16 @@ select id from log_scores order by id limit 1;
 
 -- name: GetScorerLogScores :many
 select ls.* from
-  log_scores ls use index (primary),
+  log_scores ls use index (primary)
+    left join log_scores_voided v on (v.log_score_id = ls.id),
   monitors m
 WHERE
   ls.id >  sqlc.arg('log_score_id') AND
   ls.id < (sqlc.arg('log_score_id')+10000) AND
   m.type = 'monitor' AND
-  monitor_id = m.id
+  monitor_id = m.id AND
+  v.log_score_id IS NULL AND
+  JSON_VALUE(ls.attributes, '$.quarantined') IS NULL
 ORDER by ls.id
 LIMIT ?;
 
@@ -139,7 +143,8 @@ select ls.id from
 WHERE
   ls.id > sqlc.arg('log_score_id') AND
   m.type = 'monitor' AND
-  monitor_id = m.id
+  monitor_id = m.id AND
+  JSON_VALUE(ls.attributes, '$.quarantined') IS NULL
 ORDER by id
 limit 1;
 
@@ -148,10 +153,13 @@ limit 1;
    from log_scores ls
    inner join
    (select ls2.monitor_id, max(ls2.ts) as sts
-      from log_scores ls2,
+      from log_scores ls2
+         left join log_scores_voided v on (v.log_score_id = ls2.id),
          monitors m,
          server_scores ss
       where ls2.server_id = sqlc.arg('server_id')
+         and v.log_score_id is null
+         and json_value(ls2.attributes, '$.quarantined') is null
          and ls2.monitor_id=m.id and m.type = 'monitor'
          and (ls2.monitor_id=ss.monitor_id and ls2.server_id=ss.server_id)
          and ss.status in (sqlc.arg('monitor_status'),sqlc.narg('monitor_status_2'))
@@ -185,6 +193,12 @@ INSERT INTO log_scores
   (server_id, monitor_id, ts, score, step, offset, rtt, attributes)
   values (?, ?, ?, ?, ?, ?, ?, ?);
 
+-- name: InsertLogScorePacket :exec
+INSERT INTO log_scores_packets
+  (log_score_id, sample, selected, source_ip, destination_ip,
+   t1, t4, query_data, response_data)
+  values (?, ?, ?, ?, ?, ?, ?, ?, ?);
+
 -- name: GetServers :many
 SELECT s.*
     FROM servers s
@@ -197,11 +211,12 @@ WHERE (monitor_id = sqlc.arg('monitor_id')
                AND ss.queue_ts < DATE_SUB( NOW(), INTERVAL sqlc.arg('interval_seconds') second))
           OR (ss.score_raw > -90 AND ss.status = "testing"
               AND ss.queue_ts < DATE_SUB( NOW(), INTERVAL sqlc.arg('interval_seconds_testing') second))
-          OR (ss.queue_ts < DATE_SUB( NOW(), INTERVAL 120 minute)))
+          OR (ss.queue_ts < DATE_SUB( NOW(), INTERVAL 120 minute))
+          OR (ss.lease_expires < NOW()))
     AND (s.score_ts IS NULL OR
         (s.score_ts < DATE_SUB( NOW(), INTERVAL sqlc.arg('interval_seconds_all') second) ))
     AND (deletion_on IS NULL or deletion_on > NOW()))
-ORDER BY ss.queue_ts
+ORDER BY IF(ss.lease_expires < NOW(), 0, 1), ss.queue_ts
 LIMIT  ?
 OFFSET ?;
 
@@ -217,17 +232,26 @@ select m.id, m.id_token, m.tls_name, m.account_id, m.ip as monitor_ip,
     ss.constraint_violation_type,
     ss.constraint_violation_since,
     ss.last_constraint_check,
-    ss.pause_reason
+    ss.pause_reason,
+    (select if(sum(mls.issued) >= 50, sum(mls.returned) / sum(mls.issued), NULL)
+       from monitor_lease_stats mls
+       where mls.monitor_id = m.id
+         and mls.date >= date_sub(curdate(), interval 7 day)) as reliability,
+    avg(ls.offset) as avg_offset,
+    m.location
   from log_scores ls
   inner join monitors m
   left join server_scores ss on (ss.server_id = ls.server_id and ss.monitor_id = ls.monitor_id)
   left join accounts a on (m.account_id = a.id)
+  left join log_scores_voided v on (v.log_score_id = ls.id)
   where
     m.id = ls.monitor_id
   and ls.server_id = ?
   and m.type = 'monitor'
   and ls.ts > date_sub(now(), interval 24 hour)
-  group by m.id, m.id_token, m.tls_name, m.account_id, m.ip, m.status, ss.status, a.flags,
+  and v.log_score_id is null
+  and json_value(ls.attributes, '$.quarantined') is null
+  group by m.id, m.id_token, m.tls_name, m.account_id, m.ip, m.location, m.status, ss.status, a.flags,
            ss.constraint_violation_type, ss.constraint_violation_since, ss.last_constraint_check, ss.pause_reason
   order by healthy desc, monitor_priority, avg_step desc, avg_rtt;
 
@@ -279,4 +303,283 @@ WHERE server_id = ? AND monitor_id = ?;
 -- name: DeleteServerScore :exec
 -- Remove a monitor assignment from a server
 DELETE FROM server_scores
-WHERE server_id = ? AND monitor_id = ?
+WHERE server_id = ? AND monitor_id = ?;
+
+-- name: QueueTraceroute :exec
+INSERT INTO traceroute_queue
+  (server_id, monitor_id, queue_ts)
+  VALUES (?, ?, NOW())
+  ON DUPLICATE KEY UPDATE
+    queue_ts = IF(queue_ts IS NULL
+                  AND (last_traceroute IS NULL
+                       OR last_traceroute < sqlc.arg('last_traceroute_before')),
+                  NOW(), queue_ts);
+
+-- name: GetTracerouteQueue :many
+SELECT s.*
+  FROM traceroute_queue tq
+  INNER JOIN servers s
+    ON (s.id=tq.server_id)
+  WHERE tq.monitor_id = ?
+    AND tq.queue_ts IS NOT NULL
+    AND (tq.sent_ts IS NULL OR tq.sent_ts < sqlc.arg('sent_before'))
+    AND (s.deletion_on IS NULL OR s.deletion_on > NOW())
+  ORDER BY tq.queue_ts
+  LIMIT ?;
+
+-- name: UpdateTracerouteQueueSent :exec
+UPDATE traceroute_queue
+  SET sent_ts = ?
+  WHERE
+    monitor_id = ?
+    AND server_id IN (sqlc.slice('server_ids'));
+
+-- name: UpdateTracerouteQueueDone :exec
+UPDATE traceroute_queue
+  SET queue_ts = NULL, sent_ts = NULL, last_traceroute = ?
+  WHERE monitor_id = ? AND server_id = ?;
+
+-- name: InsertTraceroute :exec
+INSERT INTO traceroutes
+  (server_id, monitor_id, ts, output, error)
+  VALUES (?, ?, ?, ?, ?);
+
+-- name: GetMonitorBatchResults :one
+SELECT results FROM monitor_batches
+  WHERE monitor_id = ? AND batch_id = ?;
+
+-- name: InsertMonitorBatch :exec
+INSERT INTO monitor_batches
+  (monitor_id, batch_id, batch_ts, results,
+   clock_offset, clock_dispersion, clock_max_error, clock_synced, clock_flagged,
+   created_on)
+  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, NOW(6));
+
+-- name: DeleteMonitorBatches :exec
+DELETE FROM monitor_batches
+  WHERE monitor_id = ? AND batch_ts < ?;
+
+-- name: ClearServerScoreLease :exec
+UPDATE server_scores
+  SET lease_expires = NULL
+  WHERE id = ?;
+
+-- name: GetServerScoreLeases :many
+-- Leases the monitor has for the servers with the IPs
+SELECT ss.id, ss.lease_expires
+  FROM server_scores ss
+  INNER JOIN servers s ON (s.id = ss.server_id)
+  WHERE ss.monitor_id = ?
+    AND s.ip IN (sqlc.slice('ips'))
+    AND ss.lease_expires IS NOT NULL;
+
+-- name: AddMonitorLeasesIssued :exec
+INSERT INTO monitor_lease_stats
+  (monitor_id, date, issued)
+  VALUES (?, CURDATE(), ?)
+  ON DUPLICATE KEY UPDATE
+    issued = issued + VALUES(issued);
+
+-- name: AddMonitorLeasesReturned :exec
+INSERT INTO monitor_lease_stats
+  (monitor_id, date, returned, late)
+  VALUES (?, CURDATE(), ?, ?)
+  ON DUPLICATE KEY UPDATE
+    returned = returned + VALUES(returned),
+    late = late + VALUES(late);
+
+-- name: GetMonitorLeaseStats :one
+SELECT CAST(COALESCE(SUM(issued), 0) AS SIGNED) AS issued,
+       CAST(COALESCE(SUM(returned), 0) AS SIGNED) AS returned,
+       CAST(COALESCE(SUM(late), 0) AS SIGNED) AS late
+  FROM monitor_lease_stats
+  WHERE monitor_id = ? AND date >= ?;
+
+-- name: GetPeerResponseCounts :one
+-- Results from other monitors for the servers, without quarantined timeouts
+SELECT CAST(COUNT(*) AS SIGNED) AS total,
+       CAST(COALESCE(SUM(JSON_VALUE(ls.attributes, '$.no_response') IS NULL), 0) AS SIGNED) AS responses
+  FROM log_scores ls
+  INNER JOIN servers s ON (s.id = ls.server_id)
+  INNER JOIN monitors m ON (m.id = ls.monitor_id)
+  WHERE s.ip IN (sqlc.slice('ips'))
+    AND ls.monitor_id != sqlc.arg('monitor_id')
+    AND m.type = 'monitor'
+    AND ls.ts > sqlc.arg('since')
+    AND JSON_VALUE(ls.attributes, '$.quarantined') IS NULL;
+
+-- name: GetMonitorResponseCounts :one
+SELECT CAST(COUNT(*) AS SIGNED) AS total,
+       CAST(COALESCE(SUM(JSON_VALUE(attributes, '$.no_response') IS NULL), 0) AS SIGNED) AS responses
+  FROM log_scores
+  WHERE monitor_id = ? AND ts > ?
+    AND JSON_VALUE(attributes, '$.quarantined') IS NULL;
+
+-- name: GetActiveMonitors :many
+SELECT * FROM monitors
+WHERE type = 'monitor'
+  AND status IN ('active', 'testing')
+  AND is_current = 1
+  AND deleted_on is null;
+
+-- name: GetMonitorServerOffsets :many
+-- Average offset each active or testing monitor measured for each server
+SELECT m.id AS monitor_id, ls.server_id,
+       AVG(ls.offset) AS offset,
+       CAST(COUNT(*) AS SIGNED) AS samples
+  FROM log_scores ls
+  INNER JOIN monitors m ON (m.id = ls.monitor_id)
+  LEFT JOIN log_scores_voided v ON (v.log_score_id = ls.id)
+  WHERE m.type = 'monitor'
+    AND m.status IN ('active', 'testing')
+    AND ls.ts > ?
+    AND ls.offset IS NOT NULL
+    AND v.log_score_id IS NULL
+  GROUP BY m.id, ls.server_id;
+
+-- name: GetMonitorClockChecks :many
+SELECT * FROM monitor_clock_checks;
+
+-- name: UpdateMonitorClockCheck :exec
+INSERT INTO monitor_clock_checks
+  (monitor_id, checked_on, bias, servers, consistency, flagged_since)
+  VALUES (?, ?, ?, ?, ?, ?)
+  ON DUPLICATE KEY UPDATE
+    checked_on = VALUES(checked_on),
+    bias = VALUES(bias),
+    servers = VALUES(servers),
+    consistency = VALUES(consistency),
+    flagged_since = VALUES(flagged_since);
+
+-- name: UpdateMonitorStatus :exec
+UPDATE monitors
+  SET status = ?
+  WHERE id = ?;
+
+-- name: InsertLog :exec
+INSERT INTO logs
+  (account_id, server_id, type, message, changes, created_on)
+  VALUES (?, ?, ?, ?, ?, NOW());
+
+-- name: GetMonitorByID :one
+SELECT * FROM monitors WHERE id = ?;
+
+-- name: GetServerScoreForUpdate :one
+SELECT * FROM server_scores
+  WHERE
+    server_id=? AND
+    monitor_id=?
+  FOR UPDATE;
+
+-- name: InsertLogScoreInvalidation :execresult
+INSERT INTO log_score_invalidations
+  (monitor_id, batch_id, start_ts, end_ts, reason, created_on)
+  VALUES (?, ?, ?, ?, ?, NOW());
+
+-- name: VoidLogScores :execresult
+INSERT IGNORE INTO log_scores_voided (log_score_id, invalidation_id)
+  SELECT ls.id, sqlc.arg('invalidation_id')
+    FROM log_scores ls
+    INNER JOIN monitors m ON (m.id = ls.monitor_id AND m.type = 'monitor')
+    WHERE ls.ts >= sqlc.arg('start_ts')
+      AND ls.ts <= sqlc.arg('end_ts')
+      AND (ls.monitor_id = sqlc.arg('monitor_id') OR 0 = sqlc.arg('monitor_id'))
+      AND (JSON_VALUE(ls.attributes, '$.batch_id') = sqlc.arg('batch_id') OR '' = sqlc.arg('batch_id'));
+
+-- name: GetVoidedMonitorServers :many
+SELECT DISTINCT ls.monitor_id, ls.server_id
+  FROM log_scores_voided v
+  INNER JOIN log_scores ls ON (ls.id = v.log_score_id)
+  WHERE v.invalidation_id = ?
+  ORDER BY ls.server_id, ls.monitor_id;
+
+-- name: GetReplayBaseLogScore :one
+SELECT ls.* FROM log_scores ls
+  LEFT JOIN log_scores_voided v ON (v.log_score_id = ls.id)
+  WHERE ls.monitor_id = sqlc.arg('monitor_id')
+    AND ls.server_id = sqlc.arg('server_id')
+    AND ls.ts < sqlc.arg('ts')
+    AND v.log_score_id IS NULL
+    AND JSON_VALUE(ls.attributes, '$.quarantined') IS NULL
+  ORDER BY ls.ts DESC, ls.id DESC
+  LIMIT 1;
+
+-- name: GetReplayLogScores :many
+SELECT ls.* FROM log_scores ls
+  LEFT JOIN log_scores_voided v ON (v.log_score_id = ls.id)
+  WHERE ls.monitor_id = sqlc.arg('monitor_id')
+    AND ls.server_id = sqlc.arg('server_id')
+    AND ls.ts >= sqlc.arg('ts')
+    AND v.log_score_id IS NULL
+    AND JSON_VALUE(ls.attributes, '$.quarantined') IS NULL
+  ORDER BY ls.ts, ls.id;
+
+-- name: UpdateLogScoreScore :exec
+UPDATE log_scores SET score = ? WHERE id = ?;
+
+-- name: GetFirstLogScoreID :one
+SELECT id FROM log_scores
+  WHERE ts >= ?
+  ORDER BY ts, id
+  LIMIT 1;
+
+-- name: GetScorerLogScoresByTime :many
+SELECT * FROM log_scores
+  WHERE monitor_id = sqlc.arg('monitor_id')
+    AND ts >= sqlc.arg('start_ts')
+    AND ts <= sqlc.arg('end_ts')
+  ORDER BY ts, id;
+
+-- name: UpdateSystemSetting :exec
+INSERT INTO system_settings (`key`, value, created_on)
+  VALUES (?, ?, NOW())
+  ON DUPLICATE KEY UPDATE value = VALUES(value);
+
+-- name: GetServerOffset :one
+SELECT * FROM server_offsets WHERE server_id = ?;
+
+-- name: UpdateServerOffset :exec
+INSERT INTO server_offsets (server_id, ts, offset, spread, monitors)
+  VALUES (?, ?, ?, ?, ?)
+  ON DUPLICATE KEY UPDATE
+    ts = VALUES(ts),
+    offset = VALUES(offset),
+    spread = VALUES(spread),
+    monitors = VALUES(monitors);
+
+-- name: GetAvailableMonitors :many
+select m.id, m.id_token, m.tls_name, m.account_id, m.ip as monitor_ip,
+    m.status as monitor_status, a.flags as account_flags,
+    (select count(*) from server_scores mss where mss.monitor_id = m.id) as server_count
+  from monitors m
+  left join accounts a on (m.account_id = a.id)
+  where m.type = 'monitor'
+    and m.status in ('active', 'testing')
+    and m.ip_version = sqlc.arg('ip_version')
+    and m.last_seen > date_sub(now(), interval 1 hour)
+    and not exists (
+      select 1 from server_scores ss
+        where ss.server_id = sqlc.arg('server_id') and ss.monitor_id = m.id)
+  order by m.status = 'active' desc, server_count, m.id;
+
+-- name: GetServerScoreMonitors :many
+select ss.monitor_id, ss.status, m.account_id
+  from server_scores ss
+  inner join monitors m on (m.id = ss.monitor_id)
+  where ss.server_id = ? and m.type = 'monitor';
+
+-- name: GetServerMonitorReviewConfig :one
+select config from servers_monitor_review where server_id = ?;
+
+-- name: GetServerZoneShare :one
+select
+    (select count(*) from server_zones sz where sz.server_id = s.id) as zones,
+    (select max(s.netspeed / zsc.netspeed_active)
+       from server_zones sz
+       inner join zone_server_counts zsc on (zsc.zone_id = sz.zone_id)
+       where sz.server_id = s.id
+         and zsc.ip_version = s.ip_version
+         and zsc.date = (select max(date) from zone_server_counts)
+         and zsc.netspeed_active > 0) as zone_share
+  from servers s
+  where s.id = ?;
@@ -215,6 +215,27 @@ CREATE TABLE `dns_roots` (
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3;
 /*!40101 SET character_set_client = @saved_cs_client */;
 
+--
+-- Table structure for table `log_score_invalidations`
+--
+
+DROP TABLE IF EXISTS `log_score_invalidations`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!40101 SET character_set_client = utf8mb4 */;
+CREATE TABLE `log_score_invalidations` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `monitor_id` int unsigned DEFAULT NULL,
+  `batch_id` varchar(26) DEFAULT NULL,
+  `start_ts` datetime NOT NULL,
+  `end_ts` datetime NOT NULL,
+  `reason` varchar(255) NOT NULL,
+  `created_on` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `log_score_invalidations_monitor_fk` (`monitor_id`),
+  CONSTRAINT `log_score_invalidations_monitor_fk` FOREIGN KEY (`monitor_id`) REFERENCES `monitors` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
 --
 -- Table structure for table `log_scores`
 --
@@ -260,6 +281,47 @@ CREATE TABLE `log_scores_archive_status` (
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3;
 /*!40101 SET character_set_client = @saved_cs_client */;
 
+--
+-- Table structure for table `log_scores_packets`
+--
+
+DROP TABLE IF EXISTS `log_scores_packets`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!40101 SET character_set_client = utf8mb4 */;
+CREATE TABLE `log_scores_packets` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `log_score_id` bigint unsigned NOT NULL,
+  `sample` tinyint unsigned NOT NULL,
+  `selected` tinyint(1) NOT NULL DEFAULT '0',
+  `source_ip` varchar(40) DEFAULT NULL,
+  `destination_ip` varchar(40) DEFAULT NULL,
+  `t1` datetime(6) DEFAULT NULL,
+  `t4` datetime(6) DEFAULT NULL,
+  `query_data` varbinary(1024) DEFAULT NULL,
+  `response_data` varbinary(1024) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `log_score_sample` (`log_score_id`,`sample`),
+  CONSTRAINT `log_scores_packets_log_score_id_fk` FOREIGN KEY (`log_score_id`) REFERENCES `log_scores` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Table structure for table `log_scores_voided`
+--
+
+DROP TABLE IF EXISTS `log_scores_voided`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!40101 SET character_set_client = utf8mb4 */;
+CREATE TABLE `log_scores_voided` (
+  `log_score_id` bigint unsigned NOT NULL,
+  `invalidation_id` int unsigned NOT NULL,
+  PRIMARY KEY (`log_score_id`),
+  KEY `log_scores_voided_invalidation_fk` (`invalidation_id`),
+  CONSTRAINT `log_scores_voided_invalidation_fk` FOREIGN KEY (`invalidation_id`) REFERENCES `log_score_invalidations` (`id`),
+  CONSTRAINT `log_scores_voided_log_score_id_fk` FOREIGN KEY (`log_score_id`) REFERENCES `log_scores` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
 --
 -- Table structure for table `logs`
 --
@@ -289,6 +351,67 @@ CREATE TABLE `logs` (
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3;
 /*!40101 SET character_set_client = @saved_cs_client */;
 
+--
+-- Table structure for table `monitor_batches`
+--
+
+DROP TABLE IF EXISTS `monitor_batches`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!40101 SET character_set_client = utf8mb4 */;
+CREATE TABLE `monitor_batches` (
+  `monitor_id` int unsigned NOT NULL,
+  `batch_id` varchar(26) NOT NULL,
+  `batch_ts` datetime(3) NOT NULL,
+  `results` blob NOT NULL,
+  `clock_offset` double DEFAULT NULL,
+  `clock_dispersion` double DEFAULT NULL,
+  `clock_max_error` double DEFAULT NULL,
+  `clock_synced` tinyint(1) DEFAULT NULL,
+  `clock_flagged` tinyint(1) NOT NULL DEFAULT '0',
+  `created_on` datetime(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  PRIMARY KEY (`monitor_id`,`batch_id`),
+  KEY `monitor_batch_ts` (`monitor_id`,`batch_ts`),
+  CONSTRAINT `monitor_batches_monitor_fk` FOREIGN KEY (`monitor_id`) REFERENCES `monitors` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Table structure for table `monitor_clock_checks`
+--
+
+DROP TABLE IF EXISTS `monitor_clock_checks`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!40101 SET character_set_client = utf8mb4 */;
+CREATE TABLE `monitor_clock_checks` (
+  `monitor_id` int unsigned NOT NULL,
+  `checked_on` datetime NOT NULL,
+  `bias` double NOT NULL,
+  `servers` int unsigned NOT NULL,
+  `consistency` double NOT NULL,
+  `flagged_since` datetime DEFAULT NULL,
+  PRIMARY KEY (`monitor_id`),
+  CONSTRAINT `monitor_clock_checks_monitor_fk` FOREIGN KEY (`monitor_id`) REFERENCES `monitors` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Table structure for table `monitor_lease_stats`
+--
+
+DROP TABLE IF EXISTS `monitor_lease_stats`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!40101 SET character_set_client = utf8mb4 */;
+CREATE TABLE `monitor_lease_stats` (
+  `monitor_id` int unsigned NOT NULL,
+  `date` date NOT NULL,
+  `issued` int unsigned NOT NULL DEFAULT '0',
+  `returned` int unsigned NOT NULL DEFAULT '0',
+  `late` int unsigned NOT NULL DEFAULT '0',
+  PRIMARY KEY (`monitor_id`,`date`),
+  CONSTRAINT `monitor_lease_stats_monitor_fk` FOREIGN KEY (`monitor_id`) REFERENCES `monitors` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
 --
 -- Table structure for table `monitor_registrations`
 --
@@ -477,6 +600,25 @@ CREATE TABLE `server_notes` (
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3;
 /*!40101 SET character_set_client = @saved_cs_client */;
 
+--
+-- Table structure for table `server_offsets`
+--
+
+DROP TABLE IF EXISTS `server_offsets`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!40101 SET character_set_client = utf8mb4 */;
+CREATE TABLE `server_offsets` (
+  `server_id` int unsigned NOT NULL,
+  `ts` datetime NOT NULL,
+  `offset` double NOT NULL,
+  `spread` double NOT NULL,
+  `monitors` smallint unsigned NOT NULL,
+  `modified_on` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`server_id`),
+  CONSTRAINT `server_offsets_server_fk` FOREIGN KEY (`server_id`) REFERENCES `servers` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
 --
 -- Table structure for table `server_scores`
 --
@@ -499,6 +641,7 @@ CREATE TABLE `server_scores` (
   `constraint_violation_since` datetime DEFAULT NULL,
   `last_constraint_check` datetime DEFAULT NULL,
   `pause_reason` varchar(20) DEFAULT NULL,
+  `lease_expires` datetime DEFAULT NULL,
   PRIMARY KEY (`id`),
   UNIQUE KEY `server_id` (`server_id`,`monitor_id`),
   KEY `monitor_id` (`monitor_id`,`server_id`),
@@ -667,6 +810,50 @@ CREATE TABLE `system_settings` (
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3;
 /*!40101 SET character_set_client = @saved_cs_client */;
 
+--
+-- Table structure for table `traceroute_queue`
+--
+
+DROP TABLE IF EXISTS `traceroute_queue`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!40101 SET character_set_client = utf8mb4 */;
+CREATE TABLE `traceroute_queue` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `server_id` int unsigned NOT NULL,
+  `monitor_id` int unsigned NOT NULL,
+  `queue_ts` datetime DEFAULT NULL,
+  `sent_ts` datetime DEFAULT NULL,
+  `last_traceroute` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `server_monitor` (`server_id`,`monitor_id`),
+  KEY `monitor_queue_ts` (`monitor_id`,`queue_ts`),
+  CONSTRAINT `traceroute_queue_monitor_fk` FOREIGN KEY (`monitor_id`) REFERENCES `monitors` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `traceroute_queue_server_fk` FOREIGN KEY (`server_id`) REFERENCES `servers` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Table structure for table `traceroutes`
+--
+
+DROP TABLE IF EXISTS `traceroutes`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!40101 SET character_set_client = utf8mb4 */;
+CREATE TABLE `traceroutes` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `server_id` int unsigned NOT NULL,
+  `monitor_id` int unsigned NOT NULL,
+  `ts` datetime NOT NULL,
+  `output` mediumtext,
+  `error` varchar(255) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `server_ts` (`server_id`,`ts`),
+  KEY `traceroutes_monitor_fk` (`monitor_id`),
+  CONSTRAINT `traceroutes_monitor_fk` FOREIGN KEY (`monitor_id`) REFERENCES `monitors` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `traceroutes_server_fk` FOREIGN KEY (`server_id`) REFERENCES `servers` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
 --
 -- Table structure for table `user_equipment_applications`
 --
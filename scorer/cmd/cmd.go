@@ -3,23 +3,29 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"go.ntppool.org/common/version"
+	"go.ntppool.org/monitor/clockcheck"
 	"go.ntppool.org/monitor/selector"
 )
 
 type RootCmd struct {
-	Scorer   ScorerCmd    `cmd:"scorer" help:"Scoring commands"`
-	Selector selector.Cmd `cmd:"selector" help:"monitor selection"`
+	Scorer     ScorerCmd      `cmd:"scorer" help:"Scoring commands"`
+	Selector   selector.Cmd   `cmd:"selector" help:"monitor selection"`
+	ClockCheck clockcheck.Cmd `cmd:"clockcheck" help:"monitor clock sanity checks"`
 
 	Db      dbCmd      `cmd:"db" help:"Database operations"`
 	Version versionCmd `cmd:"version" help:"Show version"`
 }
 
 type ScorerCmd struct {
-	Run    scorerOnceCmd   `cmd:"run" help:"Run once"`
-	Server scorerServerCmd `cmd:"server" help:"Run continuously"`
-	Setup  scorerSetupCmd  `cmd:"setup" help:"Setup scorers"`
+	Run        scorerOnceCmd       `cmd:"run" help:"Run once"`
+	Server     scorerServerCmd     `cmd:"server" help:"Run continuously"`
+	Setup      scorerSetupCmd      `cmd:"setup" help:"Setup scorers"`
+	Invalidate scorerInvalidateCmd `cmd:"invalidate" help:"Void log scores from a monitor or batch and rescore"`
+	Replay     scorerReplayCmd     `cmd:"replay" help:"Replay a scorer over past log scores and compare with the stored scores"`
+	Promote    scorerPromoteCmd    `cmd:"promote" help:"Make a scorer the main scorer"`
 }
 
 type (
@@ -29,7 +35,28 @@ type (
 	scorerServerCmd struct {
 		MetricsPort int `default:"9000" help:"Metrics server port" flag:"metrics-port"`
 	}
-	scorerSetupCmd struct{}
+	scorerSetupCmd      struct{}
+	scorerInvalidateCmd struct {
+		Monitor uint32    `help:"Monitor ID" flag:"monitor"`
+		Batch   string    `help:"Batch ID" flag:"batch"`
+		From    time.Time `required:"" help:"Start of the time range (RFC 3339)" flag:"from"`
+		To      time.Time `required:"" help:"End of the time range (RFC 3339)" flag:"to"`
+		Reason  string    `required:"" help:"Reason recorded in the logs" flag:"reason"`
+	}
+	scorerReplayCmd struct {
+		Scorer    string    `arg:"" help:"Scorer to replay"`
+		Compare   string    `help:"Scorer to compare with (default: the main scorer)" flag:"compare"`
+		From      time.Time `required:"" help:"Start of the time range (RFC 3339)" flag:"from"`
+		To        time.Time `required:"" help:"End of the time range (RFC 3339)" flag:"to"`
+		Threshold float64   `default:"10" help:"Score for a server to be in the pool" flag:"threshold"`
+		Top       int       `default:"25" help:"Number of servers with the largest differences to show" flag:"top"`
+		JSON      bool      `help:"Output the full report as JSON" flag:"json"`
+	}
+	scorerPromoteCmd struct {
+		Scorer string `arg:"" help:"Scorer to use for the server scores"`
+		Reason string `required:"" help:"Reason recorded in the logs" flag:"reason"`
+		Force  bool   `help:"Promote the scorer even if it's behind the main scorer"`
+	}
 )
 
 type versionCmd struct{}
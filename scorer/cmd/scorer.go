@@ -3,6 +3,8 @@ package cmd
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"os"
 	"strings"
 	"time"
 
@@ -165,6 +167,7 @@ func (cmd *scorerSetupCmd) Run(ctx context.Context) error {
 		log.Debug("dbScorers", "scorers", dbScorers)
 
 		codeScorers := scr.Scorers()
+		settings := scr.Settings(ntpdb.New(dbconn))
 
 		minLogScoreID, err := db.GetMinLogScoreID(ctx)
 		if err != nil {
@@ -176,6 +179,10 @@ func (cmd *scorerSetupCmd) Run(ctx context.Context) error {
 				log.Info("scorer already configured", "name", name)
 				continue
 			}
+			if !settings.IsEnabled(name) {
+				log.Info("scorer not enabled", "name", name)
+				continue
+			}
 			log.Info("setting up scorer, scorerSetup", "name", name)
 
 			insert, err := db.InsertScorer(ctx, ntpdb.InsertScorerParams{
@@ -206,3 +213,84 @@ func (cmd *scorerSetupCmd) Run(ctx context.Context) error {
 
 	return nil
 }
+
+func (cmd *scorerInvalidateCmd) Run(ctx context.Context) error {
+	log := logger.FromContext(ctx)
+
+	dbconn, err := ntpdb.OpenDB()
+	if err != nil {
+		return err
+	}
+
+	sc, err := scorer.New(ctx, log, dbconn, prometheus.NewRegistry())
+	if err != nil {
+		return err
+	}
+
+	result, err := sc.Invalidate(ctx, scorer.Invalidation{
+		MonitorID: cmd.Monitor,
+		BatchID:   cmd.Batch,
+		From:      cmd.From,
+		To:        cmd.To,
+		Reason:    cmd.Reason,
+	})
+	if result != nil {
+		log.InfoContext(ctx, "invalidation",
+			"invalidation_id", result.ID,
+			"log_scores", result.LogScores,
+			"replayed", result.Replayed,
+			"servers", result.Servers,
+		)
+	}
+	return err
+}
+
+func (cmd *scorerReplayCmd) Run(ctx context.Context) error {
+	log := logger.FromContext(ctx)
+
+	dbconn, err := ntpdb.OpenDB()
+	if err != nil {
+		return err
+	}
+
+	sc, err := scorer.New(ctx, log, dbconn, prometheus.NewRegistry())
+	if err != nil {
+		return err
+	}
+
+	report, err := sc.Replay(ctx, scorer.ReplayOptions{
+		Scorer:    cmd.Scorer,
+		Compare:   cmd.Compare,
+		From:      cmd.From,
+		To:        cmd.To,
+		Threshold: cmd.Threshold,
+	})
+	if err != nil {
+		return err
+	}
+
+	if cmd.JSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+
+	report.Print(os.Stdout, cmd.Top)
+	return nil
+}
+
+func (cmd *scorerPromoteCmd) Run(ctx context.Context) error {
+	log := logger.FromContext(ctx)
+
+	dbconn, err := ntpdb.OpenDB()
+	if err != nil {
+		return err
+	}
+
+	sc, err := scorer.New(ctx, log, dbconn, prometheus.NewRegistry())
+	if err != nil {
+		return err
+	}
+
+	return sc.Promote(ctx, cmd.Scorer, cmd.Reason, cmd.Force)
+}
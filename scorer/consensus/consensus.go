@@ -0,0 +1,137 @@
+// Package consensus scores a server with the median of the recent
+// scores from each monitor and also computes the consensus offset of
+// the server from the offsets the active monitors measured.
+package consensus
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+
+	"go.ntppool.org/common/logger"
+	"go.ntppool.org/monitor/ntpdb"
+	"go.ntppool.org/monitor/scorer/score"
+)
+
+// MinMonitors is how many active monitors need to have measured an
+// offset for the consensus to be computed
+const MinMonitors = 3
+
+type Consensus struct {
+	scorerID uint32
+	lookback score.Lookback
+}
+
+func New() *Consensus {
+	return &Consensus{lookback: score.DefaultLookback()}
+}
+
+func (s *Consensus) Setup(id uint32) {
+	s.scorerID = id
+}
+
+func (s *Consensus) SetLookback(lookback score.Lookback) {
+	s.lookback = lookback
+}
+
+func (s *Consensus) Score(ctx context.Context, db *ntpdb.Queries, serverScore ntpdb.ServerScore, latest ntpdb.LogScore) (score.Score, error) {
+	log := logger.FromContext(ctx)
+
+	if s.scorerID == 0 {
+		return score.Score{}, fmt.Errorf("Consensus not Setup()")
+	}
+
+	// only the active monitors count for the offset; if there are
+	// none the score falls back to the testing and candidate monitors
+	recent, err := db.GetScorerRecentScores(ctx, ntpdb.GetScorerRecentScoresParams{
+		TimeLookback:  int(s.lookback.Active.Seconds()),
+		ServerID:      serverScore.ServerID,
+		MonitorStatus: ntpdb.ServerScoresStatusActive,
+		Ts:            latest.Ts,
+	})
+	if err != nil {
+		return score.Score{}, err
+	}
+	consensus := offset(recent)
+
+	if len(recent) == 0 {
+		recent, err = s.lookback.RecentScores(ctx, db, serverScore.ServerID, latest.Ts)
+		if err != nil {
+			return score.Score{}, err
+		}
+	}
+	if len(recent) == 0 {
+		return score.Score{}, fmt.Errorf("no recent scores found for %d", serverScore.ServerID)
+	}
+
+	ls := score.Median(recent)
+
+	attributes := ntpdb.LogScoreAttributes{
+		FromLSID: int(latest.ID),
+		FromSSID: int(serverScore.ID),
+	}
+	var lsOffset sql.NullFloat64
+	if consensus != nil {
+		attributes.Spread = consensus.Spread
+		attributes.Monitors = consensus.Monitors
+		lsOffset = sql.NullFloat64{Float64: consensus.Offset, Valid: true}
+	}
+	b, err := json.Marshal(attributes)
+	if err != nil {
+		log.Error("could not marshal attributes", "attributes", attributes, "err", err)
+	}
+
+	return score.Score{
+		LogScore: ntpdb.LogScore{
+			ServerID:   serverScore.ServerID,
+			MonitorID:  sql.NullInt32{Valid: true, Int32: int32(s.scorerID)},
+			Ts:         latest.Ts,
+			Step:       ls.Step,
+			Score:      ls.Score,
+			Offset:     lsOffset,
+			Attributes: sql.NullString{String: string(b), Valid: true},
+		},
+		Consensus: consensus,
+	}, nil
+}
+
+// offset returns the median of the measured offsets and the median
+// absolute deviation from it as the spread, or nil if too few
+// monitors got a response from the server.
+func offset(recent []ntpdb.LogScore) *score.Consensus {
+	offsets := []float64{}
+	for _, ls := range recent {
+		if ls.Offset.Valid {
+			offsets = append(offsets, ls.Offset.Float64)
+		}
+	}
+	if len(offsets) < MinMonitors {
+		return nil
+	}
+
+	mid := middle(offsets)
+
+	deviations := make([]float64, len(offsets))
+	for i, o := range offsets {
+		deviations[i] = math.Abs(o - mid)
+	}
+
+	return &score.Consensus{
+		Offset:   mid,
+		Spread:   middle(deviations),
+		Monitors: len(offsets),
+	}
+}
+
+// middle returns the median of the values, sorting them in place
+func middle(values []float64) float64 {
+	slices.Sort(values)
+	n := len(values)
+	if n%2 == 1 {
+		return values[n/2]
+	}
+	return (values[n/2-1] + values[n/2]) / 2
+}
@@ -0,0 +1,40 @@
+package consensus
+
+import (
+	"database/sql"
+	"math"
+	"testing"
+
+	"go.ntppool.org/monitor/ntpdb"
+)
+
+func TestOffset(t *testing.T) {
+	ls := func(offset float64) ntpdb.LogScore {
+		return ntpdb.LogScore{Offset: sql.NullFloat64{Float64: offset, Valid: true}}
+	}
+
+	recent := []ntpdb.LogScore{
+		ls(0.003), ls(0.002), ls(0.004),
+		ls(0.0035), ls(0.0025),
+		ls(0.250),   // one monitor far off doesn't move the consensus
+		{Score: -5}, // no response
+	}
+
+	c := offset(recent)
+	if c == nil {
+		t.Fatal("no consensus")
+	}
+	if c.Monitors != 6 {
+		t.Errorf("monitors %d, want 6", c.Monitors)
+	}
+	if math.Abs(c.Offset-0.00325) > 1e-9 {
+		t.Errorf("offset %f, want 0.00325", c.Offset)
+	}
+	if math.Abs(c.Spread-0.00075) > 1e-9 {
+		t.Errorf("spread %f, want 0.00075", c.Spread)
+	}
+
+	if c := offset(recent[:2]); c != nil {
+		t.Errorf("got consensus %+v from two monitors", c)
+	}
+}
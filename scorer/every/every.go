@@ -11,6 +11,7 @@ import (
 
 type EveryScore struct {
 	scorerID uint32
+	decay    score.Decay
 }
 
 func New() *EveryScore {
@@ -21,6 +22,10 @@ func (s *EveryScore) Setup(id uint32) {
 	s.scorerID = id
 }
 
+func (s *EveryScore) SetDecay(decay score.Decay) {
+	s.decay = decay
+}
+
 func (s *EveryScore) Score(ctx context.Context, db *ntpdb.Queries, serverScore ntpdb.ServerScore, ls ntpdb.LogScore) (score.Score, error) {
 	if s.scorerID == 0 {
 		return score.Score{}, fmt.Errorf("EveryScore not Setup()")
@@ -29,9 +34,11 @@ func (s *EveryScore) Score(ctx context.Context, db *ntpdb.Queries, serverScore n
 	// ctx, span := srv.tracer.Start(ctx, "Score")
 	// defer span.End()
 
-	scoreRaw := ls.Step + (serverScore.ScoreRaw * 0.95)
+	// the step recorded by the server includes the timeout weight
+	// for batches during a monitor outage
+	step, maxscore, hasMaxScore := score.AppliedStep(&ls)
 
-	maxscore, hasMaxScore := ls.MaxScore()
+	scoreRaw := s.decay.Apply(serverScore.ScoreRaw, serverScore.ScoreTs, ls.Ts, step)
 
 	if hasMaxScore {
 		scoreRaw = maxscore
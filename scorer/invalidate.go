@@ -0,0 +1,404 @@
+package scorer
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"go.ntppool.org/monitor/ntpdb"
+	"go.ntppool.org/monitor/scorer/score"
+)
+
+// Invalidation selects the monitor log scores to void: the results
+// from a monitor and/or a batch within a time range.
+type Invalidation struct {
+	MonitorID uint32
+	BatchID   string
+	From      time.Time
+	To        time.Time
+	Reason    string
+}
+
+// InvalidationResult summarizes what an invalidation changed
+type InvalidationResult struct {
+	ID        uint32 `json:"invalidation_id"`
+	LogScores int64  `json:"log_scores"`
+	Replayed  int    `json:"replayed"`
+	Servers   int    `json:"servers"`
+}
+
+func (inv Invalidation) validate() error {
+	if inv.MonitorID == 0 && len(inv.BatchID) == 0 {
+		return errors.New("a monitor or batch ID is required")
+	}
+	if inv.From.IsZero() || inv.To.IsZero() || !inv.From.Before(inv.To) {
+		return errors.New("a time range is required")
+	}
+	if len(strings.TrimSpace(inv.Reason)) == 0 {
+		return errors.New("a reason is required")
+	}
+	return nil
+}
+
+// Invalidate marks the selected log scores as void, replays the
+// running score of each affected monitor and server from the start
+// of the range, recalculates the scores of the affected servers with
+// each enabled scorer and records what was done in the logs table.
+func (r *runner) Invalidate(ctx context.Context, inv Invalidation) (*InvalidationResult, error) {
+	if err := inv.validate(); err != nil {
+		return nil, err
+	}
+
+	log := r.log.With("monitor_id", inv.MonitorID, "batch_id", inv.BatchID)
+
+	db := ntpdb.New(r.dbconn)
+
+	settings, err := r.setup(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	var monitor *ntpdb.Monitor
+	if inv.MonitorID > 0 {
+		mon, err := db.GetMonitorByID(ctx, inv.MonitorID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, fmt.Errorf("monitor %d not found", inv.MonitorID)
+			}
+			return nil, err
+		}
+		monitor = &mon
+	}
+
+	result, voided, err := r.void(ctx, inv)
+	if err != nil {
+		return nil, fmt.Errorf("voiding log scores: %w", err)
+	}
+
+	log.InfoContext(ctx, "voided log scores", "invalidation_id", result.ID, "count", result.LogScores)
+
+	// the voided scores are committed already; the replay is done
+	// per monitor and server so an error leaves the others consistent.
+	latest := map[uint32]ntpdb.LogScore{}
+	for _, v := range voided {
+		ls, err := r.replay(ctx, v.MonitorID, v.ServerID, inv.From, settings.Decay())
+		if err != nil {
+			return result, fmt.Errorf("replaying monitor %d server %d: %w", v.MonitorID.Int32, v.ServerID, err)
+		}
+		result.Replayed++
+		if ls != nil && ls.Ts.After(latest[v.ServerID].Ts) {
+			latest[v.ServerID] = *ls
+		}
+	}
+
+	for serverID, ls := range latest {
+		if err := r.rescore(ctx, serverID, ls); err != nil {
+			return result, fmt.Errorf("rescoring server %d: %w", serverID, err)
+		}
+		result.Servers++
+	}
+
+	changes, err := json.Marshal(map[string]any{
+		"invalidation_id": result.ID,
+		"monitor_id":      inv.MonitorID,
+		"batch_id":        inv.BatchID,
+		"from":            inv.From,
+		"to":              inv.To,
+		"log_scores":      result.LogScores,
+		"servers":         result.Servers,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	target := "batch " + inv.BatchID
+	logEntry := ntpdb.InsertLogParams{
+		Type:    sql.NullString{String: "log-score-invalidation", Valid: true},
+		Changes: sql.NullString{String: string(changes), Valid: true},
+	}
+	if monitor != nil {
+		target = "monitor " + monitor.TlsName.String
+		if len(inv.BatchID) > 0 {
+			target += " batch " + inv.BatchID
+		}
+		logEntry.AccountID = monitor.AccountID
+	}
+	logEntry.Message = sql.NullString{
+		String: fmt.Sprintf("Voided %d log scores from %s between %s and %s: %s",
+			result.LogScores, target,
+			inv.From.UTC().Format(time.RFC3339), inv.To.UTC().Format(time.RFC3339),
+			inv.Reason,
+		),
+		Valid: true,
+	}
+
+	if err := db.InsertLog(ctx, logEntry); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// void records the invalidation and marks the log scores as void,
+// returning the monitor and server pairs that had scores voided.
+func (r *runner) void(ctx context.Context, inv Invalidation) (*InvalidationResult, []ntpdb.GetVoidedMonitorServersRow, error) {
+	tx, err := r.dbconn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	db := ntpdb.New(r.dbconn).WithTx(tx)
+
+	p := ntpdb.InsertLogScoreInvalidationParams{
+		StartTs: inv.From,
+		EndTs:   inv.To,
+		Reason:  inv.Reason,
+	}
+	if inv.MonitorID > 0 {
+		p.MonitorID = sql.NullInt32{Int32: int32(inv.MonitorID), Valid: true}
+	}
+	if len(inv.BatchID) > 0 {
+		p.BatchID = sql.NullString{String: inv.BatchID, Valid: true}
+	}
+	res, err := db.InsertLogScoreInvalidation(ctx, p)
+	if err != nil {
+		return nil, nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := &InvalidationResult{ID: uint32(id)}
+
+	res, err = db.VoidLogScores(ctx, ntpdb.VoidLogScoresParams{
+		InvalidationID: result.ID,
+		StartTs:        inv.From,
+		EndTs:          inv.To,
+		MonitorID:      inv.MonitorID,
+		BatchID:        inv.BatchID,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	result.LogScores, err = res.RowsAffected()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	voided, err := db.GetVoidedMonitorServers(ctx, result.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return result, voided, tx.Commit()
+}
+
+// replay recalculates the running score of the monitor and server
+// from the last valid score before from, updating the score of each
+// later log score and the server score. It returns the latest valid
+// log score, if any.
+func (r *runner) replay(ctx context.Context, monitorID sql.NullInt32, serverID uint32, from time.Time, decay score.Decay) (*ntpdb.LogScore, error) {
+	tx, err := r.dbconn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	db := ntpdb.New(r.dbconn).WithTx(tx)
+
+	// lock the server score so results submitted meanwhile aren't
+	// overwritten
+	ss, err := db.GetServerScoreForUpdate(ctx, ntpdb.GetServerScoreForUpdateParams{
+		ServerID:  serverID,
+		MonitorID: uint32(monitorID.Int32),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// the monitor was removed from the server
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var base *ntpdb.LogScore
+	ls, err := db.GetReplayBaseLogScore(ctx, ntpdb.GetReplayBaseLogScoreParams{
+		MonitorID: monitorID,
+		ServerID:  serverID,
+		Ts:        from,
+	})
+	switch {
+	case err == nil:
+		base = &ls
+	case !errors.Is(err, sql.ErrNoRows):
+		return nil, err
+	}
+
+	logScores, err := db.GetReplayLogScores(ctx, ntpdb.GetReplayLogScoresParams{
+		MonitorID: monitorID,
+		ServerID:  serverID,
+		Ts:        from,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	scores := replayScores(base, logScores, decay)
+	for i, ls := range logScores {
+		if ls.Score == scores[i] {
+			continue
+		}
+		if err := db.UpdateLogScoreScore(ctx, ntpdb.UpdateLogScoreScoreParams{
+			Score: scores[i],
+			ID:    ls.ID,
+		}); err != nil {
+			return nil, err
+		}
+		r.m.sqlUpdates.WithLabelValues("update_log_score").Inc()
+	}
+
+	var latest *ntpdb.LogScore
+	switch {
+	case len(logScores) > 0:
+		ls := logScores[len(logScores)-1]
+		ls.Score = scores[len(scores)-1]
+		latest = &ls
+	case base != nil:
+		latest = base
+	default:
+		// no valid scores left; leave the server score alone
+		return nil, nil
+	}
+
+	if err := db.UpdateServerScore(ctx, ntpdb.UpdateServerScoreParams{
+		ID:       ss.ID,
+		ScoreRaw: latest.Score,
+		ScoreTs:  sql.NullTime{Time: latest.Ts, Valid: true},
+	}); err != nil {
+		return nil, err
+	}
+	r.m.sqlUpdates.WithLabelValues("update_server_score").Inc()
+
+	return latest, tx.Commit()
+}
+
+// replayScores returns the running score after each of the log
+// scores, starting from the score of base (or zero).
+func replayScores(base *ntpdb.LogScore, logScores []ntpdb.LogScore, decay score.Decay) []float64 {
+	scores := make([]float64, len(logScores))
+
+	var prev float64
+	var prevTs sql.NullTime
+	if base != nil {
+		prev = base.Score
+		prevTs = sql.NullTime{Time: base.Ts, Valid: true}
+	}
+
+	for i, ls := range logScores {
+		step, maxScore, hasMaxScore := score.AppliedStep(&ls)
+		s := decay.Apply(prev, prevTs, ls.Ts, step)
+		if hasMaxScore {
+			s = math.Min(s, maxScore)
+		}
+		scores[i] = s
+		prev = s
+		prevTs = sql.NullTime{Time: ls.Ts, Valid: true}
+	}
+
+	return scores
+}
+
+// rescore calculates a new score for the server with each enabled
+// scorer, like when the scorers process ls. Only the main scorer
+// updates the score on the server.
+func (r *runner) rescore(ctx context.Context, serverID uint32, ls ntpdb.LogScore) error {
+	if sm, ok := r.registry[r.main]; !ok || sm.ScorerID == 0 {
+		return fmt.Errorf("main scorer %q not configured", r.main)
+	}
+
+	// score as of now so the scores replayed above are used
+	ls.Ts = time.Now().Truncate(time.Second)
+
+	for _, name := range slices.Sorted(maps.Keys(r.registry)) {
+		sm := r.registry[name]
+		if sm.ScorerID == 0 || !sm.enabled {
+			continue
+		}
+		if err := r.rescoreWith(ctx, name, sm, serverID, ls); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// rescoreWith calculates the server score with the named scorer.
+func (r *runner) rescoreWith(ctx context.Context, name string, sm *ScorerMap, serverID uint32, ls ntpdb.LogScore) error {
+	tx, err := r.dbconn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	db := ntpdb.New(r.dbconn).WithTx(tx)
+
+	ss, err := r.getServerScore(db, serverID, sm.ScorerID)
+	if err != nil {
+		return err
+	}
+
+	ns, err := sm.Scorer.Score(ctx, db, ss, ls)
+	if err != nil {
+		return err
+	}
+
+	if _, err := db.InsertLogScore(ctx, ntpdb.InsertLogScoreParams{
+		ServerID:   ns.ServerID,
+		MonitorID:  ns.MonitorID,
+		Ts:         ns.Ts,
+		Step:       ns.Step,
+		Offset:     ns.Offset,
+		Rtt:        ns.Rtt,
+		Score:      ns.Score,
+		Attributes: ns.Attributes,
+	}); err != nil {
+		return err
+	}
+	r.m.sqlUpdates.WithLabelValues("insert_log_score").Inc()
+
+	if err := db.UpdateServerScore(ctx, ntpdb.UpdateServerScoreParams{
+		ID:       ss.ID,
+		ScoreRaw: ns.Score,
+		ScoreTs:  sql.NullTime{Time: ns.Ts, Valid: true},
+	}); err != nil {
+		return err
+	}
+	r.m.sqlUpdates.WithLabelValues("update_server_score").Inc()
+
+	if name == r.main {
+		if err := db.UpdateServer(ctx, ntpdb.UpdateServerParams{
+			ID:       ns.ServerID,
+			ScoreTs:  sql.NullTime{Time: ns.Ts, Valid: true},
+			ScoreRaw: ns.Score,
+		}); err != nil {
+			return err
+		}
+		r.m.sqlUpdates.WithLabelValues("update_server").Inc()
+	}
+
+	return tx.Commit()
+}
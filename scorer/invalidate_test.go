@@ -0,0 +1,112 @@
+package scorer
+
+import (
+	"database/sql"
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"go.ntppool.org/monitor/ntpdb"
+	"go.ntppool.org/monitor/scorer/score"
+)
+
+func TestReplayScores(t *testing.T) {
+	decay := score.Decay{HalfLife: time.Hour}
+	now := time.Now()
+
+	base := &ntpdb.LogScore{Ts: now.Add(-time.Hour), Score: 10}
+	logScores := []ntpdb.LogScore{
+		{Ts: now, Step: 1, Score: -50},
+		{Ts: now.Add(time.Hour), Step: -1, Offset: sql.NullFloat64{Float64: 5, Valid: true}},
+	}
+
+	scores := replayScores(base, logScores, decay)
+	if len(scores) != 2 {
+		t.Fatalf("got %d scores, want 2", len(scores))
+	}
+
+	// half of the base score and half of 20 times the step
+	if math.Abs(scores[0]-15) > 1e-9 {
+		t.Errorf("first score %f, want 15", scores[0])
+	}
+	// capped by the max score for a large offset
+	if scores[1] != -20 {
+		t.Errorf("second score %f, want -20", scores[1])
+	}
+
+	// without a base the replay starts from zero
+	scores = replayScores(nil, logScores[:1], decay)
+	if want := decay.Apply(0, sql.NullTime{}, now, 1); scores[0] != want {
+		t.Errorf("score without base %f, want %f", scores[0], want)
+	}
+}
+
+func TestReplayScoresAppliedStep(t *testing.T) {
+	decay := score.Decay{HalfLife: time.Hour}
+	now := time.Now()
+
+	attributes := func(a ntpdb.LogScoreAttributes) sql.NullString {
+		b, err := json.Marshal(a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sql.NullString{String: string(b), Valid: true}
+	}
+	step := func(f float64) *float64 { return &f }
+
+	base := &ntpdb.LogScore{Ts: now.Add(-time.Hour), Score: 10}
+	logScores := []ntpdb.LogScore{
+		// a timeout scored with a fifth of the step during an outage
+		{Ts: now, Step: -5, Attributes: attributes(ntpdb.LogScoreAttributes{
+			NoResponse: true,
+			ScoreStep:  step(-1),
+		})},
+		// a DENY response capped by the policy's max score
+		{Ts: now.Add(time.Hour), Step: 1, Attributes: attributes(ntpdb.LogScoreAttributes{
+			Error:     "DENY",
+			ScoreStep: step(1),
+			MaxScore:  step(-10),
+		})},
+		// a large offset the policy didn't cap
+		{Ts: now.Add(2 * time.Hour), Step: 1, Offset: sql.NullFloat64{Float64: 5, Valid: true},
+			Attributes: attributes(ntpdb.LogScoreAttributes{ScoreStep: step(1)})},
+	}
+
+	scores := replayScores(base, logScores, decay)
+
+	// half of the base score and half of 20 times the weighted step
+	if math.Abs(scores[0]-(5-10)) > 1e-9 {
+		t.Errorf("timeout score %f, want -5", scores[0])
+	}
+	if scores[1] != -10 {
+		t.Errorf("capped score %f, want -10", scores[1])
+	}
+	if want := decay.Apply(-10, sql.NullTime{Time: now.Add(time.Hour), Valid: true}, now.Add(2*time.Hour), 1); scores[2] != want {
+		t.Errorf("uncapped score %f, want %f", scores[2], want)
+	}
+}
+
+func TestInvalidationValidate(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name string
+		inv  Invalidation
+		ok   bool
+	}{
+		{"monitor", Invalidation{MonitorID: 1, From: now.Add(-time.Hour), To: now, Reason: "broken"}, true},
+		{"batch", Invalidation{BatchID: "01JZ7X8Q2M4N6P8R0T2V4W6Y8Z", From: now.Add(-time.Hour), To: now, Reason: "broken"}, true},
+		{"no monitor or batch", Invalidation{From: now.Add(-time.Hour), To: now, Reason: "broken"}, false},
+		{"reversed range", Invalidation{MonitorID: 1, From: now, To: now.Add(-time.Hour), Reason: "broken"}, false},
+		{"no reason", Invalidation{MonitorID: 1, From: now.Add(-time.Hour), To: now}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.inv.validate(); (err == nil) != tt.ok {
+				t.Errorf("validate() = %v, expected ok: %t", err, tt.ok)
+			}
+		})
+	}
+}
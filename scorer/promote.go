@@ -0,0 +1,136 @@
+package scorer
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.ntppool.org/monitor/ntpdb"
+)
+
+// maxPromoteLag is how many log score ids a scorer can be behind the
+// main scorer and still be promoted without force
+const maxPromoteLag = 1000
+
+// Promote makes name the main scorer by updating the "scorer"
+// system setting and records the change in the logs table. Running
+// scorers switch on their next run. Unless force is set, the scorer
+// must have caught up with the current main scorer.
+func (r *runner) Promote(ctx context.Context, name, reason string, force bool) error {
+	if len(strings.TrimSpace(reason)) == 0 {
+		return errors.New("a reason is required")
+	}
+	if _, ok := r.registry[name]; !ok {
+		return fmt.Errorf("scorer %q not implemented", name)
+	}
+
+	tx, err := r.dbconn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	db := ntpdb.New(r.dbconn).WithTx(tx)
+
+	scorers, err := db.GetScorers(ctx)
+	if err != nil {
+		return err
+	}
+
+	settingsStr, err := db.GetSystemSetting(ctx, "scorer")
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	// keep the other settings as they are
+	raw := map[string]any{}
+	var settings ScorerSettings
+	if len(settingsStr) > 0 {
+		if err := json.Unmarshal([]byte(settingsStr), &raw); err != nil {
+			return fmt.Errorf("scorer settings: %w", err)
+		}
+		if err := json.Unmarshal([]byte(settingsStr), &settings); err != nil {
+			return fmt.Errorf("scorer settings: %w", err)
+		}
+	}
+
+	previous := settings.MainScorer()
+	if previous == name {
+		return fmt.Errorf("%q is already the main scorer", name)
+	}
+
+	if err := checkPromote(scorers, name, previous); err != nil {
+		if !force {
+			return err
+		}
+		r.log.WarnContext(ctx, "promoting scorer anyway", "name", name, "err", err)
+	}
+
+	raw["main"] = name
+	value, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	if err := db.UpdateSystemSetting(ctx, ntpdb.UpdateSystemSettingParams{
+		Key:   "scorer",
+		Value: string(value),
+	}); err != nil {
+		return err
+	}
+
+	changes, err := json.Marshal(map[string]string{
+		"from":   previous,
+		"to":     name,
+		"reason": reason,
+	})
+	if err != nil {
+		return err
+	}
+	if err := db.InsertLog(ctx, ntpdb.InsertLogParams{
+		Type:    sql.NullString{String: "scorer-main", Valid: true},
+		Message: sql.NullString{String: fmt.Sprintf("Main scorer changed from %s to %s: %s", previous, name, reason), Valid: true},
+		Changes: sql.NullString{String: string(changes), Valid: true},
+	}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	r.log.InfoContext(ctx, "promoted main scorer", "name", name, "previous", previous)
+
+	return nil
+}
+
+// checkPromote returns an error if name isn't configured or hasn't
+// scored the log scores the main scorer has, so promoting it would
+// move the server scores back in time.
+func checkPromote(scorers []ntpdb.GetScorersRow, name, main string) error {
+	var target, current *ntpdb.GetScorersRow
+	for i, sc := range scorers {
+		switch sc.Hostname {
+		case name:
+			target = &scorers[i]
+		case main:
+			current = &scorers[i]
+		}
+	}
+	if target == nil {
+		return fmt.Errorf("scorer %q not configured, run scorer setup first", name)
+	}
+	if current == nil {
+		// nothing to compare with
+		return nil
+	}
+	if current.LogScoreID > target.LogScoreID && current.LogScoreID-target.LogScoreID > maxPromoteLag {
+		return fmt.Errorf("scorer %q is %d log scores behind %q (at %d), use --force to promote it anyway",
+			name, current.LogScoreID-target.LogScoreID, main, current.LogScoreID)
+	}
+	return nil
+}
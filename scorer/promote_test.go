@@ -0,0 +1,37 @@
+package scorer
+
+import (
+	"testing"
+
+	"go.ntppool.org/monitor/ntpdb"
+)
+
+func TestCheckPromote(t *testing.T) {
+	scorers := []ntpdb.GetScorersRow{
+		{ID: 1, Hostname: "recentmedian", LogScoreID: 50000},
+		{ID: 2, Hostname: "weightedmedian", LogScoreID: 49900},
+		{ID: 3, Hostname: "trimmedmean", LogScoreID: 20000},
+		{ID: 4, Hostname: "every", LogScoreID: 50100},
+	}
+
+	tests := []struct {
+		name string
+		main string
+		ok   bool
+	}{
+		{"weightedmedian", "recentmedian", true},
+		{"every", "recentmedian", true},
+		{"trimmedmean", "recentmedian", false},
+		{"consensus", "recentmedian", false},
+		// the main scorer isn't configured; nothing to compare with
+		{"trimmedmean", "consensus", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+" from "+tt.main, func(t *testing.T) {
+			if err := checkPromote(scorers, tt.name, tt.main); (err == nil) != tt.ok {
+				t.Errorf("checkPromote() = %v, expected ok: %t", err, tt.ok)
+			}
+		})
+	}
+}
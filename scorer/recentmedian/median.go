@@ -9,7 +9,6 @@ import (
 	"go.ntppool.org/common/logger"
 	"go.ntppool.org/monitor/ntpdb"
 	"go.ntppool.org/monitor/scorer/score"
-	"golang.org/x/exp/slices"
 )
 
 type RecentMedian struct {
@@ -76,31 +75,7 @@ func (s *RecentMedian) Score(ctx context.Context, db *ntpdb.Queries, serverScore
 	if len(recent) < 3 {
 		ls = recent[0]
 	} else {
-
-		slices.SortStableFunc(recent, func(a, b ntpdb.LogScore) int {
-			if a.Score != b.Score {
-				if a.Score > b.Score {
-					return 1
-				}
-				if a.Score < b.Score {
-					return -1
-				}
-			}
-			if a.Rtt.Int32 > b.Rtt.Int32 {
-				return 1
-			}
-			if a.Rtt.Int32 < b.Rtt.Int32 {
-				return -1
-			}
-			return 0
-		})
-
-		i := len(recent) / 2
-		if len(recent)%2 == 0 {
-			i--
-		}
-		ls = recent[i]
-
+		ls = score.Median(recent)
 	}
 
 	attributes := ntpdb.LogScoreAttributes{}
@@ -0,0 +1,167 @@
+package scorer
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.ntppool.org/monitor/ntpdb"
+)
+
+// ReplayOptions selects the scorer and the log scores to replay
+type ReplayOptions struct {
+	// Scorer is the registered scorer to run
+	Scorer string
+	// Compare is the scorer whose stored scores are compared with
+	// the replayed scores; the main scorer if empty.
+	Compare string
+
+	From time.Time
+	To   time.Time
+
+	// Threshold is the score above which a server is in the pool
+	Threshold float64
+}
+
+// Replay runs a scorer over the monitor log scores from a
+// historical time range and compares the scores with those stored
+// by another scorer. It runs in a read-only transaction; the
+// running scores are only kept in memory.
+//
+// Scorers that look up other data (like the recent scores from the
+// other monitors) read it from the database as it is now.
+func (r *runner) Replay(ctx context.Context, opts ReplayOptions) (*ReplayReport, error) {
+	if !opts.From.Before(opts.To) {
+		return nil, errors.New("a time range is required")
+	}
+
+	settings, err := r.setup(ctx, ntpdb.New(r.dbconn))
+	if err != nil {
+		return nil, err
+	}
+	if len(opts.Compare) == 0 {
+		opts.Compare = r.main
+	}
+
+	log := r.log.With("scorer", opts.Scorer, "compare", opts.Compare)
+
+	sm, ok := r.registry[opts.Scorer]
+	if !ok {
+		return nil, fmt.Errorf("scorer %q not implemented", opts.Scorer)
+	}
+	compare, ok := r.registry[opts.Compare]
+	if !ok || compare.ScorerID == 0 {
+		return nil, fmt.Errorf("scorer %q not configured", opts.Compare)
+	}
+	if sm.ScorerID == 0 {
+		// not set up in the database yet; nothing is written, so
+		// run it as the scorer it's compared with.
+		sm.Scorer.Setup(compare.ScorerID)
+	}
+
+	tx, err := r.dbconn.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	db := ntpdb.New(r.dbconn).WithTx(tx)
+
+	firstID, err := db.GetFirstLogScoreID(ctx, opts.From)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("no log scores after %s", opts.From)
+		}
+		return nil, err
+	}
+
+	builder := newReplayBuilder(opts)
+
+	// the running scores start from the stored scores
+	serverScores := map[uint32]*ntpdb.ServerScore{}
+	serverScore := func(serverID uint32) (*ntpdb.ServerScore, error) {
+		if ss, ok := serverScores[serverID]; ok {
+			return ss, nil
+		}
+		ss := &ntpdb.ServerScore{
+			ServerID:  serverID,
+			MonitorID: compare.ScorerID,
+			ScoreRaw:  -5,
+			Status:    ntpdb.ServerScoresStatusActive,
+		}
+		base, err := db.GetReplayBaseLogScore(ctx, ntpdb.GetReplayBaseLogScoreParams{
+			MonitorID: sql.NullInt32{Int32: int32(compare.ScorerID), Valid: true},
+			ServerID:  serverID,
+			Ts:        opts.From,
+		})
+		switch {
+		case err == nil:
+			ss.ScoreRaw = base.Score
+			ss.ScoreTs = sql.NullTime{Time: base.Ts, Valid: true}
+			builder.start(serverID, base.Score)
+		case !errors.Is(err, sql.ErrNoRows):
+			return nil, err
+		}
+		serverScores[serverID] = ss
+		return ss, nil
+	}
+
+	lastID := firstID - 1
+	for done := false; !done; {
+		logScores, err := r.getLogScores(ctx, db, log, lastID, settings.BatchSize, false)
+		if err != nil {
+			return nil, err
+		}
+		if len(logScores) == 0 {
+			break
+		}
+
+		for _, ls := range logScores {
+			lastID = ls.ID
+			// batches submitted late are stored after newer log
+			// scores, so keep going until they can't be in range
+			if ls.Ts.After(opts.To.Add(ntpdb.MaxBatchAge)) {
+				done = true
+				break
+			}
+			if ls.Ts.Before(opts.From) || ls.Ts.After(opts.To) {
+				continue
+			}
+
+			ss, err := serverScore(ls.ServerID)
+			if err != nil {
+				return nil, err
+			}
+
+			ns, err := sm.Scorer.Score(ctx, db, *ss, ls)
+			if err != nil {
+				log.DebugContext(ctx, "could not calculate score", "server_id", ls.ServerID, "log_score_id", ls.ID, "err", err)
+				builder.report.Errors++
+				continue
+			}
+			builder.report.LogScores++
+
+			ss.ScoreRaw = ns.Score
+			ss.ScoreTs = sql.NullTime{Time: ns.Ts, Valid: true}
+			builder.addReplayed(ls.ServerID, ns.Score)
+		}
+	}
+
+	stored, err := db.GetScorerLogScoresByTime(ctx, ntpdb.GetScorerLogScoresByTimeParams{
+		MonitorID: sql.NullInt32{Int32: int32(compare.ScorerID), Valid: true},
+		StartTs:   opts.From,
+		EndTs:     opts.To,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, ls := range stored {
+		builder.addStored(ls.ServerID, ls.Score)
+	}
+
+	return builder.build(), nil
+}
@@ -0,0 +1,218 @@
+package scorer
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"time"
+)
+
+// DefaultPoolThreshold is the score above which a server is in the
+// pool
+const DefaultPoolThreshold = 10
+
+// ReplayReport compares the replayed scores with the stored scores
+type ReplayReport struct {
+	Scorer    string    `json:"scorer"`
+	Compare   string    `json:"compare"`
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+	Threshold float64   `json:"threshold"`
+
+	// LogScores is how many log scores were scored and Errors how
+	// many the scorer couldn't score
+	LogScores int `json:"log_scores"`
+	Errors    int `json:"errors"`
+
+	// Servers has the servers with both replayed and stored
+	// scores, the largest differences first
+	Servers []ServerDiff `json:"servers"`
+
+	Stored   Distribution `json:"stored"`
+	Replayed Distribution `json:"replayed"`
+
+	// PoolIn and PoolOut count the servers that end up in or out of
+	// the pool with the replayed scores but not the stored scores
+	PoolIn  int `json:"pool_in"`
+	PoolOut int `json:"pool_out"`
+}
+
+// ServerDiff has the final scores for a server and how often it
+// moved in or out of the pool
+type ServerDiff struct {
+	ServerID      uint32  `json:"server_id"`
+	Stored        float64 `json:"stored"`
+	Replayed      float64 `json:"replayed"`
+	Delta         float64 `json:"delta"`
+	StoredFlips   int     `json:"stored_flips"`
+	ReplayedFlips int     `json:"replayed_flips"`
+}
+
+// Distribution summarizes the final scores of the servers
+type Distribution struct {
+	Mean   float64 `json:"mean"`
+	P10    float64 `json:"p10"`
+	Median float64 `json:"median"`
+	P90    float64 `json:"p90"`
+	InPool int     `json:"in_pool"`
+	Flips  int     `json:"flips"`
+}
+
+type replayBuilder struct {
+	report   *ReplayReport
+	stored   map[uint32][]float64
+	replayed map[uint32][]float64
+}
+
+func newReplayBuilder(opts ReplayOptions) *replayBuilder {
+	if opts.Threshold == 0 {
+		opts.Threshold = DefaultPoolThreshold
+	}
+	return &replayBuilder{
+		report: &ReplayReport{
+			Scorer:    opts.Scorer,
+			Compare:   opts.Compare,
+			From:      opts.From,
+			To:        opts.To,
+			Threshold: opts.Threshold,
+		},
+		stored:   map[uint32][]float64{},
+		replayed: map[uint32][]float64{},
+	}
+}
+
+// start sets the score both series start from
+func (b *replayBuilder) start(serverID uint32, score float64) {
+	b.stored[serverID] = append(b.stored[serverID], score)
+	b.replayed[serverID] = append(b.replayed[serverID], score)
+}
+
+func (b *replayBuilder) addStored(serverID uint32, score float64) {
+	b.stored[serverID] = append(b.stored[serverID], score)
+}
+
+func (b *replayBuilder) addReplayed(serverID uint32, score float64) {
+	b.replayed[serverID] = append(b.replayed[serverID], score)
+}
+
+func (b *replayBuilder) build() *ReplayReport {
+	report := b.report
+	threshold := report.Threshold
+
+	var stored, replayed []float64
+
+	for serverID, rs := range b.replayed {
+		ss, ok := b.stored[serverID]
+		if !ok || len(rs) == 0 {
+			continue
+		}
+
+		d := ServerDiff{
+			ServerID:      serverID,
+			Stored:        ss[len(ss)-1],
+			Replayed:      rs[len(rs)-1],
+			StoredFlips:   flips(ss, threshold),
+			ReplayedFlips: flips(rs, threshold),
+		}
+		d.Delta = d.Replayed - d.Stored
+		report.Servers = append(report.Servers, d)
+
+		storedIn, replayedIn := d.Stored > threshold, d.Replayed > threshold
+		switch {
+		case replayedIn && !storedIn:
+			report.PoolIn++
+		case storedIn && !replayedIn:
+			report.PoolOut++
+		}
+
+		stored = append(stored, d.Stored)
+		replayed = append(replayed, d.Replayed)
+		report.Stored.Flips += d.StoredFlips
+		report.Replayed.Flips += d.ReplayedFlips
+	}
+
+	slices.SortFunc(report.Servers, func(a, b ServerDiff) int {
+		if c := cmp.Compare(math.Abs(b.Delta), math.Abs(a.Delta)); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ServerID, b.ServerID)
+	})
+
+	report.Stored = distribution(stored, threshold, report.Stored.Flips)
+	report.Replayed = distribution(replayed, threshold, report.Replayed.Flips)
+
+	return report
+}
+
+// flips counts how many times the scores cross the threshold
+func flips(scores []float64, threshold float64) int {
+	n := 0
+	for i := 1; i < len(scores); i++ {
+		if (scores[i-1] > threshold) != (scores[i] > threshold) {
+			n++
+		}
+	}
+	return n
+}
+
+func distribution(scores []float64, threshold float64, flips int) Distribution {
+	d := Distribution{Flips: flips}
+	if len(scores) == 0 {
+		return d
+	}
+
+	scores = slices.Clone(scores)
+	slices.Sort(scores)
+
+	sum := 0.0
+	for _, s := range scores {
+		sum += s
+		if s > threshold {
+			d.InPool++
+		}
+	}
+	d.Mean = sum / float64(len(scores))
+
+	pct := func(p float64) float64 {
+		return scores[int(p*float64(len(scores)-1))]
+	}
+	d.P10 = pct(0.1)
+	d.Median = pct(0.5)
+	d.P90 = pct(0.9)
+
+	return d
+}
+
+// Print writes the report with at most top servers
+func (report *ReplayReport) Print(w io.Writer, top int) {
+	fmt.Fprintf(w, "scorer %s compared with %s from %s to %s\n",
+		report.Scorer, report.Compare,
+		report.From.UTC().Format(time.RFC3339), report.To.UTC().Format(time.RFC3339),
+	)
+	fmt.Fprintf(w, "%d log scores, %d errors, %d servers\n\n", report.LogScores, report.Errors, len(report.Servers))
+
+	fmt.Fprintf(w, "%-10s %8s %8s %8s %8s %8s %8s\n", "", "mean", "p10", "median", "p90", "in pool", "flips")
+	for _, row := range []struct {
+		name string
+		d    Distribution
+	}{{"stored", report.Stored}, {"replayed", report.Replayed}} {
+		fmt.Fprintf(w, "%-10s %8.2f %8.2f %8.2f %8.2f %8d %8d\n",
+			row.name, row.d.Mean, row.d.P10, row.d.Median, row.d.P90, row.d.InPool, row.d.Flips)
+	}
+	fmt.Fprintf(w, "\nthreshold %.1f: %d servers in and %d out of the pool with the replayed scores\n\n",
+		report.Threshold, report.PoolIn, report.PoolOut)
+
+	if top <= 0 || len(report.Servers) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "%-10s %8s %8s %8s %8s\n", "server", "stored", "replayed", "delta", "flips")
+	for i, d := range report.Servers {
+		if i >= top {
+			break
+		}
+		fmt.Fprintf(w, "%-10d %8.2f %8.2f %8.2f %4d/%-3d\n",
+			d.ServerID, d.Stored, d.Replayed, d.Delta, d.StoredFlips, d.ReplayedFlips)
+	}
+}
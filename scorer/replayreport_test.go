@@ -0,0 +1,63 @@
+package scorer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestReplayReport(t *testing.T) {
+	b := newReplayBuilder(ReplayOptions{Scorer: "every", Compare: "recentmedian"})
+
+	// server 1 drops out of the pool with the replayed scores
+	b.start(1, 15)
+	b.addReplayed(1, 12)
+	b.addReplayed(1, 5)
+	b.addStored(1, 16)
+
+	// server 2 flaps with the stored scores but not when replayed
+	b.start(2, 18)
+	b.addStored(2, 8)
+	b.addStored(2, 19)
+	b.addReplayed(2, 17)
+
+	// server 3 has no stored scores
+	b.addReplayed(3, 20)
+
+	report := b.build()
+
+	if report.Threshold != DefaultPoolThreshold {
+		t.Errorf("threshold %f, want %d", report.Threshold, DefaultPoolThreshold)
+	}
+	if len(report.Servers) != 2 {
+		t.Fatalf("got %d servers, want 2", len(report.Servers))
+	}
+
+	d := report.Servers[0]
+	if d.ServerID != 1 || d.Delta != -11 || d.ReplayedFlips != 1 || d.StoredFlips != 0 {
+		t.Errorf("unexpected first server diff %+v", d)
+	}
+	d = report.Servers[1]
+	if d.ServerID != 2 || d.Delta != -2 || d.StoredFlips != 2 || d.ReplayedFlips != 0 {
+		t.Errorf("unexpected second server diff %+v", d)
+	}
+
+	if report.PoolIn != 0 || report.PoolOut != 1 {
+		t.Errorf("pool in/out %d/%d, want 0/1", report.PoolIn, report.PoolOut)
+	}
+	if report.Stored.InPool != 2 || report.Replayed.InPool != 1 {
+		t.Errorf("in pool stored %d replayed %d, want 2 and 1", report.Stored.InPool, report.Replayed.InPool)
+	}
+	if report.Stored.Flips != 2 || report.Replayed.Flips != 1 {
+		t.Errorf("flips stored %d replayed %d, want 2 and 1", report.Stored.Flips, report.Replayed.Flips)
+	}
+	if report.Replayed.Mean != 11 {
+		t.Errorf("replayed mean %f, want 11", report.Replayed.Mean)
+	}
+
+	var buf bytes.Buffer
+	report.Print(&buf, 1)
+	if !strings.Contains(buf.String(), "0 servers in and 1 out of the pool") {
+		t.Errorf("unexpected report output:\n%s", buf.String())
+	}
+}
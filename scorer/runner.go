@@ -7,21 +7,27 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/cenkalti/backoff/v5"
 	"github.com/prometheus/client_golang/prometheus"
 	"go.ntppool.org/monitor/ntpdb"
+	"go.ntppool.org/monitor/scorer/consensus"
 	"go.ntppool.org/monitor/scorer/every"
 	"go.ntppool.org/monitor/scorer/recentmedian"
+	"go.ntppool.org/monitor/scorer/score"
+	"go.ntppool.org/monitor/scorer/trimmedmean"
+	"go.ntppool.org/monitor/scorer/types"
+	"go.ntppool.org/monitor/scorer/weightedmedian"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 )
 
 const (
 	defaultBatchSize      = 50
-	mainScorer            = "recentmedian"
+	defaultMainScorer     = "recentmedian"
 	deadlockRetryDuration = 10 * time.Minute // Continue retrying for 10 minutes total
 	initialDeadlockDelay  = 5 * time.Second  // Start with 5-second delay
 	maxDeadlockDelay      = 60 * time.Second // Cap at 60 seconds between attempts
@@ -29,6 +35,34 @@ const (
 
 type ScorerSettings struct {
 	BatchSize int32 `json:"batch_size"`
+	score.DecaySettings
+
+	// Lookback is used by the weightedmedian and trimmedmean scorers
+	Lookback score.LookbackSettings `json:"lookback"`
+
+	// Main is the scorer that updates the server scores; change it
+	// with Promote so the change is logged.
+	Main string `json:"main"`
+	// Enabled lists the scorers to run; all of them if empty. The
+	// other enabled scorers run in shadow mode, only updating their
+	// own server_scores rows. The main scorer always runs.
+	Enabled []string `json:"enabled"`
+}
+
+// MainScorer returns the name of the main scorer
+func (s ScorerSettings) MainScorer() string {
+	if len(s.Main) == 0 {
+		return defaultMainScorer
+	}
+	return s.Main
+}
+
+// IsEnabled returns if the scorer should run
+func (s ScorerSettings) IsEnabled(name string) bool {
+	if len(s.Enabled) == 0 || name == s.MainScorer() {
+		return true
+	}
+	return slices.Contains(s.Enabled, name)
 }
 
 type metrics struct {
@@ -48,6 +82,9 @@ type runner struct {
 	log      *slog.Logger
 	registry map[string]*ScorerMap
 	m        *metrics
+
+	// main is the main scorer from the last setup
+	main string
 }
 
 type lastUpdate struct {
@@ -57,16 +94,19 @@ type lastUpdate struct {
 
 func New(ctx context.Context, log *slog.Logger, dbconn *sql.DB, prom prometheus.Registerer) (*runner, error) {
 	reg := map[string]*ScorerMap{
-		"every":        {Scorer: every.New()},
-		"recentmedian": {Scorer: recentmedian.New()},
+		"every":          {Scorer: every.New()},
+		"recentmedian":   {Scorer: recentmedian.New()},
+		"weightedmedian": {Scorer: weightedmedian.New()},
+		"trimmedmean":    {Scorer: trimmedmean.New()},
+		"consensus":      {Scorer: consensus.New()},
 	}
 
 	for _, sm := range reg {
 		sm.lastScore = map[int]*lastUpdate{}
 	}
 
-	if _, ok := reg[mainScorer]; !ok {
-		log.Warn("invalid main scorer", "name", mainScorer)
+	if _, ok := reg[defaultMainScorer]; !ok {
+		log.Warn("invalid main scorer", "name", defaultMainScorer)
 	}
 
 	met := &metrics{
@@ -156,35 +196,19 @@ func (r *runner) Run(ctx context.Context) (int, error) {
 
 	db := ntpdb.New(r.dbconn)
 
-	settings := r.Settings(db)
-
-	registry := r.Scorers()
-
-	scorers, err := db.GetScorers(r.ctx)
+	settings, err := r.setup(ctx, db)
 	if err != nil {
 		r.m.errcount.Add(1)
 		return 0, err
 	}
-	if len(scorers) == 0 {
-		return 0, fmt.Errorf("no scorers configured")
-	}
 
-	for _, sc := range scorers {
-		log.Debug("setting up scorer", "name", sc.Hostname, "last_id", sc.LogScoreID)
-		if s, ok := registry[sc.Hostname]; ok {
-			s.Scorer.Setup(sc.ID)
-			s.ScorerID = sc.ID
-			s.LastID = sc.LogScoreID
-		} else {
-			log.Warn("scorer not implemented", "name", sc.Hostname)
-		}
-	}
+	registry := r.Scorers()
 
 	count := 0
 
 	for name, sm := range registry {
 		log = log.With("name", name)
-		if sm.ScorerID == 0 {
+		if sm.ScorerID == 0 || !sm.enabled {
 			continue
 		}
 		log.DebugContext(ctx, "processing", "from_id", sm.LastID)
@@ -243,6 +267,59 @@ func (r *runner) Run(ctx context.Context) (int, error) {
 	return count, nil
 }
 
+// setup loads the scorer settings and sets up the scorers in the
+// registry with their ID and position from the database.
+func (r *runner) setup(ctx context.Context, db *ntpdb.Queries) (ScorerSettings, error) {
+	settings := r.Settings(db)
+
+	registry := r.Scorers()
+
+	scorers, err := db.GetScorers(ctx)
+	if err != nil {
+		return settings, err
+	}
+	if len(scorers) == 0 {
+		return settings, fmt.Errorf("no scorers configured")
+	}
+
+	for _, sm := range registry {
+		if ds, ok := sm.Scorer.(types.DecayScorer); ok {
+			ds.SetDecay(settings.Decay())
+		}
+		if ls, ok := sm.Scorer.(types.LookbackScorer); ok {
+			ls.SetLookback(settings.Lookback.Lookback())
+		}
+	}
+
+	for _, sc := range scorers {
+		r.log.Debug("setting up scorer", "name", sc.Hostname, "last_id", sc.LogScoreID)
+		if s, ok := registry[sc.Hostname]; ok {
+			s.Scorer.Setup(sc.ID)
+			s.ScorerID = sc.ID
+			s.LastID = sc.LogScoreID
+		} else {
+			r.log.Warn("scorer not implemented", "name", sc.Hostname)
+		}
+	}
+
+	main := settings.MainScorer()
+	if sm, ok := registry[main]; !ok || sm.ScorerID == 0 {
+		r.log.Error("main scorer not configured, using the default", "name", main, "default", defaultMainScorer)
+		main = defaultMainScorer
+		settings.Main = main
+	}
+	if main != r.main {
+		r.log.Info("main scorer", "name", main, "previous", r.main)
+		r.main = main
+	}
+
+	for name, sm := range registry {
+		sm.enabled = settings.IsEnabled(name)
+	}
+
+	return settings, nil
+}
+
 func (r *runner) getLogScores(ctx context.Context, db *ntpdb.Queries, log *slog.Logger, lastID uint64, batchSize int32, retry bool) ([]ntpdb.LogScore, error) {
 	// log.Printf("getting log scores from %d (limit %d)", sm.LastID, batchSize)
 
@@ -382,7 +459,21 @@ func (r *runner) process(ctx context.Context, name string, sm *ScorerMap, batchS
 		}
 		r.m.sqlUpdates.WithLabelValues("update_server_score").Inc()
 
-		if name == mainScorer {
+		if ns.Consensus != nil {
+			err := db.UpdateServerOffset(r.ctx, ntpdb.UpdateServerOffsetParams{
+				ServerID: ns.ServerID,
+				Ts:       ns.Ts,
+				Offset:   ns.Consensus.Offset,
+				Spread:   ns.Consensus.Spread,
+				Monitors: uint16(ns.Consensus.Monitors),
+			})
+			if err != nil {
+				return 0, err
+			}
+			r.m.sqlUpdates.WithLabelValues("update_server_offset").Inc()
+		}
+
+		if name == r.main {
 			err := db.UpdateServer(r.ctx, ntpdb.UpdateServerParams{
 				ID:       ns.ServerID,
 				ScoreTs:  sql.NullTime{Time: ns.Ts, Valid: true},
@@ -0,0 +1,34 @@
+package scorer
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestScorerSettings(t *testing.T) {
+	var settings ScorerSettings
+	if got := settings.MainScorer(); got != defaultMainScorer {
+		t.Errorf("default main scorer %q, want %q", got, defaultMainScorer)
+	}
+	if !settings.IsEnabled("every") {
+		t.Errorf("all scorers should be enabled by default")
+	}
+
+	err := json.Unmarshal([]byte(`{"main": "trimmedmean", "enabled": ["weightedmedian"]}`), &settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := settings.MainScorer(); got != "trimmedmean" {
+		t.Errorf("main scorer %q, want trimmedmean", got)
+	}
+	for name, enabled := range map[string]bool{
+		"trimmedmean":    true, // the main scorer always runs
+		"weightedmedian": true,
+		"recentmedian":   false,
+		"every":          false,
+	} {
+		if got := settings.IsEnabled(name); got != enabled {
+			t.Errorf("IsEnabled(%q) = %t, want %t", name, got, enabled)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package score
+
+import (
+	"database/sql"
+	"math"
+	"time"
+
+	"go.ntppool.org/common/timeutil"
+)
+
+const (
+	// DefaultHalfLife is about what the old fixed 0.95 multiplier
+	// per result gave with the default active check interval.
+	DefaultHalfLife = 2 * time.Hour
+
+	// legacyFactor is used when there's no previous score time
+	legacyFactor = 0.95
+
+	// steadyState scales the step so a server that always gets the
+	// same step ends up with a score of 20 times the step, like with
+	// the old multiplier (1 / (1 - 0.95)).
+	steadyState = 1 / (1 - legacyFactor)
+
+	// minDecayInterval limits how little weight a result can get
+	// when checks are very close together (or out of order)
+	minDecayInterval = time.Minute
+)
+
+// DecaySettings are part of the "scorer" system setting
+type DecaySettings struct {
+	HalfLife timeutil.Duration `json:"half_life"`
+}
+
+// Decay returns the score decay for the settings
+func (s DecaySettings) Decay() Decay {
+	return Decay{HalfLife: s.HalfLife.Duration}
+}
+
+// Decay is the time based decay of the running score. The previous
+// score decays by half every HalfLife, so a score means the same
+// thing no matter how often a server is checked.
+type Decay struct {
+	HalfLife time.Duration
+}
+
+// Factor returns how much of the previous score is kept for a
+// result at ts. The time since the previous score is limited to
+// between a minute and the half-life, so a single result after a
+// long gap doesn't replace the score.
+func (d Decay) Factor(prevTs sql.NullTime, ts time.Time) float64 {
+	if !prevTs.Valid || prevTs.Time.IsZero() {
+		return legacyFactor
+	}
+
+	halfLife := d.HalfLife
+	if halfLife <= 0 {
+		halfLife = DefaultHalfLife
+	}
+
+	dt := ts.Sub(prevTs.Time)
+	dt = max(dt, minDecayInterval)
+	dt = min(dt, halfLife)
+
+	return math.Pow(0.5, dt.Seconds()/halfLife.Seconds())
+}
+
+// Apply returns the new score after adding step at ts to the
+// previous score from prevTs.
+func (d Decay) Apply(prev float64, prevTs sql.NullTime, ts time.Time, step float64) float64 {
+	f := d.Factor(prevTs, ts)
+	return prev*f + step*(1-f)*steadyState
+}
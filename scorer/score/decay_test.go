@@ -0,0 +1,49 @@
+package score
+
+import (
+	"database/sql"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDecay(t *testing.T) {
+	d := Decay{HalfLife: time.Hour}
+	now := time.Now()
+
+	// no previous score time works like the old multiplier
+	if got := d.Apply(10, sql.NullTime{}, now, 1); math.Abs(got-10.5) > 1e-9 {
+		t.Errorf("without previous ts got %f, want 10.5", got)
+	}
+
+	prev := sql.NullTime{Time: now.Add(-time.Hour), Valid: true}
+	if got := d.Factor(prev, now); math.Abs(got-0.5) > 1e-9 {
+		t.Errorf("factor after one half-life %f, want 0.5", got)
+	}
+
+	// longer gaps count as one half-life
+	old := sql.NullTime{Time: now.Add(-24 * time.Hour), Valid: true}
+	if got := d.Factor(old, now); math.Abs(got-0.5) > 1e-9 {
+		t.Errorf("factor after a long gap %f, want 0.5", got)
+	}
+
+	// late results still count
+	late := sql.NullTime{Time: now.Add(time.Hour), Valid: true}
+	if got := d.Factor(late, now); got >= 1 {
+		t.Errorf("factor for an out of order result %f, want < 1", got)
+	}
+
+	// the score converges to 20 times the step regardless of
+	// the check interval
+	for _, interval := range []time.Duration{5 * time.Minute, 45 * time.Minute} {
+		score := 0.0
+		ts := now
+		for range 500 {
+			score = d.Apply(score, sql.NullTime{Time: ts, Valid: true}, ts.Add(interval), 1)
+			ts = ts.Add(interval)
+		}
+		if math.Abs(score-20) > 0.01 {
+			t.Errorf("interval %s: score %f, want 20", interval, score)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package score
+
+import (
+	"context"
+	"time"
+
+	"go.ntppool.org/common/timeutil"
+	"go.ntppool.org/monitor/ntpdb"
+)
+
+// Lookback is how far back the latest score from each monitor is
+// used. Active monitors are used first; if none of them have
+// scores within the window, testing monitors and then candidate
+// monitors are included with the longer windows.
+type Lookback struct {
+	Active    time.Duration
+	Testing   time.Duration
+	Candidate time.Duration
+}
+
+// LookbackSettings are part of the "scorer" system setting
+type LookbackSettings struct {
+	Active    timeutil.Duration `json:"active"`
+	Testing   timeutil.Duration `json:"testing"`
+	Candidate timeutil.Duration `json:"candidate"`
+}
+
+// DefaultLookback is what the recentmedian scorer uses
+func DefaultLookback() Lookback {
+	return Lookback{
+		Active:    20 * time.Minute,
+		Testing:   45 * time.Minute,
+		Candidate: 2 * time.Hour,
+	}
+}
+
+// Lookback returns the lookback for the settings, with the defaults
+// for anything not set.
+func (s LookbackSettings) Lookback() Lookback {
+	lb := DefaultLookback()
+	if s.Active.Duration > 0 {
+		lb.Active = s.Active.Duration
+	}
+	if s.Testing.Duration > 0 {
+		lb.Testing = s.Testing.Duration
+	}
+	if s.Candidate.Duration > 0 {
+		lb.Candidate = s.Candidate.Duration
+	}
+	return lb
+}
+
+// RecentScores returns the latest score from each monitor for the
+// server as of ts.
+func (lb Lookback) RecentScores(ctx context.Context, db *ntpdb.Queries, serverID uint32, ts time.Time) ([]ntpdb.LogScore, error) {
+	arg := ntpdb.GetScorerRecentScoresParams{
+		TimeLookback:  int(lb.Active.Seconds()),
+		ServerID:      serverID,
+		MonitorStatus: ntpdb.ServerScoresStatusActive,
+		Ts:            ts,
+	}
+
+	recent, err := db.GetScorerRecentScores(ctx, arg)
+	if err != nil || len(recent) > 0 {
+		return recent, err
+	}
+
+	arg.TimeLookback = int(lb.Testing.Seconds())
+	arg.MonitorStatus2 = ntpdb.ServerScoresStatusTesting
+	recent, err = db.GetScorerRecentScores(ctx, arg)
+	if err != nil || len(recent) > 0 {
+		return recent, err
+	}
+
+	arg.TimeLookback = int(lb.Candidate.Seconds())
+	arg.MonitorStatus = ntpdb.ServerScoresStatusCandidate
+	return db.GetScorerRecentScores(ctx, arg)
+}
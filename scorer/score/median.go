@@ -0,0 +1,37 @@
+package score
+
+import (
+	"cmp"
+	"slices"
+
+	"go.ntppool.org/monitor/ntpdb"
+)
+
+// CompareScore orders log scores by score and then by RTT, so of
+// equal scores the one from the closest monitor is first.
+func CompareScore(a, b ntpdb.LogScore) int {
+	if c := cmp.Compare(a.Score, b.Score); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.Rtt.Int32, b.Rtt.Int32)
+}
+
+// SortByScore returns a copy of the log scores ordered with
+// CompareScore.
+func SortByScore(recent []ntpdb.LogScore) []ntpdb.LogScore {
+	recent = slices.Clone(recent)
+	slices.SortStableFunc(recent, CompareScore)
+	return recent
+}
+
+// Median returns the log score with the median score; with an even
+// number of scores the lower of the two middle ones.
+func Median(recent []ntpdb.LogScore) ntpdb.LogScore {
+	recent = SortByScore(recent)
+
+	i := len(recent) / 2
+	if len(recent)%2 == 0 {
+		i--
+	}
+	return recent[i]
+}
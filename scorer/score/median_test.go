@@ -0,0 +1,40 @@
+package score
+
+import (
+	"database/sql"
+	"testing"
+
+	"go.ntppool.org/monitor/ntpdb"
+)
+
+func TestMedian(t *testing.T) {
+	ls := func(id uint64, score float64, rtt int32) ntpdb.LogScore {
+		return ntpdb.LogScore{ID: id, Score: score, Rtt: sql.NullInt32{Int32: rtt, Valid: true}}
+	}
+
+	tests := []struct {
+		name   string
+		scores []ntpdb.LogScore
+		want   uint64
+	}{
+		{"single", []ntpdb.LogScore{ls(1, 15, 10)}, 1},
+		{"odd", []ntpdb.LogScore{ls(1, 20, 10), ls(2, -10, 10), ls(3, 15, 10)}, 3},
+		{"even takes the lower", []ntpdb.LogScore{ls(1, 20, 10), ls(2, -10, 10), ls(3, 15, 10), ls(4, 19, 10)}, 3},
+		{"equal scores by rtt", []ntpdb.LogScore{ls(1, 20, 300), ls(2, 20, 5), ls(3, 20, 50)}, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Median(tt.scores); got.ID != tt.want {
+				t.Errorf("Median = %d (%f), want %d", got.ID, got.Score, tt.want)
+			}
+		})
+	}
+
+	// the scores passed in aren't reordered
+	scores := []ntpdb.LogScore{ls(1, 20, 10), ls(2, -10, 10), ls(3, 15, 10)}
+	Median(scores)
+	if scores[0].ID != 1 || scores[1].ID != 2 || scores[2].ID != 3 {
+		t.Errorf("Median sorted the scores in place")
+	}
+}
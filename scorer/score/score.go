@@ -1,6 +1,10 @@
 package score
 
-import "go.ntppool.org/monitor/ntpdb"
+import (
+	"encoding/json"
+
+	"go.ntppool.org/monitor/ntpdb"
+)
 
 type ScoreAttributes struct {
 	ntpdb.LogScoreAttributes
@@ -11,8 +15,41 @@ type Score struct {
 
 	HasMaxScore bool
 	MaxScore    float64
+
+	// ScoreStep is the step added to the running score; it's
+	// Step weighted for timeouts in a batch with an outage
+	ScoreStep float64
+
+	// Consensus is set by scorers that compute the offset of the
+	// server from the monitor measurements
+	Consensus *Consensus
+}
+
+// Consensus is the offset of a server as measured by the monitors;
+// offset and spread are in seconds.
+type Consensus struct {
+	Offset   float64
+	Spread   float64
+	Monitors int
 }
 
 func (s *Score) AsLogScore() *ntpdb.LogScore {
 	return nil
 }
+
+// AppliedStep returns the step and max score that were applied to
+// the running score for ls. Results stored before they were recorded
+// in the attributes use the step and the max score for the offset.
+func AppliedStep(ls *ntpdb.LogScore) (float64, float64, bool) {
+	if ls.Attributes.Valid {
+		var attributes ntpdb.LogScoreAttributes
+		if err := json.Unmarshal([]byte(ls.Attributes.String), &attributes); err == nil && attributes.ScoreStep != nil {
+			if attributes.MaxScore != nil {
+				return *attributes.ScoreStep, *attributes.MaxScore, true
+			}
+			return *attributes.ScoreStep, 0, false
+		}
+	}
+	maxScore, hasMaxScore := ls.MaxScore()
+	return ls.Step, maxScore, hasMaxScore
+}
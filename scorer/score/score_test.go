@@ -0,0 +1,59 @@
+package score
+
+import (
+	"database/sql"
+	"testing"
+
+	"go.ntppool.org/monitor/ntpdb"
+)
+
+func TestAppliedStep(t *testing.T) {
+	tests := []struct {
+		name        string
+		ls          ntpdb.LogScore
+		step        float64
+		maxScore    float64
+		hasMaxScore bool
+	}{
+		{
+			name: "no attributes",
+			ls:   ntpdb.LogScore{Step: 1},
+			step: 1,
+		},
+		{
+			name:        "max score for the offset",
+			ls:          ntpdb.LogScore{Step: 1, Offset: sql.NullFloat64{Float64: 4, Valid: true}},
+			step:        1,
+			maxScore:    -20,
+			hasMaxScore: true,
+		},
+		{
+			name: "recorded step",
+			ls: ntpdb.LogScore{
+				Step:       -5,
+				Attributes: sql.NullString{String: `{"no_response":true,"score_step":-1.25}`, Valid: true},
+			},
+			step: -1.25,
+		},
+		{
+			name: "recorded step and max score",
+			ls: ntpdb.LogScore{
+				Step:       0.5,
+				Attributes: sql.NullString{String: `{"score_step":0.5,"max_score":-10}`, Valid: true},
+			},
+			step:        0.5,
+			maxScore:    -10,
+			hasMaxScore: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, maxScore, hasMaxScore := AppliedStep(&tt.ls)
+			if step != tt.step || maxScore != tt.maxScore || hasMaxScore != tt.hasMaxScore {
+				t.Errorf("AppliedStep() = %f, %f, %t; want %f, %f, %t",
+					step, maxScore, hasMaxScore, tt.step, tt.maxScore, tt.hasMaxScore)
+			}
+		})
+	}
+}
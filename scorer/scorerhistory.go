@@ -13,6 +13,7 @@ type ScorerMap struct {
 	ScorerID  uint32
 	LastID    uint64
 	lastScore map[int]*lastUpdate
+	enabled   bool
 }
 
 var minScoreInterval = 15 * time.Minute
@@ -4,19 +4,262 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
 	"time"
 
+	"google.golang.org/protobuf/types/known/timestamppb"
+
 	"go.ntppool.org/common/logger"
+	"go.ntppool.org/common/timeutil"
 
 	apiv2 "go.ntppool.org/monitor/gen/monitor/v2"
 	"go.ntppool.org/monitor/ntpdb"
 	"go.ntppool.org/monitor/scorer/score"
 )
 
-type StatusScorer struct{}
+// Settings is the scoring policy: the steps for each kind of result
+// and the penalties for servers that answer but aren't synchronized
+// well enough to be useful. It's loaded from the "statusscore"
+// system setting with ParseSettings; settings that aren't in the
+// policy get the defaults.
+type Settings struct {
+	// Version identifies the policy and is recorded in the log score
+	// attributes. The built-in policy is version 0; a policy in the
+	// system settings must have a version of 1 or higher.
+	Version int `json:"version"`
+
+	// OffsetSteps maps the absolute offset to a step. They are
+	// ordered by offset; the last one has no offset and applies to
+	// anything larger.
+	OffsetSteps []OffsetStep `json:"offset_steps"`
+	// HighStratum is the stratum from which HighStratumStep is used
+	// if the offset gives a higher step.
+	HighStratum     int32   `json:"high_stratum"`
+	HighStratumStep float64 `json:"high_stratum_step"`
+
+	// TimeoutStep is the step when the server didn't respond.
+	TimeoutStep float64 `json:"timeout_step"`
+	// RateLimitStep is the step for a RATE kiss-o'-death response.
+	RateLimitStep float64 `json:"rate_limit_step"`
+	// DenyStep and DenyMaxScore are for RSTR and DENY responses.
+	DenyStep     float64 `json:"deny_step"`
+	DenyMaxScore float64 `json:"deny_max_score"`
+	// Stratum0Step and Stratum0MaxScore are for other stratum 0
+	// responses.
+	Stratum0Step     float64 `json:"stratum0_step"`
+	Stratum0MaxScore float64 `json:"stratum0_max_score"`
+	// ErrorStep is for responses with any other error.
+	ErrorStep float64 `json:"error_step"`
+
+	// MaxRootDistance is the synchronization distance above which a
+	// server isn't suitable as a time source (RFC 5905 MAXDIST).
+	MaxRootDistance timeutil.Duration `json:"max_root_distance"`
+	// RootDistanceStep is the step used when the root distance is too high.
+	RootDistanceStep float64 `json:"root_distance_step"`
+
+	// MaxReferenceAge is how long ago the server can have last
+	// updated its clock before it's considered unsynchronized.
+	MaxReferenceAge timeutil.Duration `json:"max_reference_age"`
+	// ReferenceAgeStep is the step used when the reference time is too old.
+	ReferenceAgeStep float64 `json:"reference_age_step"`
+
+	// MaxLossRate is the fraction of samples that can be lost before
+	// the server is penalized.
+	MaxLossRate float64 `json:"max_loss_rate"`
+	// LossStep is the step used when too many samples were lost.
+	LossStep float64 `json:"loss_step"`
+
+	// MaxJitter is the highest acceptable RMS difference between the
+	// sample offsets and the selected offset.
+	MaxJitter timeutil.Duration `json:"max_jitter"`
+	// JitterStep is the step used when the jitter is too high.
+	JitterStep float64 `json:"jitter_step"`
+}
+
+// OffsetStep is one point in the offset to step mapping
+type OffsetStep struct {
+	// Offset is the largest absolute offset the step is used for;
+	// zero for the last step.
+	Offset timeutil.Duration `json:"offset"`
+	// Step is the step at Offset.
+	Step float64 `json:"step"`
+	// Interpolate makes the step linear between the previous point
+	// and this one.
+	Interpolate bool `json:"interpolate,omitempty"`
+	// MaxScore caps the running score when set.
+	MaxScore *float64 `json:"max_score,omitempty"`
+}
+
+func defaultOffsetSteps() []OffsetStep {
+	maxScore := -20.0
+	return []OffsetStep{
+		{Offset: timeutil.Duration{Duration: 25 * time.Millisecond}, Step: 1},
+		{Offset: timeutil.Duration{Duration: 100 * time.Millisecond}, Step: 0.5, Interpolate: true},
+		{Offset: timeutil.Duration{Duration: 750 * time.Millisecond}, Step: -1, Interpolate: true},
+		{Offset: timeutil.Duration{Duration: 3 * time.Second}, Step: -2},
+		{Step: -4, MaxScore: &maxScore},
+	}
+}
+
+// DefaultSettings returns the settings used when nothing is configured
+func DefaultSettings() Settings {
+	return Settings{
+		OffsetSteps:      defaultOffsetSteps(),
+		HighStratum:      8,
+		HighStratumStep:  -4,
+		TimeoutStep:      -5,
+		RateLimitStep:    -3.5,
+		DenyStep:         -10,
+		DenyMaxScore:     -50,
+		Stratum0Step:     -2,
+		Stratum0MaxScore: 5,
+		ErrorStep:        -4,
+		MaxRootDistance:  timeutil.Duration{Duration: 1500 * time.Millisecond},
+		RootDistanceStep: -4,
+		MaxReferenceAge:  timeutil.Duration{Duration: 6 * time.Hour},
+		ReferenceAgeStep: -2,
+		MaxLossRate:      0.5,
+		LossStep:         -1,
+		MaxJitter:        timeutil.Duration{Duration: 50 * time.Millisecond},
+		JitterStep:       0,
+	}
+}
+
+// maxPenalty is the largest penalty a step can give; steps are
+// between -maxPenalty and 1.
+const maxPenalty = 100
+
+// ParseSettings reads a policy from the system setting and checks
+// that it's valid.
+func ParseSettings(data []byte) (Settings, error) {
+	// decode over the defaults so only the settings in the policy
+	// change; a step can be set to 0.
+	settings := DefaultSettings()
+	settings.OffsetSteps = nil
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return Settings{}, err
+	}
+	if settings.Version < 1 {
+		return Settings{}, errors.New("policy version must be 1 or higher")
+	}
+	settings.setDefaults()
+	if err := settings.Validate(); err != nil {
+		return Settings{}, fmt.Errorf("policy version %d: %w", settings.Version, err)
+	}
+	return settings, nil
+}
+
+// Validate checks that the offset steps are ordered and cover all
+// offsets and that every step is between -maxPenalty and 1.
+func (s *Settings) Validate() error {
+	if len(s.OffsetSteps) == 0 {
+		return errors.New("no offset steps")
+	}
+	var prev time.Duration
+	for i, os := range s.OffsetSteps {
+		last := i == len(s.OffsetSteps)-1
+		switch {
+		case last && os.Offset.Duration != 0:
+			return errors.New("the last offset step must not have an offset")
+		case !last && os.Offset.Duration <= prev:
+			return fmt.Errorf("offset step %d: offsets must be increasing", i)
+		case os.Interpolate && (i == 0 || last):
+			return fmt.Errorf("offset step %d: can't interpolate the first or last step", i)
+		case os.Step > 1 || os.Step < -maxPenalty:
+			return fmt.Errorf("offset step %d: step %f is out of range", i, os.Step)
+		}
+		prev = os.Offset.Duration
+	}
+
+	for name, step := range map[string]float64{
+		"high_stratum_step":  s.HighStratumStep,
+		"timeout_step":       s.TimeoutStep,
+		"rate_limit_step":    s.RateLimitStep,
+		"deny_step":          s.DenyStep,
+		"stratum0_step":      s.Stratum0Step,
+		"error_step":         s.ErrorStep,
+		"root_distance_step": s.RootDistanceStep,
+		"reference_age_step": s.ReferenceAgeStep,
+		"loss_step":          s.LossStep,
+		"jitter_step":        s.JitterStep,
+	} {
+		if step > 1 || step < -maxPenalty {
+			return fmt.Errorf("%s %f is out of range", name, step)
+		}
+	}
+
+	return nil
+}
+
+// setDefaults sets the offset steps and the limits that aren't set;
+// unlike the steps they aren't valid as zero.
+func (s *Settings) setDefaults() {
+	defaults := DefaultSettings()
+	if len(s.OffsetSteps) == 0 {
+		s.OffsetSteps = defaults.OffsetSteps
+	}
+	if s.HighStratum <= 0 {
+		s.HighStratum = defaults.HighStratum
+	}
+	if s.MaxRootDistance.Duration <= 0 {
+		s.MaxRootDistance = defaults.MaxRootDistance
+	}
+	if s.MaxReferenceAge.Duration <= 0 {
+		s.MaxReferenceAge = defaults.MaxReferenceAge
+	}
+	if s.MaxLossRate <= 0 {
+		s.MaxLossRate = defaults.MaxLossRate
+	}
+	if s.MaxJitter.Duration <= 0 {
+		s.MaxJitter = defaults.MaxJitter
+	}
+}
+
+type StatusScorer struct {
+	settings      Settings
+	batchID       string
+	quarantined   bool
+	timeoutWeight float64
+}
 
 func NewScorer() *StatusScorer {
-	return &StatusScorer{}
+	return &StatusScorer{settings: DefaultSettings(), timeoutWeight: 1}
+}
+
+// NewScorerWithSettings returns a scorer using the specified
+// settings. The steps are used as they are; start from
+// DefaultSettings to change some of them. Use ParseSettings to
+// validate settings from the database first.
+func NewScorerWithSettings(settings Settings) *StatusScorer {
+	settings.setDefaults()
+	return &StatusScorer{settings: settings, timeoutWeight: 1}
+}
+
+// WithBatchID returns a copy of the scorer that records the batch ID
+// in the attributes of the scores.
+func (s *StatusScorer) WithBatchID(batchID string) *StatusScorer {
+	n := *s
+	n.batchID = batchID
+	return &n
+}
+
+// WithQuarantined returns a copy of the scorer that marks the
+// scores as quarantined in the attributes.
+func (s *StatusScorer) WithQuarantined() *StatusScorer {
+	n := *s
+	n.quarantined = true
+	return &n
+}
+
+// WithTimeoutWeight returns a copy of the scorer that adds weight
+// times the timeout step to the running score.
+func (s *StatusScorer) WithTimeoutWeight(weight float64) *StatusScorer {
+	n := *s
+	n.timeoutWeight = weight
+	return &n
 }
 
 func (s *StatusScorer) Score(ctx context.Context, server *ntpdb.Server, status *apiv2.ServerStatus) (*score.Score, error) {
@@ -41,59 +284,74 @@ func (s *StatusScorer) calc(ctx context.Context, server *ntpdb.Server, status *a
 	sc.HasMaxScore = false
 
 	step := 0.0
+	warnings := []string{}
+	lossRate, jitter := sampleStats(status)
 
 	if status.NoResponse {
-		step = -5
+		step = s.settings.TimeoutStep
 	} else if status.Stratum == 0 && status.Error == "RATE" {
-		step = -3.5
+		step = s.settings.RateLimitStep
 	} else if status.Stratum == 0 && (status.Error == "RSTR" || status.Error == "DENY") {
-		step = -10
+		step = s.settings.DenyStep
 		sc.HasMaxScore = true
-		sc.MaxScore = -50
+		sc.MaxScore = s.settings.DenyMaxScore
 	} else if status.Stratum == 0 && (status.Error == "" || status.Error == "untrusted zero offset") {
-		step = -2
-		sc.MaxScore = 5
+		step = s.settings.Stratum0Step
+		sc.MaxScore = s.settings.Stratum0MaxScore
 		sc.HasMaxScore = true
 		if status.Error == "" {
 			status.Error = "unexpected stratum 0"
 		}
 	} else if len(status.Error) > 0 || status.Offset == nil {
-		step = -4 // what errors would this be that have a response but aren't RATE?
+		step = s.settings.ErrorStep // what errors would this be that have a response but aren't RATE?
 	} else {
-		offsetAbs := status.AbsoluteOffset()
-		if *offsetAbs > 3*time.Second || status.Stratum >= 8 {
-			step = -4
-			if *offsetAbs > 3*time.Second {
-				sc.HasMaxScore = true
-				sc.MaxScore = -20
-			}
-		} else if *offsetAbs > 750*time.Millisecond {
-			step = -2
-		} else if *offsetAbs > 25*time.Millisecond {
-			offsetSecs := offsetAbs.Seconds()
-			if offsetSecs <= 0.100 { // 25ms - 100ms range
-				step = -6.667*offsetSecs + 1.167
-			} else { // 100ms - 750ms range
-				step = -2.308*offsetSecs + 0.731
-			}
-			// Sanity check: never exceed +1
-			if step > 1 {
-				step = 1
-			}
-		} else {
-			step = 1
+		var maxScore *float64
+		step, maxScore = s.offsetStep(*status.AbsoluteOffset())
+		if maxScore != nil {
+			sc.HasMaxScore = true
+			sc.MaxScore = *maxScore
 		}
+		if status.Stratum >= s.settings.HighStratum {
+			step = math.Min(step, s.settings.HighStratumStep)
+		}
+
+		// Sanity check: never exceed +1
+		step = math.Min(step, 1)
+
+		step, warnings = s.syncPenalty(status, step, warnings)
+		step, warnings = s.samplePenalty(lossRate, jitter, step, warnings)
 	}
 
 	sc.Step = step
+	sc.ScoreStep = step
+	if status.NoResponse {
+		sc.ScoreStep = step * s.timeoutWeight
+	}
 
 	attributeStr := sql.NullString{}
 
-	if status.Leap > 0 || len(status.Error) > 0 {
+	hasHeader := status.ReferenceTime != nil || status.ReferenceId != 0
+
+	if status.Leap > 0 || status.NoResponse || len(status.Error) > 0 || len(warnings) > 0 || hasHeader || lossRate > 0 || len(s.batchID) > 0 || s.settings.Version > 0 || s.quarantined {
 		log.Debug("Got attributes", "status", status)
 		attributes := ntpdb.LogScoreAttributes{
-			Leap:  int8(status.Leap),
-			Error: status.Error,
+			Leap:       int8(status.Leap),
+			NoResponse: status.NoResponse,
+			Error:      status.Error,
+			Warning:    strings.Join(warnings, ", "),
+			LossRate:   lossRate,
+			Jitter:     jitter.Seconds(),
+			BatchID:    s.batchID,
+
+			PolicyVersion: s.settings.Version,
+			ScoreStep:     &sc.ScoreStep,
+			Quarantined:   s.quarantined,
+		}
+		if sc.HasMaxScore {
+			attributes.MaxScore = &sc.MaxScore
+		}
+		if hasHeader {
+			setHeaderAttributes(&attributes, status)
 		}
 		b, err := json.Marshal(attributes)
 		if err != nil {
@@ -106,3 +364,114 @@ func (s *StatusScorer) calc(ctx context.Context, server *ntpdb.Server, status *a
 
 	return &sc, nil
 }
+
+// offsetStep returns the step for the absolute offset and the max
+// score if the step has one.
+func (s *StatusScorer) offsetStep(offset time.Duration) (float64, *float64) {
+	steps := s.settings.OffsetSteps
+	for i, os := range steps {
+		if os.Offset.Duration != 0 && offset > os.Offset.Duration {
+			continue
+		}
+		if !os.Interpolate || i == 0 {
+			return os.Step, os.MaxScore
+		}
+		prev := steps[i-1]
+		frac := float64(offset-prev.Offset.Duration) / float64(os.Offset.Duration-prev.Offset.Duration)
+		return prev.Step + frac*(os.Step-prev.Step), os.MaxScore
+	}
+	// not reached with valid settings
+	last := steps[len(steps)-1]
+	return last.Step, last.MaxScore
+}
+
+// syncPenalty lowers the step if the server root distance or the age
+// of its reference time shows it isn't synchronized, even if the
+// offset looks fine.
+func (s *StatusScorer) syncPenalty(status *apiv2.ServerStatus, step float64, warnings []string) (float64, []string) {
+	if status.RootDistance != nil {
+		if rd := status.RootDistance.AsDuration(); rd > s.settings.MaxRootDistance.Duration {
+			step = math.Min(step, s.settings.RootDistanceStep)
+			warnings = append(warnings, fmt.Sprintf("root distance %s", rd.Round(time.Millisecond)))
+		}
+	}
+
+	if status.ReferenceAge != nil {
+		if age := status.ReferenceAge.AsDuration(); age > s.settings.MaxReferenceAge.Duration {
+			step = math.Min(step, s.settings.ReferenceAgeStep)
+			warnings = append(warnings, fmt.Sprintf("reference time age %s", age.Round(time.Second)))
+		}
+	}
+
+	return step, warnings
+}
+
+// samplePenalty lowers the step if too many of the samples were lost
+// or the offsets of the other samples vary too much.
+func (s *StatusScorer) samplePenalty(lossRate float64, jitter time.Duration, step float64, warnings []string) (float64, []string) {
+	if lossRate > s.settings.MaxLossRate {
+		step = math.Min(step, s.settings.LossStep)
+		warnings = append(warnings, fmt.Sprintf("packet loss %.0f%%", lossRate*100))
+	}
+	if jitter > s.settings.MaxJitter.Duration {
+		step = math.Min(step, s.settings.JitterStep)
+		warnings = append(warnings, fmt.Sprintf("jitter %s", jitter.Round(time.Millisecond)))
+	}
+	return step, warnings
+}
+
+// sampleStats returns the fraction of samples without a response and
+// the RMS difference between the offsets of the samples (outliers
+// excluded) and the selected offset, as in RFC 5905 section 10.
+func sampleStats(status *apiv2.ServerStatus) (float64, time.Duration) {
+	if len(status.Samples) == 0 {
+		return 0, 0
+	}
+
+	lost := 0
+	n := 0
+	sum := 0.0
+	for _, sample := range status.Samples {
+		if sample.NoResponse {
+			lost++
+			continue
+		}
+		if sample.Outlier || sample.Offset == nil || len(sample.Error) > 0 {
+			continue
+		}
+		if status.Offset != nil {
+			d := (sample.Offset.AsDuration() - status.Offset.AsDuration()).Seconds()
+			sum += d * d
+		}
+		n++
+	}
+
+	lossRate := float64(lost) / float64(len(status.Samples))
+
+	var jitter time.Duration
+	if n > 1 {
+		jitter = time.Duration(math.Sqrt(sum/float64(n-1)) * float64(time.Second))
+	}
+
+	return lossRate, jitter
+}
+
+// setHeaderAttributes copies the extended NTP header fields from the
+// status so they can be inspected with the log score.
+func setHeaderAttributes(attributes *ntpdb.LogScoreAttributes, status *apiv2.ServerStatus) {
+	attributes.Precision = status.Precision.AsDuration().Seconds()
+	attributes.RefID = status.ReferenceIDString()
+	attributes.RootDelay = status.RootDelay.AsDuration().Seconds()
+	attributes.RootDispersion = status.RootDispersion.AsDuration().Seconds()
+	attributes.ReferenceTime = timePtr(status.ReferenceTime)
+	attributes.T2 = timePtr(status.T2)
+	attributes.T3 = timePtr(status.T3)
+}
+
+func timePtr(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
@@ -2,9 +2,11 @@ package statusscore
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 	"time"
 
+	"go.ntppool.org/common/timeutil"
 	apiv2 "go.ntppool.org/monitor/gen/monitor/v2"
 	"go.ntppool.org/monitor/ntpdb"
 	"google.golang.org/protobuf/types/known/durationpb"
@@ -182,6 +184,334 @@ func TestSpecialCases(t *testing.T) {
 	}
 }
 
+func TestHeaderAttributes(t *testing.T) {
+	batchID := "01JZ7X8Q2M4N6P8R0T2V4W6Y8Z"
+	scorer := NewScorer().WithBatchID(batchID)
+	ctx := context.Background()
+	server := &ntpdb.Server{ID: 1}
+
+	refTime := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	t2 := refTime.Add(10 * time.Second)
+
+	status := &apiv2.ServerStatus{
+		Ts:             timestamppb.New(time.Now()),
+		Offset:         durationpb.New(time.Millisecond),
+		Rtt:            durationpb.New(10 * time.Millisecond),
+		Stratum:        2,
+		Precision:      durationpb.New(time.Microsecond),
+		ReferenceId:    0xc0000201, // 192.0.2.1
+		RootDelay:      durationpb.New(2 * time.Millisecond),
+		RootDispersion: durationpb.New(3 * time.Millisecond),
+		ReferenceTime:  timestamppb.New(refTime),
+		T2:             timestamppb.New(t2),
+	}
+
+	score, err := scorer.Score(ctx, server, status)
+	if err != nil {
+		t.Fatalf("Score() error = %v", err)
+	}
+
+	if !score.Attributes.Valid {
+		t.Fatalf("expected attributes to be set")
+	}
+
+	var attributes ntpdb.LogScoreAttributes
+	if err := json.Unmarshal([]byte(score.Attributes.String), &attributes); err != nil {
+		t.Fatalf("could not unmarshal attributes: %s", err)
+	}
+
+	if attributes.RefID != "192.0.2.1" {
+		t.Errorf("RefID = %q, want 192.0.2.1", attributes.RefID)
+	}
+	if abs(attributes.RootDelay-0.002) > 1e-9 || abs(attributes.RootDispersion-0.003) > 1e-9 {
+		t.Errorf("root delay/dispersion = %v/%v", attributes.RootDelay, attributes.RootDispersion)
+	}
+	if abs(attributes.Precision-0.000001) > 1e-12 {
+		t.Errorf("Precision = %v", attributes.Precision)
+	}
+	if attributes.ReferenceTime == nil || !attributes.ReferenceTime.Equal(refTime) {
+		t.Errorf("ReferenceTime = %v, want %v", attributes.ReferenceTime, refTime)
+	}
+	if attributes.T2 == nil || !attributes.T2.Equal(t2) {
+		t.Errorf("T2 = %v, want %v", attributes.T2, t2)
+	}
+	if attributes.T3 != nil {
+		t.Errorf("T3 = %v, want nil", attributes.T3)
+	}
+	if attributes.BatchID != batchID {
+		t.Errorf("BatchID = %q, want %q", attributes.BatchID, batchID)
+	}
+}
+
+func TestAppliedStepAttributes(t *testing.T) {
+	ctx := context.Background()
+	server := &ntpdb.Server{ID: 1}
+	defaults := DefaultSettings()
+
+	tests := []struct {
+		name      string
+		scorer    *StatusScorer
+		status    *apiv2.ServerStatus
+		scoreStep float64
+		maxScore  *float64
+	}{
+		{"timeout", NewScorer(), &apiv2.ServerStatus{NoResponse: true}, -5, nil},
+		{"weighted timeout", NewScorer().WithTimeoutWeight(0.2), &apiv2.ServerStatus{NoResponse: true}, -1, nil},
+		{"deny", NewScorer(), &apiv2.ServerStatus{Error: "DENY"}, defaults.DenyStep, &defaults.DenyMaxScore},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.status.Ts = timestamppb.New(time.Now())
+			score, err := tt.scorer.Score(ctx, server, tt.status)
+			if err != nil {
+				t.Fatalf("Score() error = %v", err)
+			}
+			if score.ScoreStep != tt.scoreStep {
+				t.Errorf("ScoreStep = %v, want %v", score.ScoreStep, tt.scoreStep)
+			}
+
+			var attributes ntpdb.LogScoreAttributes
+			if err := json.Unmarshal([]byte(score.Attributes.String), &attributes); err != nil {
+				t.Fatalf("invalid attributes %q: %v", score.Attributes.String, err)
+			}
+			if attributes.ScoreStep == nil || *attributes.ScoreStep != tt.scoreStep {
+				t.Errorf("score_step attribute in %s, want %v", score.Attributes.String, tt.scoreStep)
+			}
+			switch {
+			case tt.maxScore == nil && attributes.MaxScore != nil:
+				t.Errorf("max_score attribute in %s", score.Attributes.String)
+			case tt.maxScore != nil && (attributes.MaxScore == nil || *attributes.MaxScore != *tt.maxScore):
+				t.Errorf("max_score attribute in %s, want %v", score.Attributes.String, *tt.maxScore)
+			}
+		})
+	}
+}
+
+func TestSyncPenalties(t *testing.T) {
+	ctx := context.Background()
+	server := &ntpdb.Server{ID: 1}
+
+	newStatus := func(rootDistance, refAge time.Duration) *apiv2.ServerStatus {
+		return &apiv2.ServerStatus{
+			Ts:           timestamppb.New(time.Now()),
+			Offset:       durationpb.New(time.Millisecond),
+			Rtt:          durationpb.New(10 * time.Millisecond),
+			Stratum:      2,
+			RootDistance: durationpb.New(rootDistance),
+			ReferenceAge: durationpb.New(refAge),
+		}
+	}
+
+	tests := []struct {
+		name         string
+		settings     *Settings
+		rootDistance time.Duration
+		refAge       time.Duration
+		expectedStep float64
+		warning      bool
+	}{
+		{"synchronized", nil, 50 * time.Millisecond, 10 * time.Minute, 1, false},
+		{"root distance too high", nil, 2 * time.Second, 10 * time.Minute, -4, true},
+		{"reference time too old", nil, 50 * time.Millisecond, 8 * time.Hour, -2, true},
+		{"both", nil, 2 * time.Second, 8 * time.Hour, -4, true},
+		{
+			"configured thresholds",
+			func() *Settings {
+				settings := DefaultSettings()
+				settings.MaxRootDistance = timeutil.Duration{Duration: 500 * time.Millisecond}
+				settings.MaxReferenceAge = timeutil.Duration{Duration: time.Hour}
+				settings.ReferenceAgeStep = -3
+				return &settings
+			}(),
+			50 * time.Millisecond, 2 * time.Hour, -3, true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scorer := NewScorer()
+			if tt.settings != nil {
+				scorer = NewScorerWithSettings(*tt.settings)
+			}
+
+			score, err := scorer.Score(ctx, server, newStatus(tt.rootDistance, tt.refAge))
+			if err != nil {
+				t.Fatalf("Score() error = %v", err)
+			}
+			if score.Step != tt.expectedStep {
+				t.Errorf("Step = %v, want %v", score.Step, tt.expectedStep)
+			}
+
+			var attributes ntpdb.LogScoreAttributes
+			if score.Attributes.Valid {
+				if err := json.Unmarshal([]byte(score.Attributes.String), &attributes); err != nil {
+					t.Fatalf("could not unmarshal attributes: %s", err)
+				}
+			}
+			if hasWarning := len(attributes.Warning) > 0; hasWarning != tt.warning {
+				t.Errorf("warning = %q, expected warning: %t", attributes.Warning, tt.warning)
+			}
+		})
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	ctx := context.Background()
+	server := &ntpdb.Server{ID: 1}
+
+	settings, err := ParseSettings([]byte(`{
+		"version": 3,
+		"offset_steps": [
+			{"offset": "10ms", "step": 1},
+			{"offset": "110ms", "step": -1, "interpolate": true},
+			{"step": -3, "max_score": -10}
+		],
+		"timeout_step": -2,
+		"stratum0_step": 0
+	}`))
+	if err != nil {
+		t.Fatalf("ParseSettings() error = %v", err)
+	}
+	scorer := NewScorerWithSettings(settings)
+
+	tests := []struct {
+		name     string
+		status   *apiv2.ServerStatus
+		step     float64
+		maxScore float64
+	}{
+		{"in range", &apiv2.ServerStatus{Offset: durationpb.New(5 * time.Millisecond), Stratum: 2}, 1, 0},
+		{"interpolated", &apiv2.ServerStatus{Offset: durationpb.New(60 * time.Millisecond), Stratum: 2}, 0, 0},
+		{"out of range", &apiv2.ServerStatus{Offset: durationpb.New(time.Second), Stratum: 2}, -3, -10},
+		{"timeout", &apiv2.ServerStatus{NoResponse: true}, -2, 0},
+		{"default rate limit step", &apiv2.ServerStatus{Error: "RATE"}, -3.5, 0},
+		{"zero stratum 0 step", &apiv2.ServerStatus{Stratum: 0, Offset: durationpb.New(5 * time.Millisecond)}, 0, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.status.Ts = timestamppb.New(time.Now())
+			tt.status.Rtt = durationpb.New(10 * time.Millisecond)
+
+			score, err := scorer.Score(ctx, server, tt.status)
+			if err != nil {
+				t.Fatalf("Score() error = %v", err)
+			}
+			if abs(score.Step-tt.step) > 1e-9 {
+				t.Errorf("Step = %v, want %v", score.Step, tt.step)
+			}
+			if score.HasMaxScore != (tt.maxScore != 0) || score.MaxScore != tt.maxScore {
+				t.Errorf("MaxScore = %v (%t), want %v", score.MaxScore, score.HasMaxScore, tt.maxScore)
+			}
+
+			var attributes ntpdb.LogScoreAttributes
+			if !score.Attributes.Valid {
+				t.Fatalf("expected attributes to be set")
+			}
+			if err := json.Unmarshal([]byte(score.Attributes.String), &attributes); err != nil {
+				t.Fatalf("could not unmarshal attributes: %s", err)
+			}
+			if attributes.PolicyVersion != 3 {
+				t.Errorf("PolicyVersion = %d, want 3", attributes.PolicyVersion)
+			}
+		})
+	}
+}
+
+func TestParseSettings(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		ok   bool
+	}{
+		{"defaults", `{"version": 1}`, true},
+		{"no version", `{"max_loss_rate": 0.2}`, false},
+		{"unordered", `{"version": 1, "offset_steps": [{"offset": "100ms", "step": 1}, {"offset": "50ms", "step": 0}, {"step": -4}]}`, false},
+		{"no catch-all", `{"version": 1, "offset_steps": [{"offset": "100ms", "step": 1}]}`, false},
+		{"interpolate first", `{"version": 1, "offset_steps": [{"offset": "100ms", "step": 1, "interpolate": true}, {"step": -4}]}`, false},
+		{"step too high", `{"version": 1, "offset_steps": [{"offset": "100ms", "step": 2}, {"step": -4}]}`, false},
+		{"timeout step too high", `{"version": 1, "timeout_step": 5}`, false},
+		{"timeout step too low", `{"version": 1, "timeout_step": -500}`, false},
+		{"loss step too high", `{"version": 1, "loss_step": 2}`, false},
+		{"jitter step too low", `{"version": 1, "jitter_step": -101}`, false},
+		{"zero steps", `{"version": 1, "rate_limit_step": 0, "loss_step": 0}`, true},
+		{"invalid json", `{"version": `, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSettings([]byte(tt.json))
+			if ok := err == nil; ok != tt.ok {
+				t.Errorf("ParseSettings() error = %v, expected ok: %t", err, tt.ok)
+			}
+		})
+	}
+
+	defaults := DefaultSettings()
+	if err := defaults.Validate(); err != nil {
+		t.Errorf("default settings aren't valid: %s", err)
+	}
+}
+
+func TestSamplePenalties(t *testing.T) {
+	scorer := NewScorer()
+	ctx := context.Background()
+	server := &ntpdb.Server{ID: 1}
+
+	sample := func(offsetMs int, outlier bool) *apiv2.Sample {
+		return &apiv2.Sample{
+			Offset:  durationpb.New(time.Duration(offsetMs) * time.Millisecond),
+			Rtt:     durationpb.New(10 * time.Millisecond),
+			Outlier: outlier,
+		}
+	}
+	lost := &apiv2.Sample{NoResponse: true, Error: "network: i/o timeout"}
+
+	tests := []struct {
+		name         string
+		samples      []*apiv2.Sample
+		expectedStep float64
+		lossRate     float64
+	}{
+		{"all good", []*apiv2.Sample{sample(1, false), sample(2, false), sample(1, false)}, 1, 0},
+		{"one lost", []*apiv2.Sample{sample(1, false), lost, sample(2, false)}, 1, 1.0 / 3},
+		{"two lost", []*apiv2.Sample{lost, sample(1, false), lost}, -1, 2.0 / 3},
+		{"jitter", []*apiv2.Sample{sample(1, false), sample(120, false), sample(-90, false)}, 0, 0},
+		{"outlier ignored for jitter", []*apiv2.Sample{sample(1, false), sample(500, true), sample(3, false)}, 1, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := &apiv2.ServerStatus{
+				Ts:      timestamppb.New(time.Now()),
+				Offset:  durationpb.New(time.Millisecond),
+				Rtt:     durationpb.New(10 * time.Millisecond),
+				Stratum: 2,
+				Samples: tt.samples,
+			}
+
+			score, err := scorer.Score(ctx, server, status)
+			if err != nil {
+				t.Fatalf("Score() error = %v", err)
+			}
+			if score.Step != tt.expectedStep {
+				t.Errorf("Step = %v, want %v", score.Step, tt.expectedStep)
+			}
+
+			var attributes ntpdb.LogScoreAttributes
+			if score.Attributes.Valid {
+				if err := json.Unmarshal([]byte(score.Attributes.String), &attributes); err != nil {
+					t.Fatalf("could not unmarshal attributes: %s", err)
+				}
+			}
+			if abs(attributes.LossRate-tt.lossRate) > 0.001 {
+				t.Errorf("LossRate = %v, want %v", attributes.LossRate, tt.lossRate)
+			}
+		})
+	}
+}
+
 // Helper function for absolute value
 func abs(x float64) float64 {
 	if x < 0 {
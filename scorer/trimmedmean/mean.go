@@ -0,0 +1,91 @@
+// Package trimmedmean scores a server with the mean of the recent
+// scores from each monitor, leaving out the highest and lowest
+// scores so a few broken monitors don't move the score.
+package trimmedmean
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"go.ntppool.org/common/logger"
+	"go.ntppool.org/monitor/ntpdb"
+	"go.ntppool.org/monitor/scorer/score"
+)
+
+// trim is the fraction of the scores left out at each end
+const trim = 0.2
+
+type TrimmedMean struct {
+	scorerID uint32
+	lookback score.Lookback
+}
+
+func New() *TrimmedMean {
+	return &TrimmedMean{lookback: score.DefaultLookback()}
+}
+
+func (s *TrimmedMean) Setup(id uint32) {
+	s.scorerID = id
+}
+
+func (s *TrimmedMean) SetLookback(lookback score.Lookback) {
+	s.lookback = lookback
+}
+
+func (s *TrimmedMean) Score(ctx context.Context, db *ntpdb.Queries, serverScore ntpdb.ServerScore, latest ntpdb.LogScore) (score.Score, error) {
+	log := logger.FromContext(ctx)
+
+	if s.scorerID == 0 {
+		return score.Score{}, fmt.Errorf("TrimmedMean not Setup()")
+	}
+
+	recent, err := s.lookback.RecentScores(ctx, db, serverScore.ServerID, latest.Ts)
+	if err != nil {
+		return score.Score{}, err
+	}
+	if len(recent) == 0 {
+		return score.Score{}, fmt.Errorf("no recent scores found for %d", serverScore.ServerID)
+	}
+
+	scoreRaw, step := mean(recent)
+
+	attributes := ntpdb.LogScoreAttributes{
+		FromLSID: int(latest.ID),
+		FromSSID: int(serverScore.ID),
+	}
+	b, err := json.Marshal(attributes)
+	if err != nil {
+		log.Error("could not marshal attributes", "attributes", attributes, "err", err)
+	}
+
+	return score.Score{
+		LogScore: ntpdb.LogScore{
+			ServerID:   serverScore.ServerID,
+			MonitorID:  sql.NullInt32{Valid: true, Int32: int32(s.scorerID)},
+			Ts:         latest.Ts,
+			Step:       step,
+			Score:      scoreRaw,
+			Attributes: sql.NullString{String: string(b), Valid: true},
+		},
+	}, nil
+}
+
+// mean returns the trimmed mean of the scores and the mean step of
+// the same log scores.
+func mean(recent []ntpdb.LogScore) (float64, float64) {
+	recent = score.SortByScore(recent)
+
+	n := int(float64(len(recent)) * trim)
+	recent = recent[n : len(recent)-n]
+
+	var scoreSum, stepSum float64
+	for _, ls := range recent {
+		scoreSum += ls.Score
+		stepSum += ls.Step
+	}
+
+	count := float64(len(recent))
+	return scoreSum / count, stepSum / count
+}
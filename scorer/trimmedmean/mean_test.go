@@ -0,0 +1,41 @@
+package trimmedmean
+
+import (
+	"math"
+	"testing"
+
+	"go.ntppool.org/monitor/ntpdb"
+)
+
+func TestMean(t *testing.T) {
+	scores := func(s ...float64) []ntpdb.LogScore {
+		r := []ntpdb.LogScore{}
+		for _, v := range s {
+			r = append(r, ntpdb.LogScore{Score: v, Step: v / 20})
+		}
+		return r
+	}
+
+	tests := []struct {
+		name   string
+		scores []ntpdb.LogScore
+		want   float64
+	}{
+		{"single", scores(15), 15},
+		{"too few to trim", scores(10, 20), 15},
+		{"outliers trimmed", scores(-100, 18, 19, 20, 20), 19},
+		{"both ends", scores(20, -50, 18, 19, 17, 16, 100, 15, 18, 17), 17.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, step := mean(tt.scores)
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("mean = %f, want %f", got, tt.want)
+			}
+			if math.Abs(step-tt.want/20) > 1e-9 {
+				t.Errorf("step = %f, want %f", step, tt.want/20)
+			}
+		})
+	}
+}
@@ -12,3 +12,15 @@ type Scorer interface {
 	Setup(id uint32)
 	Score(ctx context.Context, db *ntpdb.Queries, serverScore ntpdb.ServerScore, ls ntpdb.LogScore) (score.Score, error)
 }
+
+// DecayScorer is implemented by scorers that keep a running score
+// with the configured time based decay
+type DecayScorer interface {
+	SetDecay(score.Decay)
+}
+
+// LookbackScorer is implemented by scorers that use the configured
+// lookback windows for the recent scores from each monitor
+type LookbackScorer interface {
+	SetLookback(score.Lookback)
+}
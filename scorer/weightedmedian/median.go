@@ -0,0 +1,110 @@
+// Package weightedmedian scores a server with the median of the
+// recent scores from each monitor, weighted by the round trip time
+// so monitors close to the server count more.
+package weightedmedian
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.ntppool.org/common/logger"
+	"go.ntppool.org/monitor/ntpdb"
+	"go.ntppool.org/monitor/scorer/score"
+)
+
+// rttReference is the round trip time at which a monitor has half
+// the weight of a monitor right next to the server. Scores without
+// an RTT (no response is stored as zero) get the weight for
+// rttReference.
+const rttReference = 50 * time.Millisecond
+
+type WeightedMedian struct {
+	scorerID uint32
+	lookback score.Lookback
+}
+
+func New() *WeightedMedian {
+	return &WeightedMedian{lookback: score.DefaultLookback()}
+}
+
+func (s *WeightedMedian) Setup(id uint32) {
+	s.scorerID = id
+}
+
+func (s *WeightedMedian) SetLookback(lookback score.Lookback) {
+	s.lookback = lookback
+}
+
+func (s *WeightedMedian) Score(ctx context.Context, db *ntpdb.Queries, serverScore ntpdb.ServerScore, latest ntpdb.LogScore) (score.Score, error) {
+	log := logger.FromContext(ctx)
+
+	if s.scorerID == 0 {
+		return score.Score{}, fmt.Errorf("WeightedMedian not Setup()")
+	}
+
+	recent, err := s.lookback.RecentScores(ctx, db, serverScore.ServerID, latest.Ts)
+	if err != nil {
+		return score.Score{}, err
+	}
+	if len(recent) == 0 {
+		return score.Score{}, fmt.Errorf("no recent scores found for %d", serverScore.ServerID)
+	}
+
+	ls := median(recent)
+
+	attributes := ntpdb.LogScoreAttributes{}
+	if ls.Attributes.Valid {
+		if err := json.Unmarshal([]byte(ls.Attributes.String), &attributes); err != nil {
+			return score.Score{}, err
+		}
+	}
+	attributes.FromLSID = int(latest.ID)
+	attributes.FromSSID = int(serverScore.ID)
+	b, err := json.Marshal(attributes)
+	if err != nil {
+		log.Error("could not marshal attributes", "attributes", attributes, "err", err)
+	}
+
+	return score.Score{
+		LogScore: ntpdb.LogScore{
+			ServerID:   ls.ServerID,
+			MonitorID:  sql.NullInt32{Valid: true, Int32: int32(s.scorerID)},
+			Ts:         latest.Ts,
+			Step:       ls.Step,
+			Score:      ls.Score,
+			Attributes: sql.NullString{String: string(b), Valid: true},
+		},
+	}, nil
+}
+
+// weight is how much the score counts in the median
+func weight(ls ntpdb.LogScore) float64 {
+	rtt := rttReference
+	if ls.Rtt.Valid && ls.Rtt.Int32 > 0 {
+		// the RTT is stored in microseconds
+		rtt = time.Duration(ls.Rtt.Int32) * time.Microsecond
+	}
+	return float64(rttReference) / float64(rttReference+rtt)
+}
+
+// median returns the log score at the weighted median
+func median(recent []ntpdb.LogScore) ntpdb.LogScore {
+	recent = score.SortByScore(recent)
+
+	total := 0.0
+	for _, ls := range recent {
+		total += weight(ls)
+	}
+
+	sum := 0.0
+	for _, ls := range recent {
+		sum += weight(ls)
+		if sum >= total/2 {
+			return ls
+		}
+	}
+	return recent[len(recent)-1]
+}
@@ -0,0 +1,35 @@
+package weightedmedian
+
+import (
+	"database/sql"
+	"testing"
+
+	"go.ntppool.org/monitor/ntpdb"
+)
+
+func TestMedian(t *testing.T) {
+	ls := func(score float64, rttMs int32) ntpdb.LogScore {
+		return ntpdb.LogScore{Score: score, Rtt: sql.NullInt32{Int32: rttMs * 1000, Valid: true}}
+	}
+
+	tests := []struct {
+		name   string
+		scores []ntpdb.LogScore
+		want   float64
+	}{
+		{"single", []ntpdb.LogScore{ls(15, 10)}, 15},
+		{"equal rtt", []ntpdb.LogScore{ls(20, 50), ls(-10, 50), ls(15, 50)}, 15},
+		// the nearby monitors outweigh the two far away ones
+		{"nearby monitors", []ntpdb.LogScore{ls(-10, 300), ls(-5, 300), ls(18, 5), ls(19, 5)}, 18},
+		// a timeout (no RTT) counts like a monitor at the reference RTT
+		{"no response", []ntpdb.LogScore{ls(-5, 0), ls(20, 50)}, -5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := median(tt.scores); got.Score != tt.want {
+				t.Errorf("median = %f, want %f", got.Score, tt.want)
+			}
+		})
+	}
+}
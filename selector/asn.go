@@ -0,0 +1,18 @@
+package selector
+
+import (
+	"strconv"
+	"strings"
+)
+
+// lookupASN returns the AS number for the IP address from the ASN
+// database, or 0 if it's unknown. Both "64500" and "AS64500" are
+// accepted; 0 is used for addresses that aren't routed.
+func (sl *Selector) lookupASN(ip string) uint32 {
+	value := strings.TrimPrefix(strings.ToUpper(sl.asn.lookup(ip)), "AS")
+	asn, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return 0
+	}
+	return uint32(asn)
+}
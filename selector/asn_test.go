@@ -0,0 +1,98 @@
+package selector
+
+import (
+	"database/sql"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"go.ntppool.org/monitor/ntpdb"
+)
+
+// testASNDB is in the iptoasn.com format
+const testASNDB = "192.0.2.0\t192.0.2.255\t64500\tUS\tEXAMPLE-NET\n" +
+	"198.51.100.0\t198.51.100.255\t64501\tDE\tOTHER-NET, Inc\n" +
+	"203.0.113.0\t203.0.113.255\t0\tNone\tNot routed\n" +
+	"2001:db8::\t2001:db8:ffff:ffff:ffff:ffff:ffff:ffff\tAS64500\tUS\tEXAMPLE-NET\n"
+
+func TestLookupASN(t *testing.T) {
+	db, err := parseIPRangeDB(strings.NewReader(testASNDB))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sl := &Selector{log: slog.Default(), asn: db}
+
+	tests := map[string]uint32{
+		"192.0.2.10":    64500,
+		"198.51.100.10": 64501,
+		"203.0.113.10":  0,
+		"2001:db8::1":   64500,
+		"10.0.0.1":      0,
+	}
+	for ip, want := range tests {
+		if got := sl.lookupASN(ip); got != want {
+			t.Errorf("lookupASN(%q) = %d, want %d", ip, got, want)
+		}
+	}
+
+	sl.asn = nil
+	if got := sl.lookupASN("192.0.2.10"); got != 0 {
+		t.Errorf("lookupASN without a database = %d", got)
+	}
+}
+
+func TestASNConstraints(t *testing.T) {
+	db, err := parseIPRangeDB(strings.NewReader(testASNDB))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sl := &Selector{log: slog.Default(), asn: db}
+
+	// different subnets, same autonomous system
+	if err := sl.checkASNConstraint("192.0.2.10", "192.0.2.200"); err == nil {
+		t.Errorf("monitor in the same AS as the server should be a violation")
+	}
+	if err := sl.checkASNConstraint("198.51.100.10", "192.0.2.200"); err != nil {
+		t.Errorf("different AS: %s", err)
+	}
+	if err := sl.checkASNConstraint("10.0.0.1", "10.0.0.2"); err != nil {
+		t.Errorf("unknown AS: %s", err)
+	}
+
+	row := func(id uint32, ip string, status ntpdb.ServerScoresStatus, priority int32) ntpdb.GetMonitorPriorityRow {
+		return ntpdb.GetMonitorPriorityRow{
+			ID:              id,
+			MonitorIp:       sql.NullString{String: ip, Valid: true},
+			MonitorPriority: priority,
+			Status:          ntpdb.NullServerScoresStatus{ServerScoresStatus: status, Valid: true},
+		}
+	}
+
+	monitors := []ntpdb.GetMonitorPriorityRow{
+		row(1, "192.0.2.1", ntpdb.ServerScoresStatusTesting, 5),
+		row(2, "192.0.2.2", ntpdb.ServerScoresStatusActive, 20),
+		row(3, "192.0.2.3", ntpdb.ServerScoresStatusActive, 10),
+		row(4, "198.51.100.1", ntpdb.ServerScoresStatusActive, 30),
+		row(5, "192.0.2.5", ntpdb.ServerScoresStatusCandidate, 1),
+	}
+
+	violations := sl.checkASNConstraintsIterative(monitors)
+	if len(violations) != 1 {
+		t.Fatalf("got %d violations, want 1: %+v", len(violations), violations)
+	}
+	if v := violations[1]; v == nil || v.Type != violationASNDiversity {
+		t.Errorf("the testing monitor should be over the limit, got %+v", v)
+	}
+
+	candidate := &monitorCandidate{ID: 5, IP: "192.0.2.5"}
+	if err := sl.checkASNDiversityConstraint(candidate, monitors[1:], ntpdb.ServerScoresStatusTesting); err == nil {
+		t.Errorf("promotion should be over the AS limit")
+	}
+	if err := sl.checkASNDiversityConstraint(candidate, monitors[1:], ntpdb.ServerScoresStatusCandidate); err != nil {
+		t.Errorf("candidates aren't limited: %s", err)
+	}
+	candidate = &monitorCandidate{ID: 5, IP: "198.51.100.5"}
+	if err := sl.checkASNDiversityConstraint(candidate, monitors, ntpdb.ServerScoresStatusActive); err != nil {
+		t.Errorf("second monitor in the AS: %s", err)
+	}
+}
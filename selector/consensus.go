@@ -0,0 +1,65 @@
+package selector
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"time"
+
+	"go.ntppool.org/monitor/ntpdb"
+	"go.ntppool.org/monitor/scorer/consensus"
+)
+
+const (
+	// consensusMaxAge is how old the consensus offset from the
+	// scorer can be and still be used
+	consensusMaxAge = time.Hour
+
+	// maxOffsetBias is how far (in seconds) the average offset a
+	// monitor measures can be from the consensus before the monitor
+	// is unhealthy; it also has to be well outside the spread.
+	maxOffsetBias     = 0.025
+	offsetBiasSpreads = 3
+)
+
+// loadServerOffset returns the consensus offset for the server from
+// the consensus scorer, or nil if there isn't a recent one from
+// enough monitors.
+func (sl *Selector) loadServerOffset(ctx context.Context, db ntpdb.QuerierTx, serverID uint32) (*ntpdb.ServerOffset, error) {
+	offset, err := db.GetServerOffset(ctx, serverID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if time.Since(offset.Ts) > consensusMaxAge || offset.Monitors < consensus.MinMonitors {
+		return nil, nil
+	}
+	return &offset, nil
+}
+
+// applyOffsetBias sets how far the offsets the monitor measured are
+// from the consensus and marks monitors with a large bias unhealthy.
+func applyOffsetBias(monitor *monitorCandidate, row ntpdb.GetMonitorPriorityRow, consensus *ntpdb.ServerOffset) {
+	if consensus == nil {
+		return
+	}
+
+	avg, ok := row.AvgOffset.([]uint8)
+	if !ok {
+		return
+	}
+	x := sql.NullFloat64{}
+	if err := x.Scan(avg); err != nil || !x.Valid {
+		return
+	}
+
+	bias := x.Float64 - consensus.Offset
+	monitor.OffsetBias = &bias
+
+	if math.Abs(bias) > maxOffsetBias && math.Abs(bias) > offsetBiasSpreads*consensus.Spread {
+		monitor.IsHealthy = false
+	}
+}
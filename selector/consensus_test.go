@@ -0,0 +1,70 @@
+package selector
+
+import (
+	"testing"
+
+	"go.ntppool.org/monitor/ntpdb"
+)
+
+func TestApplyOffsetBias(t *testing.T) {
+	consensus := &ntpdb.ServerOffset{Offset: 0.003, Spread: 0.002, Monitors: 7}
+
+	tests := []struct {
+		name      string
+		avgOffset interface{}
+		consensus *ntpdb.ServerOffset
+		bias      *float64
+		healthy   bool
+	}{
+		{
+			name:      "no_consensus",
+			avgOffset: []uint8("0.100"),
+			healthy:   true,
+		},
+		{
+			name:      "no_offsets",
+			consensus: consensus,
+			healthy:   true,
+		},
+		{
+			name:      "close",
+			avgOffset: []uint8("0.004"),
+			consensus: consensus,
+			bias:      floatPtr(0.001),
+			healthy:   true,
+		},
+		{
+			name:      "biased",
+			avgOffset: []uint8("0.053"),
+			consensus: consensus,
+			bias:      floatPtr(0.05),
+			healthy:   false,
+		},
+		{
+			name:      "within_spread",
+			avgOffset: []uint8("0.053"),
+			consensus: &ntpdb.ServerOffset{Offset: 0.003, Spread: 0.02, Monitors: 7},
+			bias:      floatPtr(0.05),
+			healthy:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			monitor := monitorCandidate{IsHealthy: true}
+			applyOffsetBias(&monitor, ntpdb.GetMonitorPriorityRow{AvgOffset: tt.avgOffset}, tt.consensus)
+
+			if monitor.IsHealthy != tt.healthy {
+				t.Errorf("healthy %t, want %t", monitor.IsHealthy, tt.healthy)
+			}
+			switch {
+			case tt.bias == nil && monitor.OffsetBias != nil:
+				t.Errorf("bias %f, want none", *monitor.OffsetBias)
+			case tt.bias != nil && monitor.OffsetBias == nil:
+				t.Errorf("no bias, want %f", *tt.bias)
+			case tt.bias != nil && (*monitor.OffsetBias-*tt.bias > 1e-9 || *tt.bias-*monitor.OffsetBias > 1e-9):
+				t.Errorf("bias %f, want %f", *monitor.OffsetBias, *tt.bias)
+			}
+		})
+	}
+}
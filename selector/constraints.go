@@ -4,12 +4,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/netip"
+	"slices"
 	"time"
 
 	"go.ntppool.org/monitor/ntpdb"
 )
 
-// Hardcoded constraint values
+// Default constraint values; see Settings
 const (
 	defaultSubnetV4              = 24 // IPv4 subnet constraint (/24)
 	defaultSubnetV6              = 48 // IPv6 subnet constraint (/48)
@@ -19,6 +20,15 @@ const (
 	diversitySubnetV4 = 20 // IPv4 network diversity constraint (/20)
 	diversitySubnetV6 = 44 // IPv6 network diversity constraint (/44)
 
+	// Geographic diversity constraints (active monitors); defaults
+	// for the settings
+	maxActivePerContinent = 5 // At least two of the seven active monitors elsewhere
+	maxActivePerCountry   = 4
+
+	// ASN diversity constraint (active and testing monitors); default
+	// for the settings
+	maxPerASN = 2
+
 	// Constraint resolution intervals
 	constraintRecheckInterval = 8 * time.Hour   // Network/account constraints might resolve
 	excessRecheckInterval     = 120 * time.Hour // Excess candidates (5 days for system stability)
@@ -51,6 +61,7 @@ const (
 // an unchangeable constraint that should result in paused status
 func isUnchangeableConstraint(violationType constraintViolationType) bool {
 	return violationType == violationNetworkSameSubnet ||
+		violationType == violationNetworkSameASN ||
 		violationType == violationAccount
 }
 
@@ -80,9 +91,9 @@ func (sl *Selector) checkNetworkConstraint(
 
 	var prefixLen int
 	if mAddr.Is4() {
-		prefixLen = defaultSubnetV4
+		prefixLen = sl.config().SubnetV4
 	} else {
-		prefixLen = defaultSubnetV6
+		prefixLen = sl.config().SubnetV6
 	}
 
 	// Check if in same subnet
@@ -118,9 +129,9 @@ func (sl *Selector) checkNetworkDiversityConstraint(
 	// Determine diversity prefix length
 	var diversityPrefixLen int
 	if candidateAddr.Is4() {
-		diversityPrefixLen = diversitySubnetV4
+		diversityPrefixLen = sl.config().DiversitySubnetV4
 	} else {
-		diversityPrefixLen = diversitySubnetV6
+		diversityPrefixLen = sl.config().DiversitySubnetV6
 	}
 
 	// Get candidate's diversity network
@@ -292,6 +303,29 @@ func (sl *Selector) checkConstraints(
 		return violation
 	}
 
+	// Check ASN constraint (same autonomous system as the server)
+	if err := sl.checkASNConstraint(monitor.IP, server.IP); err != nil {
+		violation := &constraintViolation{
+			Type:    violationNetworkSameASN,
+			Details: err.Error(),
+		}
+		// If we have a stored violation of the same type, preserve the timestamp
+		if monitor.ConstraintViolationType != nil &&
+			*monitor.ConstraintViolationType == string(violationNetworkSameASN) &&
+			monitor.ConstraintViolationSince != nil {
+			violation.Since = *monitor.ConstraintViolationSince
+		} else {
+			violation.Since = time.Now()
+		}
+
+		// Track constraint violation in metrics
+		if sl.metrics != nil {
+			sl.metrics.TrackConstraintViolation(monitor, violation.Type, server.ID, false)
+		}
+
+		return violation
+	}
+
 	// Check account constraints
 	if err := sl.checkAccountConstraints(monitor, server, accountLimits, targetState); err != nil {
 		// Determine specific violation type
@@ -347,6 +381,52 @@ func (sl *Selector) checkConstraints(
 		return violation
 	}
 
+	// Check ASN diversity constraints
+	if err := sl.checkASNDiversityConstraint(monitor, existingMonitors, targetState); err != nil {
+		violation := &constraintViolation{
+			Type:    violationASNDiversity,
+			Details: err.Error(),
+		}
+		// If we have a stored violation of the same type, preserve the timestamp
+		if monitor.ConstraintViolationType != nil &&
+			*monitor.ConstraintViolationType == string(violationASNDiversity) &&
+			monitor.ConstraintViolationSince != nil {
+			violation.Since = *monitor.ConstraintViolationSince
+		} else {
+			violation.Since = time.Now()
+		}
+
+		// Track constraint violation in metrics
+		if sl.metrics != nil {
+			sl.metrics.TrackConstraintViolation(monitor, violation.Type, server.ID, false)
+		}
+
+		return violation
+	}
+
+	// Check geographic diversity constraints
+	if err := sl.checkGeoDiversityConstraint(monitor, existingMonitors, targetState); err != nil {
+		violation := &constraintViolation{
+			Type:    violationGeoDiversity,
+			Details: err.Error(),
+		}
+		// If we have a stored violation of the same type, preserve the timestamp
+		if monitor.ConstraintViolationType != nil &&
+			*monitor.ConstraintViolationType == string(violationGeoDiversity) &&
+			monitor.ConstraintViolationSince != nil {
+			violation.Since = *monitor.ConstraintViolationSince
+		} else {
+			violation.Since = time.Now()
+		}
+
+		// Track constraint violation in metrics
+		if sl.metrics != nil {
+			sl.metrics.TrackConstraintViolation(monitor, violation.Type, server.ID, false)
+		}
+
+		return violation
+	}
+
 	return &constraintViolation{
 		Type: violationNone,
 	}
@@ -547,6 +627,29 @@ func (sl *Selector) checkNonAccountConstraints(
 		return violation
 	}
 
+	// Check ASN constraint (same autonomous system as the server)
+	if err := sl.checkASNConstraint(monitor.IP, server.IP); err != nil {
+		violation := &constraintViolation{
+			Type:    violationNetworkSameASN,
+			Details: err.Error(),
+		}
+		// If we have a stored violation of the same type, preserve the timestamp
+		if monitor.ConstraintViolationType != nil &&
+			*monitor.ConstraintViolationType == string(violationNetworkSameASN) &&
+			monitor.ConstraintViolationSince != nil {
+			violation.Since = *monitor.ConstraintViolationSince
+		} else {
+			violation.Since = time.Now()
+		}
+
+		// Track constraint violation in metrics
+		if sl.metrics != nil {
+			sl.metrics.TrackConstraintViolation(monitor, violation.Type, server.ID, false)
+		}
+
+		return violation
+	}
+
 	// Check same account constraint (not account limits)
 	if monitor.AccountID != nil && server.AccountID != nil {
 		if *monitor.AccountID == *server.AccountID {
@@ -703,9 +806,9 @@ func (sl *Selector) shouldCheckConstraintResolution(monitor monitorCandidate, pa
 
 	switch pauseReasonValue {
 	case pauseConstraintViolation:
-		return timeSinceLastCheck > constraintRecheckInterval // 8 hours
+		return timeSinceLastCheck > sl.config().ConstraintRecheck.Duration // 8 hours by default
 	case pauseExcess:
-		return timeSinceLastCheck > excessRecheckInterval // 120 hours
+		return timeSinceLastCheck > sl.config().ExcessRecheck.Duration // 120 hours by default
 	}
 
 	return false
@@ -736,6 +839,8 @@ func (sl *Selector) checkConstraintResolution(
 	switch violationType {
 	case violationNetworkSameSubnet:
 		return sl.checkNetworkConstraint(monitor.IP, server.IP) == nil
+	case violationNetworkSameASN:
+		return sl.checkASNConstraint(monitor.IP, server.IP) == nil
 	case violationAccount:
 		return !(monitor.AccountID != nil && server.AccountID != nil &&
 			*monitor.AccountID == *server.AccountID)
@@ -743,3 +848,233 @@ func (sl *Selector) checkConstraintResolution(
 
 	return false
 }
+
+// checkGeoDiversityConstraint verifies that promoting the monitor to
+// active doesn't put too many active monitors on one continent or in
+// one country. Monitors without a known location aren't limited.
+func (sl *Selector) checkGeoDiversityConstraint(
+	monitor *monitorCandidate,
+	existingMonitors []ntpdb.GetMonitorPriorityRow,
+	targetState ntpdb.ServerScoresStatus,
+) error {
+	if targetState != ntpdb.ServerScoresStatusActive {
+		return nil
+	}
+
+	geo := sl.monitorGeo(monitor.Location, monitor.IP)
+	if geo.Country == "" {
+		return nil
+	}
+
+	continentCount := 0
+	countryCount := 0
+	for _, existing := range existingMonitors {
+		if existing.ID == monitor.ID {
+			continue
+		}
+		if !existing.Status.Valid || existing.Status.ServerScoresStatus != ntpdb.ServerScoresStatusActive {
+			continue
+		}
+		existingGeo := sl.monitorGeo(existing.Location, existing.MonitorIp.String)
+		if existingGeo.Continent == geo.Continent {
+			continentCount++
+		}
+		if existingGeo.Country == geo.Country {
+			countryCount++
+		}
+	}
+
+	settings := sl.config()
+	if countryCount >= settings.MaxActivePerCountry {
+		return fmt.Errorf("%d active monitors already in %s", countryCount, geo.Country)
+	}
+	if geo.Continent != "" && continentCount >= settings.MaxActivePerContinent {
+		return fmt.Errorf("%d active monitors already in %s", continentCount, geo.Continent)
+	}
+
+	return nil
+}
+
+// checkGeoConstraintsIterative finds the active monitors over the
+// per-continent and per-country limits. Like the account limits, only
+// the worst performing monitors over the limit are flagged.
+func (sl *Selector) checkGeoConstraintsIterative(
+	monitors []ntpdb.GetMonitorPriorityRow,
+) map[uint32]*constraintViolation {
+	violations := make(map[uint32]*constraintViolation)
+
+	type monitorInfo struct {
+		row ntpdb.GetMonitorPriorityRow
+		geo geoInfo
+	}
+
+	var active []monitorInfo
+	for _, monitor := range monitors {
+		if !monitor.Status.Valid || monitor.Status.ServerScoresStatus != ntpdb.ServerScoresStatusActive {
+			continue
+		}
+		geo := sl.monitorGeo(monitor.Location, monitor.MonitorIp.String)
+		if geo.Country == "" {
+			continue
+		}
+		active = append(active, monitorInfo{row: monitor, geo: geo})
+	}
+
+	// best performers (lowest priority) first
+	slices.SortStableFunc(active, func(a, b monitorInfo) int {
+		return int(a.row.MonitorPriority) - int(b.row.MonitorPriority)
+	})
+
+	flag := func(mi monitorInfo, details string) {
+		violation := &constraintViolation{
+			Type:    violationGeoDiversity,
+			Details: details,
+		}
+		// Preserve existing violation timestamp if it exists
+		if mi.row.ConstraintViolationType.Valid &&
+			mi.row.ConstraintViolationType.String == string(violationGeoDiversity) &&
+			mi.row.ConstraintViolationSince.Valid {
+			violation.Since = mi.row.ConstraintViolationSince.Time
+		} else {
+			violation.Since = time.Now()
+		}
+		violations[mi.row.ID] = violation
+	}
+
+	settings := sl.config()
+	continentCounts := make(map[string]int)
+	countryCounts := make(map[string]int)
+	for _, mi := range active {
+		if countryCounts[mi.geo.Country] >= settings.MaxActivePerCountry {
+			flag(mi, fmt.Sprintf("more than %d active monitors in %s", settings.MaxActivePerCountry, mi.geo.Country))
+			continue
+		}
+		if mi.geo.Continent != "" && continentCounts[mi.geo.Continent] >= settings.MaxActivePerContinent {
+			flag(mi, fmt.Sprintf("more than %d active monitors in %s", settings.MaxActivePerContinent, mi.geo.Continent))
+			continue
+		}
+		countryCounts[mi.geo.Country]++
+		continentCounts[mi.geo.Continent]++
+	}
+
+	return violations
+}
+
+// checkASNConstraint verifies that the monitor isn't in the same
+// autonomous system as the server, so a provider doesn't monitor its
+// own servers from a different subnet
+func (sl *Selector) checkASNConstraint(monitorIP, serverIP string) error {
+	lookupASN := sl.lookupASN(monitorIP)
+	if lookupASN == 0 {
+		return nil
+	}
+	if lookupASN == sl.lookupASN(serverIP) {
+		return fmt.Errorf("monitor and server in same autonomous system (AS%d)", lookupASN)
+	}
+	return nil
+}
+
+// checkASNDiversityConstraint verifies that promoting the monitor to
+// testing or active doesn't put more than the MaxPerASN setting of
+// active and testing monitors in the same autonomous system
+func (sl *Selector) checkASNDiversityConstraint(
+	monitor *monitorCandidate,
+	existingMonitors []ntpdb.GetMonitorPriorityRow,
+	targetState ntpdb.ServerScoresStatus,
+) error {
+	if targetState != ntpdb.ServerScoresStatusActive &&
+		targetState != ntpdb.ServerScoresStatusTesting {
+		return nil
+	}
+
+	asn := sl.lookupASN(monitor.IP)
+	if asn == 0 {
+		return nil
+	}
+
+	count := 0
+	for _, existing := range existingMonitors {
+		if existing.ID == monitor.ID || !existing.Status.Valid {
+			continue
+		}
+		status := existing.Status.ServerScoresStatus
+		if status != ntpdb.ServerScoresStatusActive && status != ntpdb.ServerScoresStatusTesting {
+			continue
+		}
+		if sl.lookupASN(existing.MonitorIp.String) == asn {
+			count++
+		}
+	}
+
+	if count >= sl.config().MaxPerASN {
+		return fmt.Errorf("%d active or testing monitors already in AS%d", count, asn)
+	}
+	return nil
+}
+
+// checkASNConstraintsIterative finds the active and testing monitors
+// over the per-ASN limit. Active monitors are kept before testing
+// monitors, and then the best performing ones.
+func (sl *Selector) checkASNConstraintsIterative(
+	monitors []ntpdb.GetMonitorPriorityRow,
+) map[uint32]*constraintViolation {
+	violations := make(map[uint32]*constraintViolation)
+
+	type monitorInfo struct {
+		row ntpdb.GetMonitorPriorityRow
+		asn uint32
+	}
+
+	var assigned []monitorInfo
+	for _, monitor := range monitors {
+		if !monitor.Status.Valid {
+			continue
+		}
+		status := monitor.Status.ServerScoresStatus
+		if status != ntpdb.ServerScoresStatusActive && status != ntpdb.ServerScoresStatusTesting {
+			continue
+		}
+		asn := sl.lookupASN(monitor.MonitorIp.String)
+		if asn == 0 {
+			continue
+		}
+		assigned = append(assigned, monitorInfo{row: monitor, asn: asn})
+	}
+
+	slices.SortStableFunc(assigned, func(a, b monitorInfo) int {
+		aActive := a.row.Status.ServerScoresStatus == ntpdb.ServerScoresStatusActive
+		bActive := b.row.Status.ServerScoresStatus == ntpdb.ServerScoresStatusActive
+		if aActive != bActive {
+			if aActive {
+				return -1
+			}
+			return 1
+		}
+		return int(a.row.MonitorPriority) - int(b.row.MonitorPriority)
+	})
+
+	maxPerASN := sl.config().MaxPerASN
+	counts := make(map[uint32]int)
+	for _, mi := range assigned {
+		if counts[mi.asn] < maxPerASN {
+			counts[mi.asn]++
+			continue
+		}
+
+		violation := &constraintViolation{
+			Type:    violationASNDiversity,
+			Details: fmt.Sprintf("more than %d active or testing monitors in AS%d", maxPerASN, mi.asn),
+		}
+		// Preserve existing violation timestamp if it exists
+		if mi.row.ConstraintViolationType.Valid &&
+			mi.row.ConstraintViolationType.String == string(violationASNDiversity) &&
+			mi.row.ConstraintViolationSince.Valid {
+			violation.Since = mi.row.ConstraintViolationSince.Time
+		} else {
+			violation.Since = time.Now()
+		}
+		violations[mi.row.ID] = violation
+	}
+
+	return violations
+}
@@ -0,0 +1,181 @@
+package selector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.ntppool.org/monitor/ntpdb"
+)
+
+// maxNewCandidates is how many monitors are added as candidates
+// for a server in one review, so coverage grows gradually
+const maxNewCandidates = 5
+
+// discoverCandidates assigns live monitors that aren't yet assigned
+// to the server as candidates, up to the candidate target. Monitors
+// that would violate the network, account or diversity constraints
+// are skipped. Returns the number of monitors added.
+func (sl *Selector) discoverCandidates(
+	ctx context.Context,
+	db ntpdb.QuerierTx,
+	server *serverInfo,
+	assignedMonitors []ntpdb.GetMonitorPriorityRow,
+) (int, error) {
+	// all server_scores rows, including monitors that haven't
+	// submitted any results for the server yet
+	assigned, err := db.GetServerScoreMonitors(ctx, server.ID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get server score monitors: %w", err)
+	}
+
+	var active, testing, candidates int
+	accountCounts := make(map[uint32]int)
+	for _, row := range assigned {
+		switch row.Status {
+		case ntpdb.ServerScoresStatusActive:
+			active++
+		case ntpdb.ServerScoresStatusTesting:
+			testing++
+		case ntpdb.ServerScoresStatusCandidate:
+			candidates++
+		default:
+			continue // paused monitors don't count against the account
+		}
+		if row.AccountID.Valid {
+			accountCounts[uint32(row.AccountID.Int32)]++
+		}
+	}
+
+	needed := min(sl.calculateNeededCandidates(active, testing, candidates), maxNewCandidates)
+	if needed == 0 {
+		return 0, nil
+	}
+
+	available, err := db.GetAvailableMonitors(ctx, ntpdb.GetAvailableMonitorsParams{
+		IpVersion: ntpdb.NullMonitorsIpVersion{MonitorsIpVersion: ntpdb.MonitorsIpVersion(server.IPVersion), Valid: true},
+		ServerID:  server.ID,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get available monitors: %w", err)
+	}
+
+	added := 0
+	for _, row := range available {
+		if added >= needed {
+			break
+		}
+
+		monitor := convertAvailableMonitorToCandidate(row)
+
+		if reason := sl.checkDiscoveryConstraints(&monitor, server, assignedMonitors, accountCounts, row.AccountFlags); reason != "" {
+			sl.log.DebugContext(ctx, "skipping monitor for candidate discovery",
+				"serverID", server.ID,
+				"monitorID", monitor.ID,
+				"reason", reason)
+			continue
+		}
+
+		err := db.InsertServerScore(ctx, ntpdb.InsertServerScoreParams{
+			MonitorID: monitor.ID,
+			ServerID:  server.ID,
+			ScoreRaw:  0,
+			CreatedOn: time.Now(),
+		})
+		if err != nil {
+			return added, fmt.Errorf("failed to insert server score: %w", err)
+		}
+
+		if monitor.AccountID != nil {
+			accountCounts[*monitor.AccountID]++
+		}
+		added++
+
+		if sl.metrics != nil {
+			sl.metrics.TrackStatusChange(&monitor, "new", ntpdb.ServerScoresStatusCandidate, server.ID, "new candidate")
+		}
+
+		sl.log.InfoContext(ctx, "added candidate monitor",
+			"serverID", server.ID,
+			"monitorID", monitor.ID,
+			"globalStatus", monitor.GlobalStatus)
+	}
+
+	return added, nil
+}
+
+// checkDiscoveryConstraints returns why the monitor can't be added as
+// a candidate for the server, or an empty string if it can. Candidates
+// that could never be promoted to testing aren't added.
+func (sl *Selector) checkDiscoveryConstraints(
+	monitor *monitorCandidate,
+	server *serverInfo,
+	assignedMonitors []ntpdb.GetMonitorPriorityRow,
+	accountCounts map[uint32]int,
+	flags *json.RawMessage,
+) string {
+	if err := sl.checkNetworkConstraint(monitor.IP, server.IP); err != nil {
+		return err.Error()
+	}
+
+	if err := sl.checkASNConstraint(monitor.IP, server.IP); err != nil {
+		return err.Error()
+	}
+
+	if monitor.AccountID != nil && server.AccountID != nil &&
+		*monitor.AccountID == *server.AccountID {
+		return "monitor from same account as server"
+	}
+
+	if monitor.AccountID != nil {
+		limit := defaultAccountLimitPerServer
+		if flags != nil {
+			var f accountFlags
+			if err := json.Unmarshal(*flags, &f); err == nil && f.MonitorsPerServerLimit > 0 {
+				limit = f.MonitorsPerServerLimit
+			}
+		}
+		// the same as the active+testing limit
+		if accountCounts[*monitor.AccountID] >= limit+1 {
+			return fmt.Sprintf("account %d has %d monitors for the server", *monitor.AccountID, accountCounts[*monitor.AccountID])
+		}
+	}
+
+	if err := sl.checkNetworkDiversityConstraint(monitor.ID, monitor.IP, assignedMonitors, ntpdb.ServerScoresStatusTesting); err != nil {
+		return err.Error()
+	}
+
+	if err := sl.checkASNDiversityConstraint(monitor, assignedMonitors, ntpdb.ServerScoresStatusTesting); err != nil {
+		return err.Error()
+	}
+
+	return ""
+}
+
+// convertAvailableMonitorToCandidate converts a monitor from
+// GetAvailableMonitors to a monitorCandidate for constraint checking
+func convertAvailableMonitorToCandidate(row ntpdb.GetAvailableMonitorsRow) monitorCandidate {
+	candidate := monitorCandidate{
+		ID:           row.ID,
+		GlobalStatus: row.MonitorStatus,
+		ServerStatus: ntpdb.ServerScoresStatusCandidate,
+		IsHealthy:    true,
+	}
+
+	if row.IDToken.Valid {
+		candidate.IDToken = row.IDToken.String
+	}
+	if row.TlsName.Valid {
+		candidate.TLSName = row.TlsName.String
+	}
+	if row.AccountID.Valid {
+		id := uint32(row.AccountID.Int32)
+		candidate.AccountID = &id
+	}
+	if row.MonitorIp.Valid {
+		candidate.IP = row.MonitorIp.String
+	}
+
+	return candidate
+}
@@ -0,0 +1,92 @@
+package selector
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"go.ntppool.org/monitor/ntpdb"
+)
+
+func TestCheckDiscoveryConstraints(t *testing.T) {
+	sl := &Selector{
+		log: slog.Default(),
+	}
+
+	serverAccount := uint32(1)
+	server := &serverInfo{
+		ID:        100,
+		IP:        "192.0.2.10",
+		AccountID: &serverAccount,
+		IPVersion: "v4",
+	}
+
+	assigned := []ntpdb.GetMonitorPriorityRow{
+		{
+			ID:        10,
+			MonitorIp: sql.NullString{String: "198.51.100.1", Valid: true},
+			Status:    ntpdb.NullServerScoresStatus{ServerScoresStatus: ntpdb.ServerScoresStatusActive, Valid: true},
+		},
+	}
+
+	accountCounts := map[uint32]int{
+		2: 3,
+		3: 1,
+	}
+	highLimit := json.RawMessage(`{"monitors_per_server_limit": 4}`)
+
+	account := func(id uint32) *uint32 { return &id }
+
+	tests := []struct {
+		name    string
+		monitor monitorCandidate
+		flags   *json.RawMessage
+		ok      bool
+	}{
+		{
+			name:    "eligible",
+			monitor: monitorCandidate{ID: 1, IP: "203.0.113.1", AccountID: account(3)},
+			ok:      true,
+		},
+		{
+			name:    "no_account",
+			monitor: monitorCandidate{ID: 1, IP: "203.0.113.1"},
+			ok:      true,
+		},
+		{
+			name:    "same_subnet_as_server",
+			monitor: monitorCandidate{ID: 1, IP: "192.0.2.20", AccountID: account(3)},
+		},
+		{
+			name:    "same_account_as_server",
+			monitor: monitorCandidate{ID: 1, IP: "203.0.113.1", AccountID: account(1)},
+		},
+		{
+			name:    "account_at_limit",
+			monitor: monitorCandidate{ID: 1, IP: "203.0.113.1", AccountID: account(2)},
+		},
+		{
+			name:    "account_with_higher_limit",
+			monitor: monitorCandidate{ID: 1, IP: "203.0.113.1", AccountID: account(2)},
+			flags:   &highLimit,
+			ok:      true,
+		},
+		{
+			name:    "same_network_as_active_monitor",
+			monitor: monitorCandidate{ID: 1, IP: "198.51.100.200", AccountID: account(3)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason := sl.checkDiscoveryConstraints(&tt.monitor, server, assigned, accountCounts, tt.flags)
+			if tt.ok && reason != "" {
+				t.Errorf("monitor skipped: %s", reason)
+			}
+			if !tt.ok && reason == "" {
+				t.Errorf("monitor should have been skipped")
+			}
+		})
+	}
+}
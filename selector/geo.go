@@ -0,0 +1,74 @@
+package selector
+
+import "strings"
+
+// continentCountries lists the ISO 3166 country codes on each
+// continent (the continent codes used by MaxMind)
+var continentCountries = map[string]string{
+	"AF": "AO BF BI BJ BW CD CF CG CI CM CV DJ DZ EG EH ER ET GA GH GM GN GQ GW KE KM LR LS LY MA MG ML MR MU MW MZ NA NE NG RE RW SC SD SH SL SN SO SS ST SZ TD TG TN TZ UG YT ZA ZM ZW",
+	"AN": "AQ BV GS HM TF",
+	"AS": "AE AF AM AZ BD BH BN BT CC CN CX GE HK ID IL IN IO IQ IR JO JP KG KH KP KR KW KZ LA LB LK MM MN MO MV MY NP OM PH PK PS QA SA SG SY TH TJ TL TM TR TW UZ VN YE",
+	"EU": "AD AL AT AX BA BE BG BY CH CY CZ DE DK EE ES FI FO FR GB GG GI GR HR HU IE IM IS IT JE LI LT LU LV MC MD ME MK MT NL NO PL PT RO RS RU SE SI SJ SK SM UA VA XK",
+	"NA": "AG AI AW BB BL BM BQ BS BZ CA CR CU CW DM DO GD GL GP GT HN HT JM KN KY LC MF MQ MS MX NI PA PM PR SV SX TC TT US VC VG VI",
+	"OC": "AS AU CK FJ FM GU KI MH MP NC NF NR NU NZ PF PG PN PW SB TK TO TV UM VU WF WS",
+	"SA": "AR BO BR CL CO EC FK GF GY PE PY SR UY VE",
+}
+
+// countryContinent maps country codes to the continent
+var countryContinent = func() map[string]string {
+	m := map[string]string{}
+	for continent, countries := range continentCountries {
+		for _, country := range strings.Fields(countries) {
+			m[country] = continent
+		}
+	}
+	return m
+}()
+
+// geoInfo is where a monitor is; empty if unknown
+type geoInfo struct {
+	Continent string
+	Country   string
+}
+
+// usStates are the US state and territory codes that are also
+// country codes, so "Atlanta, GA" isn't taken to be in Gabon
+var usStates = map[string]bool{
+	"AL": true, "AR": true, "AS": true, "AZ": true, "CA": true,
+	"CO": true, "DE": true, "GA": true, "GU": true, "ID": true,
+	"IL": true, "IN": true, "KY": true, "LA": true, "MA": true,
+	"MD": true, "ME": true, "MN": true, "MO": true, "MP": true,
+	"MS": true, "MT": true, "NC": true, "NE": true, "PA": true,
+	"PR": true, "SC": true, "SD": true, "TN": true, "VA": true,
+	"VI": true,
+}
+
+// locationCountry returns the country code from a monitor location:
+// the location itself ("DE") or the part after the last comma, as in
+// "Los Angeles, CA, US". With only a city before it ("Wilmington,
+// DE") a code that's also a US state is ambiguous and not used.
+func locationCountry(location string) string {
+	parts := strings.Split(location, ",")
+	country := strings.TrimSpace(parts[len(parts)-1])
+	if _, ok := countryContinent[country]; !ok {
+		return ""
+	}
+	if len(parts) == 2 && usStates[country] {
+		return ""
+	}
+	return country
+}
+
+// monitorGeo returns where the monitor is, from the location
+// configured for the monitor or the GeoIP database if the location
+// doesn't have a country
+func (sl *Selector) monitorGeo(location, ip string) geoInfo {
+	country := locationCountry(location)
+	if country == "" {
+		country = strings.ToUpper(sl.geo.lookup(ip))
+	}
+	if country == "" {
+		return geoInfo{}
+	}
+	return geoInfo{Continent: countryContinent[country], Country: country}
+}
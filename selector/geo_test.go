@@ -0,0 +1,121 @@
+package selector
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"go.ntppool.org/monitor/ntpdb"
+)
+
+const testGeoDB = `network,country
+# test ranges
+192.0.2.0/24,DE
+198.51.100.0/25,us
+2001:db8::/32,JP
+203.0.113.10,203.0.113.20,BR
+`
+
+func TestLocationCountry(t *testing.T) {
+	tests := map[string]string{
+		"Los Angeles, CA, US":  "US",
+		"DE":                   "DE",
+		"Paris, FR":            "FR",
+		"Frankfurt, Hesse, DE": "DE",
+		"Amsterdam, NL ":       "NL",
+		"somewhere":            "",
+		"":                     "",
+
+		// only a standalone code at the end is a country
+		"NL-AMS":      "",
+		"US West, OR": "",
+
+		// US states that are also country codes
+		"Atlanta, GA":    "",
+		"San Jose, CA":   "",
+		"Wilmington, DE": "",
+		"Frankfurt, DE":  "",
+	}
+	for location, want := range tests {
+		if got := locationCountry(location); got != want {
+			t.Errorf("locationCountry(%q) = %q, want %q", location, got, want)
+		}
+	}
+}
+
+func TestGeoDiversity(t *testing.T) {
+	db, err := parseIPRangeDB(strings.NewReader(testGeoDB))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sl := &Selector{log: slog.Default(), geo: db}
+
+	if geo := sl.monitorGeo("", "192.0.2.1"); geo.Country != "DE" || geo.Continent != "EU" {
+		t.Errorf("GeoIP location %+v, want DE in EU", geo)
+	}
+	if geo := sl.monitorGeo("Paris, FR", "192.0.2.1"); geo.Country != "FR" {
+		t.Errorf("configured location %+v, want FR", geo)
+	}
+
+	active := ntpdb.NullServerScoresStatus{ServerScoresStatus: ntpdb.ServerScoresStatusActive, Valid: true}
+	row := func(id uint32, location string, priority int32) ntpdb.GetMonitorPriorityRow {
+		return ntpdb.GetMonitorPriorityRow{
+			ID:              id,
+			Location:        location,
+			MonitorPriority: priority,
+			Status:          active,
+		}
+	}
+
+	// seven active monitors in Europe, five of them in Germany
+	monitors := []ntpdb.GetMonitorPriorityRow{
+		row(1, "DE", 10),
+		row(2, "DE", 20),
+		row(3, "DE", 30),
+		row(4, "DE", 40),
+		row(5, "DE", 50),
+		row(6, "FR", 60),
+		row(7, "NL", 70),
+		row(8, "US", 80),
+		row(9, "", 90),
+	}
+	// a testing monitor doesn't count
+	testingMonitor := row(10, "DE", 5)
+	testingMonitor.Status.ServerScoresStatus = ntpdb.ServerScoresStatusTesting
+	monitors = append(monitors, testingMonitor)
+
+	violations := sl.checkGeoConstraintsIterative(monitors)
+	if len(violations) != 2 {
+		t.Fatalf("got %d violations, want 2: %+v", len(violations), violations)
+	}
+	if v := violations[5]; v == nil || v.Type != violationGeoDiversity || !strings.Contains(v.Details, "DE") {
+		t.Errorf("monitor 5 should be over the country limit, got %+v", v)
+	}
+	if v := violations[7]; v == nil || !strings.Contains(v.Details, "EU") {
+		t.Errorf("monitor 7 should be over the continent limit, got %+v", v)
+	}
+
+	// the limits are in the selector settings
+	settings := sl.buildSettings(context.Background(), `{"max_active_per_continent": 7, "max_active_per_country": 5}`, nil, "", 1)
+	if v := sl.withSettings(settings).checkGeoConstraintsIterative(monitors); len(v) != 0 {
+		t.Errorf("got %d violations with higher limits: %+v", len(v), v)
+	}
+
+	// promoting another European monitor to active
+	candidate := &monitorCandidate{ID: 11, Location: "Amsterdam, NL"}
+	if err := sl.checkGeoDiversityConstraint(candidate, monitors[:5], ntpdb.ServerScoresStatusActive); err == nil {
+		t.Errorf("promotion should be over the continent limit")
+	}
+	if err := sl.checkGeoDiversityConstraint(candidate, monitors[:5], ntpdb.ServerScoresStatusTesting); err != nil {
+		t.Errorf("testing isn't limited: %s", err)
+	}
+	candidate = &monitorCandidate{ID: 11, Location: "Sydney, AU"}
+	if err := sl.checkGeoDiversityConstraint(candidate, monitors, ntpdb.ServerScoresStatusActive); err != nil {
+		t.Errorf("promotion in another continent: %s", err)
+	}
+	candidate = &monitorCandidate{ID: 11, IP: "10.0.0.1"}
+	if err := sl.checkGeoDiversityConstraint(candidate, monitors, ntpdb.ServerScoresStatusActive); err != nil {
+		t.Errorf("monitor without a location isn't limited: %s", err)
+	}
+}
@@ -0,0 +1,65 @@
+package selector
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"go.ntppool.org/monitor/ntpdb"
+)
+
+// Defaults for the importance settings
+const (
+	notInPoolActive      = 4       // Active monitors for servers not in the pool or any zone
+	notInPoolTesting     = 2       // Testing monitors for servers not in the pool or any zone
+	importantExtraActive = 2       // Extra active monitors for important servers
+	importantNetspeed    = 1000000 // Netspeed (kbit/s, 1 Gbit/s) that makes a server important
+	importantZoneShare   = 0.05    // Share of a zone's active netspeed that makes a server important
+)
+
+// serverImportance is what the monitor targets for a server are
+// based on
+type serverImportance struct {
+	InPool    bool
+	Netspeed  uint32  // kbit/s
+	Zones     int     // number of zones the server is in
+	ZoneShare float64 // largest share of the active netspeed in one of the server's zones
+}
+
+// loadImportance loads the zone membership of the server
+func (sl *Selector) loadImportance(ctx context.Context, db ntpdb.QuerierTx, server *serverInfo) (*serverImportance, error) {
+	row, err := db.GetServerZoneShare(ctx, server.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server zone share: %w", err)
+	}
+
+	imp := &serverImportance{
+		InPool:   server.InPool,
+		Netspeed: server.Netspeed,
+		Zones:    int(row.Zones),
+	}
+
+	if share, ok := row.ZoneShare.([]uint8); ok {
+		x := sql.NullFloat64{}
+		if err := x.Scan(share); err == nil && x.Valid {
+			imp.ZoneShare = x.Float64
+		}
+	}
+
+	return imp, nil
+}
+
+// applyImportance sets the active and testing targets for the
+// server. Servers that aren't in the pool (or in any zone) get the
+// smaller not-in-pool targets; servers with a high netspeed or a
+// large share of a zone get extra active monitors.
+func (s *Settings) applyImportance(imp serverImportance) {
+	switch {
+	case !imp.InPool || imp.Zones == 0:
+		s.TargetActive = min(s.TargetActive, s.NotInPoolActive)
+		s.TargetTesting = min(s.TargetTesting, s.NotInPoolTesting)
+
+	case imp.Netspeed >= s.ImportantNetspeed || imp.ZoneShare >= s.ImportantZoneShare:
+		s.TargetActive += s.ImportantExtraActive
+	}
+}
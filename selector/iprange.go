@@ -0,0 +1,195 @@
+package selector
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"slices"
+	"strings"
+)
+
+// ipRange is a range of IP addresses with a value from the database
+type ipRange struct {
+	start netip.Addr
+	end   netip.Addr
+	value string
+}
+
+// ipRangeDB maps IP addresses to a value (a country or an AS number)
+// from a local file. Each line is either "network,value" (CIDR
+// notation) or "start,end,value" followed by any other fields; the
+// fields can be separated by commas or tabs. A header line, empty
+// lines and lines starting with # are skipped. Where ranges overlap
+// the most specific one is used.
+type ipRangeDB struct {
+	ranges []ipRange
+}
+
+func loadIPRangeDB(path string) (*ipRangeDB, error) {
+	fh, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+
+	db, err := parseIPRangeDB(fh)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return db, nil
+}
+
+func parseIPRangeDB(r io.Reader) (*ipRangeDB, error) {
+	db := &ipRangeDB{}
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		sep := ","
+		if strings.Contains(text, "\t") {
+			sep = "\t"
+		}
+		fields := strings.Split(text, sep)
+		for i, f := range fields {
+			fields[i] = strings.Trim(strings.TrimSpace(f), `"`)
+		}
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("line %d: expected at least 2 fields", line)
+		}
+
+		var ir ipRange
+		if prefix, err := netip.ParsePrefix(fields[0]); err == nil {
+			prefix = prefix.Masked()
+			ir = ipRange{start: prefix.Addr(), end: lastAddr(prefix), value: fields[1]}
+		} else {
+			start, err := netip.ParseAddr(fields[0])
+			if err != nil {
+				if line == 1 {
+					continue // header
+				}
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			if len(fields) < 3 {
+				return nil, fmt.Errorf("line %d: expected start, end and value", line)
+			}
+			end, err := netip.ParseAddr(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			ir = ipRange{start: start.Unmap(), end: end.Unmap(), value: fields[2]}
+		}
+
+		db.ranges = append(db.ranges, ir)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	db.ranges = flattenRanges(db.ranges)
+
+	return db, nil
+}
+
+// flattenRanges returns the ranges without overlaps, sorted by the
+// start address. Where ranges overlap the value is from the one
+// starting last (or, starting at the same address, ending first),
+// so a prefix nested in a larger one takes precedence inside it.
+func flattenRanges(ranges []ipRange) []ipRange {
+	slices.SortFunc(ranges, func(a, b ipRange) int {
+		if c := a.start.Compare(b.start); c != 0 {
+			return c
+		}
+		return b.end.Compare(a.end)
+	})
+
+	// the addresses where the most specific range can change
+	points := []netip.Addr{}
+	for _, ir := range ranges {
+		points = append(points, ir.start)
+		if next := ir.end.Next(); next.IsValid() {
+			points = append(points, next)
+		}
+	}
+	slices.SortFunc(points, netip.Addr.Compare)
+	points = slices.Compact(points)
+
+	flat := []ipRange{}
+	active := []ipRange{}
+	next := 0
+	for i, p := range points {
+		active = slices.DeleteFunc(active, func(ir ipRange) bool {
+			return ir.end.Less(p)
+		})
+		for next < len(ranges) && ranges[next].start == p {
+			active = append(active, ranges[next])
+			next++
+		}
+		if len(active) == 0 {
+			continue
+		}
+
+		// in sort order, so the last one is the most specific
+		ir := active[len(active)-1]
+		end := ir.end
+		if i+1 < len(points) {
+			if last := points[i+1].Prev(); last.IsValid() && last.Less(end) {
+				end = last
+			}
+		}
+
+		if n := len(flat); n > 0 && flat[n-1].value == ir.value && flat[n-1].end.Next() == p {
+			flat[n-1].end = end
+			continue
+		}
+		flat = append(flat, ipRange{start: p, end: end, value: ir.value})
+	}
+
+	return flat
+}
+
+// lookup returns the value for the IP address, or an empty string if
+// it isn't in the database
+func (db *ipRangeDB) lookup(ip string) string {
+	if db == nil || ip == "" {
+		return ""
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ""
+	}
+	addr = addr.Unmap()
+
+	// the last range starting at or before the address
+	i, found := slices.BinarySearchFunc(db.ranges, addr, func(ir ipRange, addr netip.Addr) int {
+		return ir.start.Compare(addr)
+	})
+	if !found {
+		i--
+	}
+	if i < 0 {
+		return ""
+	}
+	ir := db.ranges[i]
+	if ir.start.Is4() != addr.Is4() || ir.end.Less(addr) {
+		return ""
+	}
+	return ir.value
+}
+
+// lastAddr returns the last address in the prefix
+func lastAddr(prefix netip.Prefix) netip.Addr {
+	b := prefix.Addr().AsSlice()
+	for i := prefix.Bits(); i < len(b)*8; i++ {
+		b[i/8] |= 1 << (7 - i%8)
+	}
+	addr, _ := netip.AddrFromSlice(b)
+	return addr
+}
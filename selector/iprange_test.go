@@ -0,0 +1,87 @@
+package selector
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIPRangeDB(t *testing.T) {
+	db, err := parseIPRangeDB(strings.NewReader(testGeoDB))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"192.0.2.1":         "DE",
+		"192.0.2.255":       "DE",
+		"192.0.3.0":         "",
+		"198.51.100.127":    "us",
+		"198.51.100.128":    "",
+		"203.0.113.15":      "BR",
+		"203.0.113.21":      "",
+		"::ffff:192.0.2.10": "DE",
+		"2001:db8:1234::1":  "JP",
+		"2001:db9::1":       "",
+		"10.0.0.1":          "",
+		"not an ip":         "",
+		"":                  "",
+	}
+	for ip, want := range tests {
+		if got := db.lookup(ip); got != want {
+			t.Errorf("lookup(%q) = %q, want %q", ip, got, want)
+		}
+	}
+
+	var nilDB *ipRangeDB
+	if got := nilDB.lookup("192.0.2.1"); got != "" {
+		t.Errorf("lookup without a database = %q", got)
+	}
+
+	if _, err := parseIPRangeDB(strings.NewReader("192.0.2.0/24,DE\nbad line\n")); err == nil {
+		t.Errorf("expected an error for an invalid line")
+	}
+}
+
+func TestIPRangeDBNested(t *testing.T) {
+	db, err := parseIPRangeDB(strings.NewReader(`network,value
+10.0.0.0/8,A
+10.0.0.0/24,B
+10.2.0.0/16,C
+10.2.3.0/24,D
+10.2.3.128,10.2.4.255,E
+2001:db8::/32,JP
+2001:db8:1::/48,KR
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"10.0.0.5":       "B",
+		"10.0.1.1":       "A",
+		"10.1.255.255":   "A",
+		"10.2.0.1":       "C",
+		"10.2.3.4":       "D",
+		"10.2.3.200":     "E",
+		"10.2.4.1":       "E",
+		"10.2.5.0":       "C",
+		"10.3.0.0":       "A",
+		"10.255.255.255": "A",
+		"11.0.0.0":       "",
+		"9.255.255.255":  "",
+		"2001:db8:1::1":  "KR",
+		"2001:db8:2::1":  "JP",
+		"2001:db8::1":    "JP",
+	}
+	for ip, want := range tests {
+		if got := db.lookup(ip); got != want {
+			t.Errorf("lookup(%q) = %q, want %q", ip, got, want)
+		}
+	}
+
+	for i := 1; i < len(db.ranges); i++ {
+		if !db.ranges[i-1].end.Less(db.ranges[i].start) {
+			t.Errorf("ranges %+v and %+v overlap", db.ranges[i-1], db.ranges[i])
+		}
+	}
+}
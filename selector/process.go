@@ -8,7 +8,8 @@ import (
 	"go.ntppool.org/monitor/ntpdb"
 )
 
-// Constants for monitor selection
+// Constants for monitor selection; the targets and counts are the
+// defaults for Settings
 const (
 	targetActiveMonitors       = 7  // Target number of active monitors per server
 	baseTestingTarget          = 5  // Base number of testing monitors
@@ -18,6 +19,10 @@ const (
 	minCountForActive          = 32 // Minimum data points required for testing->active promotion
 )
 
+// minReliability is the minimum share of work leases a monitor must
+// return on time to be considered healthy
+const minReliability = 0.8
+
 // replacementType defines the type of performance-based replacement
 type replacementType int8
 
@@ -108,6 +113,8 @@ func (sl *Selector) loadServerInfo(
 		IP:        server.Ip,
 		AccountID: accountID,
 		IPVersion: string(server.IpVersion),
+		InPool:    server.InPool > 0,
+		Netspeed:  server.Netspeed,
 	}, nil
 }
 
@@ -288,7 +295,7 @@ func (sl *Selector) applyRule2GradualConstraintRemoval(
 	// Process testing removals with updated count
 	testingChanges := sl.processRemovals(ctx, testingMonitors,
 		ntpdb.ServerScoresStatusTesting, ntpdb.ServerScoresStatusCandidate,
-		testingCount, max(1, baseTestingTarget-2),
+		testingCount, max(1, sl.config().TargetTesting-2),
 		selCtx.limits.testingRemovals, currentActiveMonitors)
 
 	return append(activeChanges, testingChanges...)
@@ -388,7 +395,7 @@ func (sl *Selector) applyRule3TestingToActivePromotion(
 			}
 
 			// Check count requirement for testing->active promotion
-			if em.monitor.Count < int64(minCountForActive) {
+			if em.monitor.Count < int64(sl.config().MinCountActive) {
 				continue // Skip this monitor, insufficient data points
 			}
 
@@ -455,7 +462,7 @@ func (sl *Selector) applyRule5CandidateToTestingPromotion(
 	if changesRemaining > 0 && len(candidateMonitors) > 0 {
 		// Calculate dynamic testing target to avoid over-promoting
 		activeGap := max(0, selCtx.targetNumber-workingActiveCount)
-		dynamicTestingTarget := baseTestingTarget + activeGap
+		dynamicTestingTarget := sl.config().TargetTesting + activeGap
 		testingCapacity := max(0, dynamicTestingTarget-workingTestingCount)
 
 		sl.log.InfoContext(ctx, "Rule 5 Phase 1: capacity-based promotion analysis",
@@ -466,7 +473,7 @@ func (sl *Selector) applyRule5CandidateToTestingPromotion(
 			slog.Int("workingTestingCount", workingTestingCount),
 			slog.Int("targetNumber", selCtx.targetNumber),
 			slog.Int("activeGap", activeGap),
-			slog.Int("baseTestingTarget", baseTestingTarget),
+			slog.Int("baseTestingTarget", sl.config().TargetTesting),
 			slog.Int("dynamicTestingTarget", dynamicTestingTarget),
 			slog.Int("testingCapacity", testingCapacity),
 		)
@@ -491,7 +498,7 @@ func (sl *Selector) applyRule5CandidateToTestingPromotion(
 			}
 
 			// Check count requirement for candidate->testing promotion
-			if em.monitor.Count < int64(minCountForTesting) {
+			if em.monitor.Count < int64(sl.config().MinCountTesting) {
 				continue // Skip this monitor, insufficient data points
 			}
 
@@ -573,7 +580,7 @@ func (sl *Selector) applyRule2_5TestingPoolManagement(
 
 	// Calculate dynamic testing target based on working active monitor gap
 	activeGap := max(0, selCtx.targetNumber-workingActiveCount)
-	dynamicTestingTarget := baseTestingTarget + activeGap
+	dynamicTestingTarget := sl.config().TargetTesting + activeGap
 
 	if workingTestingCount > dynamicTestingTarget {
 		excessTesting := workingTestingCount - dynamicTestingTarget
@@ -637,13 +644,13 @@ func (sl *Selector) applyRule6BootstrapPromotion(
 
 	// Bootstrap case - if no testing monitors exist, promote candidates to reach target
 	if len(testingMonitors) == 0 && len(candidateMonitors) > 0 {
-		// In bootstrap scenario, we can promote up to baseTestingTarget at once
-		bootstrapPromotions := baseTestingTarget
+		// In bootstrap scenario, we can promote up to the testing target at once
+		bootstrapPromotions := sl.config().TargetTesting
 		promoted := 0
 
 		sl.log.Info("bootstrap: no testing monitors, promoting candidates to start monitoring",
 			"candidatesAvailable", len(candidateMonitors),
-			"baseTestingTarget", baseTestingTarget,
+			"baseTestingTarget", bootstrapPromotions,
 			"bootstrapPromotions", bootstrapPromotions)
 
 		// Sort candidates by health first, then by global status
@@ -857,7 +864,7 @@ func (sl *Selector) performFinalValidation(
 			"serverID", server.ID)
 	}
 
-	finalTestingTarget := baseTestingTarget + max(0, targetActiveMonitors-state.activeCount)
+	finalTestingTarget := sl.config().TargetTesting + max(0, targetActiveMonitors-state.activeCount)
 	if state.testingCount > finalTestingTarget {
 		sl.log.ErrorContext(ctx, "CRITICAL: testing monitor count exceeds target after selection",
 			"finalTestingCount", state.testingCount,
@@ -908,7 +915,7 @@ func (sl *Selector) applySelectionRules(
 	}
 
 	// Initialize working state and limits
-	targetNumber := targetActiveMonitors
+	targetNumber := sl.config().TargetActive
 	limits := calculateChangeLimits(len(activeMonitors), sl.countBlocked(evaluatedMonitors))
 	state := sl.initializeWorkingCounts(activeMonitors, testingMonitors, evaluatedMonitors)
 	emergencyOverride := (len(activeMonitors) == 0)
@@ -1162,7 +1169,7 @@ func max(a, b int) int {
 func (sl *Selector) calculateNeededCandidates(active, testing, candidates int) int {
 	// We want a buffer of candidates ready to be promoted
 	// Target: enough to replace both active and testing pools (using base testing target)
-	targetCandidates := targetActiveMonitors + baseTestingTarget
+	targetCandidates := sl.config().TargetActive + sl.config().TargetTesting
 	current := candidates
 
 	if current < targetCandidates {
@@ -1388,7 +1395,7 @@ func (sl *Selector) attemptPerformanceReplacement(
 			var minRequiredCount int64
 			switch repType {
 			case candidateToTesting:
-				minRequiredCount = int64(minCountForTesting)
+				minRequiredCount = int64(sl.config().MinCountTesting)
 				if replacer.monitor.Count < minRequiredCount {
 					logMsg := "replacer skipped: insufficient data points"
 					if isSpecialReplacer {
@@ -1408,7 +1415,7 @@ func (sl *Selector) attemptPerformanceReplacement(
 					)
 				}
 			case testingToActive:
-				minRequiredCount = int64(minCountForActive)
+				minRequiredCount = int64(sl.config().MinCountActive)
 				if replacer.monitor.Count < minRequiredCount {
 					sl.log.InfoContext(ctx, "replacer skipped: insufficient data points",
 						slog.Uint64("replacerMonitorID", uint64(replacer.monitor.ID)),
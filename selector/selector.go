@@ -27,23 +27,32 @@ type Cmd struct {
 type (
 	ServerCmd struct {
 		MetricsPort int `default:"9000" help:"Metrics server port" flag:"metrics-port"`
+		DataFiles   `embed:""`
 	}
 	OnceCmd struct {
 		ServerID    *uint32 `arg:"" optional:"" help:"Server ID to process (if not specified, processes all servers)"`
 		MetricsPort int     `default:"9000" help:"Metrics server port" flag:"metrics-port"`
+		DataFiles   `embed:""`
 	}
 	SimulateCmd struct {
-		ServerID uint32 `arg:"" help:"Server ID to simulate selection for"`
-		Verbose  bool   `flag:"verbose" short:"v" help:"Enable verbose debug logging"`
+		ServerID  uint32 `arg:"" help:"Server ID to simulate selection for"`
+		Verbose   bool   `flag:"verbose" short:"v" help:"Enable verbose debug logging"`
+		DataFiles `embed:""`
 	}
 )
 
+// DataFiles are the local databases used for the constraints
+type DataFiles struct {
+	GeoIPDB string `name:"geoip-db" env:"SELECTOR_GEOIP_DB" help:"CSV file with the country for IP ranges (network,country or start,end,country)"`
+	ASNDB   string `name:"asn-db" env:"SELECTOR_ASN_DB" help:"CSV or TSV file with the AS number for IP ranges (network,asn or start,end,asn,...)"`
+}
+
 func (cmd ServerCmd) Run(ctx context.Context) error {
-	return Run(ctx, true, cmd.MetricsPort, nil)
+	return Run(ctx, true, cmd.MetricsPort, nil, cmd.DataFiles)
 }
 
 func (cmd OnceCmd) Run(ctx context.Context) error {
-	return Run(ctx, false, cmd.MetricsPort, cmd.ServerID)
+	return Run(ctx, false, cmd.MetricsPort, cmd.ServerID, cmd.DataFiles)
 }
 
 func (cmd SimulateCmd) Run(ctx context.Context) error {
@@ -75,6 +84,9 @@ func (cmd SimulateCmd) Run(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("failed to create selector: %w", err)
 	}
+	if err := sl.LoadDataFiles(cmd.DataFiles); err != nil {
+		return err
+	}
 
 	// Run simulation within read-only transaction
 	db := ntpdb.New(dbconn)
@@ -110,7 +122,7 @@ func (cmd SimulateCmd) Run(ctx context.Context) error {
 }
 
 // Run executes the selector logic either continuously or once
-func Run(ctx context.Context, continuous bool, metricsPort int, serverID *uint32) error {
+func Run(ctx context.Context, continuous bool, metricsPort int, serverID *uint32, files DataFiles) error {
 	log := logger.FromContext(ctx)
 
 	log.InfoContext(ctx, "selector starting", "version", version.Version())
@@ -136,6 +148,9 @@ func Run(ctx context.Context, continuous bool, metricsPort int, serverID *uint32
 	if err != nil {
 		return err
 	}
+	if err := sl.LoadDataFiles(files); err != nil {
+		return err
+	}
 
 	expback := backoff.NewExponentialBackOff()
 	expback.InitialInterval = time.Second * 3
@@ -218,6 +233,12 @@ type Selector struct {
 	dbconn  *sql.DB
 	log     *slog.Logger
 	metrics *Metrics
+	// settings for the server being processed, only set on the
+	// copy from withSettings; nil for the defaults
+	settings *Settings
+
+	geo *ipRangeDB // country for IP addresses; nil without a GeoIP database
+	asn *ipRangeDB // AS number for IP addresses; nil without an ASN database
 }
 
 // NewSelector creates a new selector instance
@@ -225,6 +246,30 @@ func NewSelector(ctx context.Context, dbconn *sql.DB, log *slog.Logger, metrics
 	return &Selector{ctx: ctx, dbconn: dbconn, log: log, metrics: metrics}, nil
 }
 
+// LoadDataFiles loads the local databases used for the constraints.
+// Without a GeoIP database the geographic diversity constraint only
+// uses the configured monitor locations; without an ASN database the
+// ASN constraints aren't checked.
+func (sl *Selector) LoadDataFiles(files DataFiles) error {
+	if files.GeoIPDB != "" {
+		geo, err := loadIPRangeDB(files.GeoIPDB)
+		if err != nil {
+			return fmt.Errorf("failed to load GeoIP database: %w", err)
+		}
+		sl.geo = geo
+		sl.log.Info("loaded GeoIP database", "path", files.GeoIPDB, "ranges", len(geo.ranges))
+	}
+	if files.ASNDB != "" {
+		asn, err := loadIPRangeDB(files.ASNDB)
+		if err != nil {
+			return fmt.Errorf("failed to load ASN database: %w", err)
+		}
+		sl.asn = asn
+		sl.log.Info("loaded ASN database", "path", files.ASNDB, "ranges", len(asn.ranges))
+	}
+	return nil
+}
+
 // Run processes all servers that need monitor review
 func (sl *Selector) Run() (int, error) {
 	ctx, cancel := context.WithCancel(sl.ctx)
@@ -290,13 +335,24 @@ func (sl *Selector) processServer(ctx context.Context, db ntpdb.QuerierTx, serve
 		return false, fmt.Errorf("failed to load server info: %w", err)
 	}
 
+	// Targets and thresholds for the server; the rest of the
+	// processing uses a selector with them
+	sl = sl.withSettings(sl.loadSettings(ctx, db, server))
+
 	// Step 2: Get all assigned monitors
 	assignedMonitors, err := db.GetMonitorPriority(ctx, serverID)
 	if err != nil {
 		return false, fmt.Errorf("failed to get monitor priority: %w", err)
 	}
 
-	// No longer loading available monitors - only work with assigned monitors
+	// Consensus offset from the scorer, to find monitors with a biased clock
+	consensus, err := sl.loadServerOffset(ctx, db, serverID)
+	if err != nil {
+		return false, fmt.Errorf("failed to load server offset: %w", err)
+	}
+
+	// Only assigned monitors are evaluated here; unassigned monitors
+	// are added as candidates by discoverCandidates after the changes
 
 	// Step 3: Build account limits from assigned monitors (still needed for promotion logic)
 	accountLimits := sl.buildAccountLimitsFromMonitors(assignedMonitors)
@@ -305,12 +361,18 @@ func (sl *Selector) processServer(ctx context.Context, db ntpdb.QuerierTx, serve
 	// This identifies which specific monitors exceed the per-category limits
 	accountLimitViolations := sl.checkAccountConstraintsIterative(assignedMonitors, server)
 
+	// Same for the active monitors over the per-continent and per-country limits
+	// and the active and testing monitors over the per-ASN limit
+	geoViolations := sl.checkGeoConstraintsIterative(assignedMonitors)
+	asnViolations := sl.checkASNConstraintsIterative(assignedMonitors)
+
 	// Step 5: Evaluate all monitors against constraints
 	evaluatedMonitors := make([]evaluatedMonitor, 0, len(assignedMonitors))
 
 	// Process assigned monitors
 	for _, row := range assignedMonitors {
 		monitor := convertMonitorPriorityToCandidate(row)
+		applyOffsetBias(&monitor, row, consensus)
 
 		// Check non-account constraints for ALL monitors on EVERY run
 		// This allows us to detect when constraint rules change
@@ -319,6 +381,10 @@ func (sl *Selector) processServer(ctx context.Context, db ntpdb.QuerierTx, serve
 		// First check if this monitor has an account limit violation from iterative checking
 		if violation, hasAccountViolation := accountLimitViolations[monitor.ID]; hasAccountViolation {
 			currentViolation = violation
+		} else if violation, hasGeoViolation := geoViolations[monitor.ID]; hasGeoViolation {
+			currentViolation = violation
+		} else if violation, hasASNViolation := asnViolations[monitor.ID]; hasASNViolation {
+			currentViolation = violation
 		} else {
 			// Check other constraints (network, same account) but skip account limits
 			// since those are handled iteratively
@@ -373,6 +439,14 @@ func (sl *Selector) processServer(ctx context.Context, db ntpdb.QuerierTx, serve
 		}
 	}
 
+	// Step 8: Add new candidates if the server has too few
+	discovered, err := sl.discoverCandidates(ctx, db, server, assignedMonitors)
+	if err != nil {
+		sl.log.Error("failed to discover candidates", "serverID", serverID, "error", err)
+		// Don't fail the whole operation; the candidates added so far are kept
+	}
+	changeCount += discovered
+
 	// Track constraint violations
 	if err := sl.trackConstraintViolations(db, serverID, evaluatedMonitors); err != nil {
 		sl.log.Error("failed to track constraint violations", "error", err)
@@ -448,6 +522,7 @@ func (sl *Selector) processServer(ctx context.Context, db ntpdb.QuerierTx, serve
 		"pausedMonitors", len(pausedMonitors),
 		"plannedChanges", len(changes),
 		"appliedChanges", changeCount,
+		"discoveredCandidates", discovered,
 		"failedChanges", failedChanges)
 
 	return changeCount > 0, nil
@@ -0,0 +1,195 @@
+package selector
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"go.ntppool.org/common/timeutil"
+	"go.ntppool.org/monitor/ntpdb"
+)
+
+// Settings are the targets and thresholds for the selector, loaded
+// from the "selector" system setting; anything not in the setting
+// gets the default. The active and testing targets
+// are adjusted for how important the server is, and the config column
+// in servers_monitor_review can override any of them for a server.
+type Settings struct {
+	// Active and (base) testing monitors per server
+	TargetActive  int `json:"target_active"`
+	TargetTesting int `json:"target_testing"`
+
+	// Data points required for candidate->testing and
+	// testing->active promotions
+	MinCountTesting int `json:"min_count_testing"`
+	MinCountActive  int `json:"min_count_active"`
+
+	// Prefix lengths for the same subnet and network diversity
+	// constraints
+	SubnetV4          int `json:"subnet_v4"`
+	SubnetV6          int `json:"subnet_v6"`
+	DiversitySubnetV4 int `json:"diversity_subnet_v4"`
+	DiversitySubnetV6 int `json:"diversity_subnet_v6"`
+
+	// How often paused monitors are checked for constraint resolution
+	ConstraintRecheck timeutil.Duration `json:"constraint_recheck"`
+	ExcessRecheck     timeutil.Duration `json:"excess_recheck"`
+
+	// Targets for servers that aren't in the pool or in any zone
+	NotInPoolActive  int `json:"not_in_pool_active"`
+	NotInPoolTesting int `json:"not_in_pool_testing"`
+
+	// Extra active monitors for servers with at least the netspeed
+	// (kbit/s) or share of the active netspeed in one of their zones
+	ImportantExtraActive int     `json:"important_extra_active"`
+	ImportantNetspeed    uint32  `json:"important_netspeed"`
+	ImportantZoneShare   float64 `json:"important_zone_share"`
+
+	// Geographic diversity limits for active monitors, and the limit
+	// for active and testing monitors in one autonomous system
+	MaxActivePerContinent int `json:"max_active_per_continent"`
+	MaxActivePerCountry   int `json:"max_active_per_country"`
+	MaxPerASN             int `json:"max_per_asn"`
+}
+
+// DefaultSettings returns the built-in targets and thresholds
+func DefaultSettings() Settings {
+	return Settings{
+		TargetActive:          targetActiveMonitors,
+		TargetTesting:         baseTestingTarget,
+		MinCountTesting:       minCountForTesting,
+		MinCountActive:        minCountForActive,
+		SubnetV4:              defaultSubnetV4,
+		SubnetV6:              defaultSubnetV6,
+		DiversitySubnetV4:     diversitySubnetV4,
+		DiversitySubnetV6:     diversitySubnetV6,
+		ConstraintRecheck:     timeutil.Duration{Duration: constraintRecheckInterval},
+		ExcessRecheck:         timeutil.Duration{Duration: excessRecheckInterval},
+		NotInPoolActive:       notInPoolActive,
+		NotInPoolTesting:      notInPoolTesting,
+		ImportantExtraActive:  importantExtraActive,
+		ImportantNetspeed:     importantNetspeed,
+		ImportantZoneShare:    importantZoneShare,
+		MaxActivePerContinent: maxActivePerContinent,
+		MaxActivePerCountry:   maxActivePerCountry,
+		MaxPerASN:             maxPerASN,
+	}
+}
+
+// setDefaults replaces values that aren't valid (negative counts,
+// prefix lengths out of range and intervals that aren't positive)
+// with the defaults. Zero is kept.
+func (s *Settings) setDefaults() {
+	defaults := DefaultSettings()
+	for _, v := range []struct{ value, def *int }{
+		{&s.TargetActive, &defaults.TargetActive},
+		{&s.TargetTesting, &defaults.TargetTesting},
+		{&s.MinCountTesting, &defaults.MinCountTesting},
+		{&s.MinCountActive, &defaults.MinCountActive},
+		{&s.NotInPoolActive, &defaults.NotInPoolActive},
+		{&s.NotInPoolTesting, &defaults.NotInPoolTesting},
+		{&s.ImportantExtraActive, &defaults.ImportantExtraActive},
+		{&s.MaxActivePerContinent, &defaults.MaxActivePerContinent},
+		{&s.MaxActivePerCountry, &defaults.MaxActivePerCountry},
+		{&s.MaxPerASN, &defaults.MaxPerASN},
+	} {
+		if *v.value < 0 {
+			*v.value = *v.def
+		}
+	}
+	if s.ImportantZoneShare < 0 {
+		s.ImportantZoneShare = defaults.ImportantZoneShare
+	}
+	if s.SubnetV4 <= 0 || s.SubnetV4 > 32 {
+		s.SubnetV4 = defaults.SubnetV4
+	}
+	if s.SubnetV6 <= 0 || s.SubnetV6 > 128 {
+		s.SubnetV6 = defaults.SubnetV6
+	}
+	if s.DiversitySubnetV4 <= 0 || s.DiversitySubnetV4 > 32 {
+		s.DiversitySubnetV4 = defaults.DiversitySubnetV4
+	}
+	if s.DiversitySubnetV6 <= 0 || s.DiversitySubnetV6 > 128 {
+		s.DiversitySubnetV6 = defaults.DiversitySubnetV6
+	}
+	if s.ConstraintRecheck.Duration <= 0 {
+		s.ConstraintRecheck = defaults.ConstraintRecheck
+	}
+	if s.ExcessRecheck.Duration <= 0 {
+		s.ExcessRecheck = defaults.ExcessRecheck
+	}
+}
+
+// withSettings returns a copy of the selector using the settings, so
+// they don't carry over to the next server processed
+func (sl *Selector) withSettings(settings Settings) *Selector {
+	n := *sl
+	n.settings = &settings
+	return &n
+}
+
+// config returns the settings for the server being processed
+func (sl *Selector) config() Settings {
+	if sl.settings == nil {
+		return DefaultSettings()
+	}
+	return *sl.settings
+}
+
+// loadSettings returns the settings for the server from the
+// "selector" system setting, the importance of the server and the
+// server's review config. They are read for every server so changes
+// apply on the next review.
+func (sl *Selector) loadSettings(ctx context.Context, db ntpdb.QuerierTx, server *serverInfo) Settings {
+	serverID := server.ID
+
+	settingsStr, err := db.GetSystemSetting(ctx, "selector")
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		sl.log.WarnContext(ctx, "could not fetch selector settings", "err", err)
+	}
+
+	config, err := db.GetServerMonitorReviewConfig(ctx, serverID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		sl.log.WarnContext(ctx, "could not fetch server review config", "serverID", serverID, "err", err)
+	}
+
+	imp, err := sl.loadImportance(ctx, db, server)
+	if err != nil {
+		// use the targets from the system setting
+		sl.log.WarnContext(ctx, "could not load server importance", "serverID", serverID, "err", err)
+	}
+
+	return sl.buildSettings(ctx, settingsStr, imp, config, serverID)
+}
+
+// buildSettings adjusts the system settings for the importance of
+// the server (if known) and applies the server config on top. Both
+// are decoded over the defaults, so only the settings they have
+// change. Invalid JSON is logged and ignored.
+func (sl *Selector) buildSettings(ctx context.Context, settingsStr string, imp *serverImportance, config string, serverID uint32) Settings {
+	settings := DefaultSettings()
+	if len(settingsStr) > 0 {
+		if err := json.Unmarshal([]byte(settingsStr), &settings); err != nil {
+			sl.log.WarnContext(ctx, "could not unmarshal selector settings", "err", err)
+			settings = DefaultSettings()
+		}
+	}
+	settings.setDefaults()
+
+	if imp != nil {
+		settings.applyImportance(*imp)
+	}
+
+	if len(config) > 0 {
+		server := settings
+		if err := json.Unmarshal([]byte(config), &server); err != nil {
+			sl.log.WarnContext(ctx, "could not unmarshal server review config", "serverID", serverID, "err", err)
+		} else {
+			server.setDefaults()
+			settings = server
+		}
+	}
+
+	return settings
+}
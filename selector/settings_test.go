@@ -0,0 +1,167 @@
+package selector
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"testing"
+	"time"
+
+	"go.ntppool.org/monitor/ntpdb"
+)
+
+func TestBuildSettings(t *testing.T) {
+	sl := &Selector{log: slog.Default()}
+	ctx := context.Background()
+
+	settings := sl.buildSettings(ctx, "", nil, "", 1)
+	if settings != DefaultSettings() {
+		t.Errorf("without settings got %+v, want the defaults", settings)
+	}
+	if settings.TargetActive != targetActiveMonitors || settings.SubnetV4 != defaultSubnetV4 ||
+		settings.ConstraintRecheck.Duration != constraintRecheckInterval {
+		t.Errorf("unexpected defaults %+v", settings)
+	}
+
+	system := `{"target_active": 9, "min_count_active": 40, "diversity_subnet_v4": 18, "constraint_recheck": "4h"}`
+	settings = sl.buildSettings(ctx, system, nil, "", 1)
+	if settings.TargetActive != 9 || settings.MinCountActive != 40 ||
+		settings.DiversitySubnetV4 != 18 || settings.ConstraintRecheck.Duration != 4*time.Hour {
+		t.Errorf("system settings not applied: %+v", settings)
+	}
+	if settings.TargetTesting != baseTestingTarget || settings.ExcessRecheck.Duration != excessRecheckInterval {
+		t.Errorf("settings not in the system setting should be the defaults: %+v", settings)
+	}
+
+	// the server config overrides the system settings
+	settings = sl.buildSettings(ctx, system, nil, `{"target_active": 3, "target_testing": 2}`, 1)
+	if settings.TargetActive != 3 || settings.TargetTesting != 2 || settings.MinCountActive != 40 {
+		t.Errorf("server config not applied: %+v", settings)
+	}
+
+	// invalid JSON and values are ignored
+	settings = sl.buildSettings(ctx, system, nil, `{"target_active": `, 1)
+	if settings.TargetActive != 9 {
+		t.Errorf("invalid server config should be ignored: %+v", settings)
+	}
+	settings = sl.buildSettings(ctx, `{"subnet_v4": 40, "subnet_v6": -1}`, nil, "", 1)
+	if settings.SubnetV4 != defaultSubnetV4 || settings.SubnetV6 != defaultSubnetV6 {
+		t.Errorf("invalid prefix lengths should be the defaults: %+v", settings)
+	}
+	if s := sl.buildSettings(ctx, `{"max_per_asn": -2}`, nil, "", 1); s.MaxPerASN != maxPerASN {
+		t.Errorf("negative max per ASN should be the default: %+v", s)
+	}
+
+	// zero is a valid setting
+	s := sl.buildSettings(ctx, `{"max_per_asn": 0, "important_zone_share": 0}`, nil, `{"target_testing": 0}`, 1)
+	if s.MaxPerASN != 0 || s.ImportantZoneShare != 0 || s.TargetTesting != 0 {
+		t.Errorf("zero settings should be kept: %+v", s)
+	}
+
+	// the constraints use the settings for the server
+	settings.SubnetV4 = 16
+	server := sl.withSettings(settings)
+	if err := server.checkNetworkConstraint("192.168.1.10", "192.168.200.20"); err == nil {
+		t.Errorf("monitor and server in the same /16 should be a violation")
+	}
+	if got := server.calculateNeededCandidates(0, 0, 0); got != targetActiveMonitors+baseTestingTarget {
+		t.Errorf("needed candidates %d, want %d", got, targetActiveMonitors+baseTestingTarget)
+	}
+
+	// and don't change the selector they were applied to
+	if sl.settings != nil {
+		t.Errorf("settings for the server set on the selector")
+	}
+	if err := sl.checkNetworkConstraint("192.168.1.10", "192.168.200.20"); err != nil {
+		t.Errorf("default /24 constraint: %v", err)
+	}
+}
+
+func TestImportanceSettings(t *testing.T) {
+	sl := &Selector{log: slog.Default()}
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		imp     serverImportance
+		active  int
+		testing int
+	}{
+		{"not in pool", serverImportance{InPool: false, Zones: 2, ZoneShare: 0.5}, notInPoolActive, notInPoolTesting},
+		{"no zones", serverImportance{InPool: true, Netspeed: 10000000}, notInPoolActive, notInPoolTesting},
+		{"regular", serverImportance{InPool: true, Netspeed: 50000, Zones: 3, ZoneShare: 0.01}, targetActiveMonitors, baseTestingTarget},
+		{"high netspeed", serverImportance{InPool: true, Netspeed: importantNetspeed, Zones: 3, ZoneShare: 0.01}, targetActiveMonitors + importantExtraActive, baseTestingTarget},
+		{"large zone share", serverImportance{InPool: true, Netspeed: 50000, Zones: 1, ZoneShare: 0.2}, targetActiveMonitors + importantExtraActive, baseTestingTarget},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := sl.buildSettings(ctx, "", &tt.imp, "", 1)
+			if settings.TargetActive != tt.active || settings.TargetTesting != tt.testing {
+				t.Errorf("targets %d/%d, want %d/%d", settings.TargetActive, settings.TargetTesting, tt.active, tt.testing)
+			}
+		})
+	}
+
+	// the system setting can disable the extra monitors and the
+	// server config still overrides the targets
+	imp := serverImportance{InPool: true, Netspeed: 1000000, Zones: 1}
+	settings := sl.buildSettings(ctx, `{"important_extra_active": 0}`, &imp, "", 1)
+	if settings.TargetActive != targetActiveMonitors {
+		t.Errorf("target active %d, want %d", settings.TargetActive, targetActiveMonitors)
+	}
+	imp.InPool = false
+	settings = sl.buildSettings(ctx, "", &imp, `{"target_active": 9}`, 1)
+	if settings.TargetActive != 9 || settings.TargetTesting != 2 {
+		t.Errorf("unexpected targets with server config: %+v", settings)
+	}
+}
+
+// zoneShareDB returns the row for GetServerZoneShare
+type zoneShareDB struct {
+	ntpdb.QuerierTx
+	row ntpdb.GetServerZoneShareRow
+	err error
+}
+
+func (db *zoneShareDB) GetServerZoneShare(ctx context.Context, id uint32) (ntpdb.GetServerZoneShareRow, error) {
+	return db.row, db.err
+}
+
+func TestLoadImportance(t *testing.T) {
+	sl := &Selector{log: slog.Default()}
+	ctx := context.Background()
+	server := &serverInfo{ID: 1, InPool: true, Netspeed: 50000}
+
+	tests := []struct {
+		name  string
+		row   ntpdb.GetServerZoneShareRow
+		zones int
+		share float64
+	}{
+		// MySQL returns the decimal as bytes
+		{"zone share", ntpdb.GetServerZoneShareRow{Zones: 2, ZoneShare: []uint8("0.1250")}, 2, 0.125},
+		// no zones, or no active netspeed in them
+		{"null zone share", ntpdb.GetServerZoneShareRow{Zones: 0, ZoneShare: nil}, 0, 0},
+		{"null with zones", ntpdb.GetServerZoneShareRow{Zones: 1, ZoneShare: nil}, 1, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			imp, err := sl.loadImportance(ctx, &zoneShareDB{row: tt.row}, server)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := serverImportance{InPool: true, Netspeed: 50000, Zones: tt.zones, ZoneShare: tt.share}
+			if *imp != want {
+				t.Errorf("importance %+v, want %+v", *imp, want)
+			}
+		})
+	}
+
+	// without the zone share the targets from the settings are used
+	imp, err := sl.loadImportance(ctx, &zoneShareDB{err: sql.ErrConnDone}, server)
+	if err == nil || imp != nil {
+		t.Errorf("got %+v, %v; want an error", imp, err)
+	}
+}
@@ -214,6 +214,9 @@ func convertMonitorPriorityToCandidate(row ntpdb.GetMonitorPriorityRow) monitorC
 		candidate.AccountID = &accountID
 	}
 
+	// Location, for geographic diversity
+	candidate.Location = row.Location
+
 	// Monitor IP
 	if row.MonitorIp.Valid {
 		candidate.IP = row.MonitorIp.String
@@ -270,5 +273,17 @@ func convertMonitorPriorityToCandidate(row ntpdb.GetMonitorPriorityRow) monitorC
 		candidate.PauseReason = &row.PauseReason.String
 	}
 
+	// Reliability; monitors that often don't return their
+	// results in time are unhealthy
+	if reliability, ok := row.Reliability.([]uint8); ok {
+		x := sql.NullFloat64{}
+		if err := x.Scan(reliability); err == nil && x.Valid {
+			candidate.Reliability = &x.Float64
+			if x.Float64 < minReliability {
+				candidate.IsHealthy = false
+			}
+		}
+	}
+
 	return candidate
 }
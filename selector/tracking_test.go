@@ -0,0 +1,62 @@
+package selector
+
+import (
+	"testing"
+
+	"go.ntppool.org/monitor/ntpdb"
+)
+
+func TestConvertReliability(t *testing.T) {
+	tests := []struct {
+		name        string
+		reliability interface{}
+		expected    *float64
+		healthy     bool
+	}{
+		{
+			name:        "no_lease_data",
+			reliability: nil,
+			healthy:     true,
+		},
+		{
+			name:        "reliable",
+			reliability: []uint8("0.9950"),
+			expected:    floatPtr(0.995),
+			healthy:     true,
+		},
+		{
+			name:        "flaky",
+			reliability: []uint8("0.5000"),
+			expected:    floatPtr(0.5),
+			healthy:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			row := ntpdb.GetMonitorPriorityRow{
+				ID:              1,
+				MonitorStatus:   ntpdb.MonitorsStatusActive,
+				Healthy:         int64(1),
+				MonitorPriority: 10,
+				Reliability:     tt.reliability,
+			}
+
+			candidate := convertMonitorPriorityToCandidate(row)
+
+			if candidate.IsHealthy != tt.healthy {
+				t.Errorf("IsHealthy = %v, want %v", candidate.IsHealthy, tt.healthy)
+			}
+			switch {
+			case tt.expected == nil && candidate.Reliability != nil:
+				t.Errorf("Reliability = %v, want nil", *candidate.Reliability)
+			case tt.expected != nil && (candidate.Reliability == nil || *candidate.Reliability != *tt.expected):
+				t.Errorf("Reliability = %v, want %v", candidate.Reliability, *tt.expected)
+			}
+		})
+	}
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
@@ -27,6 +27,9 @@ const (
 	violationAccount           constraintViolationType = "account"             // Same account
 	violationLimit             constraintViolationType = "limit"               // Account limit exceeded
 	violationNetworkDiversity  constraintViolationType = "network_diversity"   // Multiple monitors in same /44 or /20 network
+	violationGeoDiversity      constraintViolationType = "geo_diversity"       // Too many active monitors on one continent or in one country
+	violationNetworkSameASN    constraintViolationType = "network_same_asn"    // Monitor and server in same autonomous system
+	violationASNDiversity      constraintViolationType = "asn_diversity"       // Too many active and testing monitors in one autonomous system
 )
 
 // constraintViolation describes a constraint violation
@@ -43,6 +46,7 @@ type monitorCandidate struct {
 	TLSName                  string // Human-readable name for metrics
 	AccountID                *uint32
 	IP                       string
+	Location                 string // Configured location (monitors.location)
 	GlobalStatus             ntpdb.MonitorsStatus
 	ServerStatus             ntpdb.ServerScoresStatus
 	HasMetrics               bool
@@ -54,6 +58,8 @@ type monitorCandidate struct {
 	ConstraintViolationSince *time.Time
 	LastConstraintCheck      *time.Time // When constraint resolution was last checked
 	PauseReason              *string    // Reason why monitor was paused
+	Reliability              *float64   // Share of work leases returned on time (nil if too few)
+	OffsetBias               *float64   // Average offset minus the consensus offset (nil if no consensus)
 }
 
 // serverInfo contains server details needed for constraint checking
@@ -62,6 +68,8 @@ type serverInfo struct {
 	AccountID *uint32
 	IP        string
 	IPVersion string
+	InPool    bool
+	Netspeed  uint32 // kbit/s
 }
 
 // evaluatedMonitor combines a monitor candidate with its constraint evaluation
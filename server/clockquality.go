@@ -0,0 +1,139 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.ntppool.org/common/logger"
+	"go.ntppool.org/common/timeutil"
+	apiv2 "go.ntppool.org/monitor/gen/monitor/v2"
+	"go.ntppool.org/monitor/ntpdb"
+)
+
+const (
+	clockModeFlag   = "flag"
+	clockModeReject = "reject"
+)
+
+// ClockQualitySettings configure the checks of the clock estimate
+// agents send with each batch. They are loaded from the
+// "clockquality" system setting.
+type ClockQualitySettings struct {
+	// MaxUncertainty is the largest accepted uncertainty (the
+	// absolute median BaseCheck offset plus the dispersion)
+	MaxUncertainty timeutil.Duration `json:"max_uncertainty"`
+
+	// Mode is "flag" to only record and log batches from monitors
+	// with too much uncertainty, or "reject" to also not score
+	// results in those batches with an offset smaller than
+	// OffsetRatio times the uncertainty.
+	Mode        string  `json:"mode"`
+	OffsetRatio float64 `json:"offset_ratio"`
+}
+
+func (s *ClockQualitySettings) setDefaults() {
+	if s.MaxUncertainty.Duration <= 0 {
+		s.MaxUncertainty = timeutil.Duration{Duration: 10 * time.Millisecond}
+	}
+	if s.Mode != clockModeReject {
+		s.Mode = clockModeFlag
+	}
+	if s.OffsetRatio <= 0 {
+		s.OffsetRatio = 10
+	}
+}
+
+// clockQualitySettings returns the settings from the "clockquality"
+// system setting, with defaults for anything not set.
+func (srv *Server) clockQualitySettings(ctx context.Context) ClockQualitySettings {
+	log := logger.FromContext(ctx)
+
+	var settings ClockQualitySettings
+
+	settingsStr, err := srv.db.GetSystemSetting(ctx, "clockquality")
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.WarnContext(ctx, "could not fetch clockquality settings", "err", err)
+	}
+	if len(settingsStr) > 0 {
+		if err := json.Unmarshal([]byte(settingsStr), &settings); err != nil {
+			log.WarnContext(ctx, "could not unmarshal clockquality settings", "err", err)
+			settings = ClockQualitySettings{}
+		}
+	}
+
+	settings.setDefaults()
+
+	return settings
+}
+
+// clockCheck is the server's assessment of the clock estimate
+// submitted with a batch
+type clockCheck struct {
+	Uncertainty time.Duration
+	Flagged     bool
+	Reason      string
+}
+
+// checkClockQuality flags batches where the kernel reports the clock
+// isn't synchronized or the monitor's own uncertainty is larger than
+// the settings allow. Batches from agents that don't send a clock
+// estimate aren't flagged.
+func checkClockQuality(cq *apiv2.ClockQuality, settings ClockQualitySettings) clockCheck {
+	if cq == nil || cq.Offset == nil {
+		return clockCheck{}
+	}
+
+	offset := cq.Offset.AsDuration()
+	if offset < 0 {
+		offset = -offset
+	}
+	cc := clockCheck{Uncertainty: offset + cq.Dispersion.AsDuration()}
+
+	switch {
+	case cq.KernelSync == apiv2.KernelSyncState_KERNEL_SYNC_STATE_UNSYNCHRONIZED:
+		cc.Flagged = true
+		cc.Reason = "kernel clock not synchronized"
+	case cc.Uncertainty > settings.MaxUncertainty.Duration:
+		cc.Flagged = true
+		cc.Reason = fmt.Sprintf("uncertainty %s over %s", cc.Uncertainty, settings.MaxUncertainty.Duration)
+	}
+
+	return cc
+}
+
+// uncertain returns true if the offset the monitor reported for the
+// status is too small to be trusted given the monitor's uncertainty
+func (cc clockCheck) uncertain(status *apiv2.ServerStatus, settings ClockQualitySettings) bool {
+	if !cc.Flagged || status.NoResponse || status.Offset == nil {
+		return false
+	}
+	return float64(*status.AbsoluteOffset()) < settings.OffsetRatio*float64(cc.Uncertainty)
+}
+
+// monitorBatchClock sets the clock estimate fields for the batch
+// record
+func monitorBatchClock(p *ntpdb.InsertMonitorBatchParams, cq *apiv2.ClockQuality, cc clockCheck) {
+	p.ClockFlagged = cc.Flagged
+	if cq == nil {
+		return
+	}
+	if cq.Offset != nil {
+		p.ClockOffset = sql.NullFloat64{Float64: cq.Offset.AsDuration().Seconds(), Valid: true}
+	}
+	if cq.Dispersion != nil {
+		p.ClockDispersion = sql.NullFloat64{Float64: cq.Dispersion.AsDuration().Seconds(), Valid: true}
+	}
+	if cq.KernelMaxError != nil {
+		p.ClockMaxError = sql.NullFloat64{Float64: cq.KernelMaxError.AsDuration().Seconds(), Valid: true}
+	}
+	switch cq.KernelSync {
+	case apiv2.KernelSyncState_KERNEL_SYNC_STATE_SYNCHRONIZED:
+		p.ClockSynced = sql.NullBool{Bool: true, Valid: true}
+	case apiv2.KernelSyncState_KERNEL_SYNC_STATE_UNSYNCHRONIZED:
+		p.ClockSynced = sql.NullBool{Bool: false, Valid: true}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"go.ntppool.org/monitor/ntpdb"
 )
@@ -40,6 +41,22 @@ func (cmd *dbMonitorCmd) Run(ctx context.Context) error {
 
 	for _, mon := range mons {
 		fmt.Printf("Monitor: %+v\n", mon)
+
+		leases, err := db.GetMonitorLeaseStats(ctx, ntpdb.GetMonitorLeaseStatsParams{
+			MonitorID: mon.ID,
+			Date:      time.Now().AddDate(0, 0, -7),
+		})
+		if err != nil {
+			return err
+		}
+		if leases.Issued > 0 {
+			fmt.Printf("Leases (7 days): issued %d, returned on time %d (%.1f%%), late %d\n",
+				leases.Issued, leases.Returned,
+				100*float64(leases.Returned)/float64(leases.Issued),
+				leases.Late,
+			)
+		}
+
 		smon, err := ntpdb.GetSystemMonitor(ctx, db, "settings", mon.IpVersion)
 		if err == nil {
 			mconf, err := mon.GetConfigWithDefaults([]byte(smon.Config))
@@ -9,6 +9,7 @@ import (
 
 	"go.ntppool.org/common/logger"
 	apiv2 "go.ntppool.org/monitor/gen/monitor/v2"
+	"go.ntppool.org/monitor/ntpdb"
 )
 
 type conServer struct {
@@ -59,29 +60,29 @@ func (cs *conServer) GetServers(ctx context.Context, req *connect.Request[apiv2.
 		Config:  cfg,
 	})
 
+	if serverList.monitor == nil {
+		log.ErrorContext(ctx, "serverList.monitor is nil")
+	}
+
+	if cs.srv == nil {
+		log.ErrorContext(ctx, "cs.srv is nil")
+	}
+
 	pServers := []*apiv2.Server{}
 	for _, server := range serverList.Servers {
-		pServer := &apiv2.Server{}
-
-		ip, err := netip.ParseAddr(server.Ip)
+		pServer, err := cs.apiServer(serverList, server)
 		if err != nil {
 			return nil, err
 		}
-		pServer.IpBytes, _ = ip.MarshalBinary()
-
-		if serverList.monitor == nil {
-			log.ErrorContext(ctx, "serverList.monitor is nil")
-		}
-
-		if cs.srv == nil {
-			log.ErrorContext(ctx, "cs.srv is nil")
-		}
+		pServers = append(pServers, pServer)
+	}
 
-		pServer.Ticket, err = cs.srv.SignIPs(serverList.monitor.ID, serverList.BatchID, &ip)
+	for _, server := range serverList.Traceroutes {
+		pServer, err := cs.apiServer(serverList, server)
 		if err != nil {
 			return nil, err
 		}
-
+		pServer.Trace = true
 		pServers = append(pServers, pServer)
 	}
 
@@ -90,6 +91,23 @@ func (cs *conServer) GetServers(ctx context.Context, req *connect.Request[apiv2.
 	return resp, nil
 }
 
+func (cs *conServer) apiServer(serverList *ServerListResponse, server ntpdb.Server) (*apiv2.Server, error) {
+	pServer := &apiv2.Server{}
+
+	ip, err := netip.ParseAddr(server.Ip)
+	if err != nil {
+		return nil, err
+	}
+	pServer.IpBytes, _ = ip.MarshalBinary()
+
+	pServer.Ticket, err = cs.srv.SignIPs(serverList.monitor.ID, serverList.BatchID, &ip)
+	if err != nil {
+		return nil, err
+	}
+
+	return pServer, nil
+}
+
 func (cs *conServer) SubmitResults(ctx context.Context, req *connect.Request[apiv2.SubmitResultsRequest]) (*connect.Response[apiv2.SubmitResultsResponse], error) {
 	msg := req.Msg
 	if msg == nil {
@@ -100,9 +118,21 @@ func (cs *conServer) SubmitResults(ctx context.Context, req *connect.Request[api
 		Version: msg.Version,
 		List:    msg.List,
 		BatchId: msg.BatchId,
+		Clock:   msg.Clock,
+	}
+
+	results, err := cs.srv.SubmitResults(ctx, p, req.Msg.MonId)
+
+	return connect.NewResponse(&apiv2.SubmitResultsResponse{Ok: err == nil, Results: results}), err
+}
+
+func (cs *conServer) SubmitTraceroute(ctx context.Context, req *connect.Request[apiv2.SubmitTracerouteRequest]) (*connect.Response[apiv2.SubmitTracerouteResponse], error) {
+	msg := req.Msg
+	if msg == nil {
+		return nil, fmt.Errorf("missing message")
 	}
 
-	ok, err := cs.srv.SubmitResults(ctx, p, req.Msg.MonId)
+	ok, err := cs.srv.SubmitTraceroute(ctx, msg)
 
-	return connect.NewResponse(&apiv2.SubmitResultsResponse{Ok: ok}), err
+	return connect.NewResponse(&apiv2.SubmitTracerouteResponse{Ok: ok}), err
 }
@@ -0,0 +1,294 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"maps"
+	"slices"
+	"time"
+
+	"go.ntppool.org/monitor/ntpdb"
+)
+
+// fakeDB is an in-memory ntpdb.QuerierTx with the queries the API
+// uses. Other queries panic. Begin saves the state and Rollback
+// restores it, so transactions can be nested but not concurrent.
+type fakeDB struct {
+	ntpdb.QuerierTx
+
+	state fakeState
+	saved []fakeState
+
+	// failIPs make GetServerIP fail for the IPs
+	failIPs map[string]bool
+
+	// returned by the response count queries
+	peerCounts    ntpdb.GetPeerResponseCountsRow
+	monitorCounts ntpdb.GetMonitorResponseCountsRow
+}
+
+type fakeState struct {
+	settings     map[string]string
+	servers      map[uint32]ntpdb.Server
+	serverScores map[[2]uint32]ntpdb.ServerScore           // monitor and server id
+	traceQueue   map[[2]uint32]fakeTraceQueue              // monitor and server id
+	batches      map[string]ntpdb.InsertMonitorBatchParams // batch id
+	logScores    []ntpdb.InsertLogScoreParams
+	lastSubmit   sql.NullTime
+	leaseStats   ntpdb.AddMonitorLeasesReturnedParams
+}
+
+type fakeTraceQueue struct {
+	queueTs        sql.NullTime
+	sentTs         sql.NullTime
+	lastTraceroute sql.NullTime
+}
+
+func newFakeDB() *fakeDB {
+	return &fakeDB{
+		state: fakeState{
+			settings:     map[string]string{},
+			servers:      map[uint32]ntpdb.Server{},
+			serverScores: map[[2]uint32]ntpdb.ServerScore{},
+			traceQueue:   map[[2]uint32]fakeTraceQueue{},
+			batches:      map[string]ntpdb.InsertMonitorBatchParams{},
+		},
+	}
+}
+
+func (s fakeState) clone() fakeState {
+	return fakeState{
+		settings:     maps.Clone(s.settings),
+		servers:      maps.Clone(s.servers),
+		serverScores: maps.Clone(s.serverScores),
+		traceQueue:   maps.Clone(s.traceQueue),
+		batches:      maps.Clone(s.batches),
+		logScores:    slices.Clone(s.logScores),
+		lastSubmit:   s.lastSubmit,
+		leaseStats:   s.leaseStats,
+	}
+}
+
+func (db *fakeDB) addServer(id uint32, ip string) {
+	db.state.servers[id] = ntpdb.Server{ID: id, Ip: ip}
+}
+
+// addServerScore assigns the server to the monitor
+func (db *fakeDB) addServerScore(monitorID, serverID uint32) {
+	db.state.serverScores[[2]uint32{monitorID, serverID}] = ntpdb.ServerScore{
+		ID:        uint64(len(db.state.serverScores) + 1),
+		MonitorID: monitorID,
+		ServerID:  serverID,
+		Status:    ntpdb.ServerScoresStatusActive,
+	}
+}
+
+func (db *fakeDB) serverScore(monitorID, serverID uint32) ntpdb.ServerScore {
+	return db.state.serverScores[[2]uint32{monitorID, serverID}]
+}
+
+// updateServerScore runs fn on the server score with the id
+func (db *fakeDB) updateServerScore(id uint64, fn func(ss *ntpdb.ServerScore)) {
+	for key, ss := range db.state.serverScores {
+		if ss.ID == id {
+			fn(&ss)
+			db.state.serverScores[key] = ss
+		}
+	}
+}
+
+func (db *fakeDB) Begin(ctx context.Context) (ntpdb.QuerierTx, error) {
+	db.saved = append(db.saved, db.state.clone())
+	return db, nil
+}
+
+func (db *fakeDB) Commit(ctx context.Context) error {
+	db.saved = db.saved[:len(db.saved)-1]
+	return nil
+}
+
+func (db *fakeDB) Rollback(ctx context.Context) error {
+	db.state = db.saved[len(db.saved)-1]
+	db.saved = db.saved[:len(db.saved)-1]
+	return nil
+}
+
+func (db *fakeDB) GetSystemSetting(ctx context.Context, key string) (string, error) {
+	value, ok := db.state.settings[key]
+	if !ok {
+		return "", sql.ErrNoRows
+	}
+	return value, nil
+}
+
+func (db *fakeDB) GetServerIP(ctx context.Context, ip string) (ntpdb.Server, error) {
+	if db.failIPs[ip] {
+		return ntpdb.Server{}, errors.New("connection lost")
+	}
+	for _, s := range db.state.servers {
+		if s.Ip == ip {
+			return s, nil
+		}
+	}
+	return ntpdb.Server{}, sql.ErrNoRows
+}
+
+func (db *fakeDB) QueueTraceroute(ctx context.Context, arg ntpdb.QueueTracerouteParams) error {
+	key := [2]uint32{arg.MonitorID, arg.ServerID}
+	tq, ok := db.state.traceQueue[key]
+	if ok && (tq.queueTs.Valid || (tq.lastTraceroute.Valid && !tq.lastTraceroute.Time.Before(arg.LastTracerouteBefore.Time))) {
+		return nil
+	}
+	tq.queueTs = sql.NullTime{Time: time.Now(), Valid: true}
+	db.state.traceQueue[key] = tq
+	return nil
+}
+
+func (db *fakeDB) GetTracerouteQueue(ctx context.Context, arg ntpdb.GetTracerouteQueueParams) ([]ntpdb.Server, error) {
+	type queued struct {
+		ts     time.Time
+		server ntpdb.Server
+	}
+	list := []queued{}
+	for key, tq := range db.state.traceQueue {
+		if key[0] != arg.MonitorID || !tq.queueTs.Valid {
+			continue
+		}
+		if tq.sentTs.Valid && !tq.sentTs.Time.Before(arg.SentBefore.Time) {
+			continue
+		}
+		list = append(list, queued{tq.queueTs.Time, db.state.servers[key[1]]})
+	}
+	slices.SortFunc(list, func(a, b queued) int {
+		return a.ts.Compare(b.ts)
+	})
+
+	servers := []ntpdb.Server{}
+	for i, q := range list {
+		if i >= int(arg.Limit) {
+			break
+		}
+		servers = append(servers, q.server)
+	}
+	return servers, nil
+}
+
+func (db *fakeDB) UpdateTracerouteQueueSent(ctx context.Context, arg ntpdb.UpdateTracerouteQueueSentParams) error {
+	for _, id := range arg.ServerIds {
+		key := [2]uint32{arg.MonitorID, id}
+		if tq, ok := db.state.traceQueue[key]; ok {
+			tq.sentTs = arg.SentTs
+			db.state.traceQueue[key] = tq
+		}
+	}
+	return nil
+}
+
+func (db *fakeDB) UpdateTracerouteQueueDone(ctx context.Context, arg ntpdb.UpdateTracerouteQueueDoneParams) error {
+	key := [2]uint32{arg.MonitorID, arg.ServerID}
+	if _, ok := db.state.traceQueue[key]; ok {
+		db.state.traceQueue[key] = fakeTraceQueue{lastTraceroute: arg.LastTraceroute}
+	}
+	return nil
+}
+
+func (db *fakeDB) GetServerScore(ctx context.Context, arg ntpdb.GetServerScoreParams) (ntpdb.ServerScore, error) {
+	ss, ok := db.state.serverScores[[2]uint32{arg.MonitorID, arg.ServerID}]
+	if !ok {
+		return ntpdb.ServerScore{}, sql.ErrNoRows
+	}
+	return ss, nil
+}
+
+func (db *fakeDB) UpdateServerScore(ctx context.Context, arg ntpdb.UpdateServerScoreParams) error {
+	db.updateServerScore(arg.ID, func(ss *ntpdb.ServerScore) {
+		ss.ScoreTs = arg.ScoreTs
+		ss.ScoreRaw = arg.ScoreRaw
+	})
+	return nil
+}
+
+func (db *fakeDB) UpdateServerScoreStratum(ctx context.Context, arg ntpdb.UpdateServerScoreStratumParams) error {
+	db.updateServerScore(arg.ID, func(ss *ntpdb.ServerScore) {
+		ss.Stratum = arg.Stratum
+	})
+	return nil
+}
+
+func (db *fakeDB) UpdateServerStratum(ctx context.Context, arg ntpdb.UpdateServerStratumParams) error {
+	s := db.state.servers[arg.ID]
+	s.Stratum = arg.Stratum
+	db.state.servers[arg.ID] = s
+	return nil
+}
+
+func (db *fakeDB) ClearServerScoreLease(ctx context.Context, id uint64) error {
+	db.updateServerScore(id, func(ss *ntpdb.ServerScore) {
+		ss.LeaseExpires = sql.NullTime{}
+	})
+	return nil
+}
+
+func (db *fakeDB) InsertLogScore(ctx context.Context, arg ntpdb.InsertLogScoreParams) (sql.Result, error) {
+	db.state.logScores = append(db.state.logScores, arg)
+	return fakeResult(len(db.state.logScores)), nil
+}
+
+func (db *fakeDB) UpdateMonitorSubmit(ctx context.Context, arg ntpdb.UpdateMonitorSubmitParams) error {
+	db.state.lastSubmit = arg.LastSubmit
+	return nil
+}
+
+func (db *fakeDB) GetMonitorBatchResults(ctx context.Context, arg ntpdb.GetMonitorBatchResultsParams) ([]byte, error) {
+	batch, ok := db.state.batches[arg.BatchID]
+	if !ok || batch.MonitorID != arg.MonitorID {
+		return nil, sql.ErrNoRows
+	}
+	return batch.Results, nil
+}
+
+func (db *fakeDB) InsertMonitorBatch(ctx context.Context, arg ntpdb.InsertMonitorBatchParams) error {
+	db.state.batches[arg.BatchID] = arg
+	return nil
+}
+
+func (db *fakeDB) DeleteMonitorBatches(ctx context.Context, arg ntpdb.DeleteMonitorBatchesParams) error {
+	return nil
+}
+
+func (db *fakeDB) GetServerScoreLeases(ctx context.Context, arg ntpdb.GetServerScoreLeasesParams) ([]ntpdb.GetServerScoreLeasesRow, error) {
+	leases := []ntpdb.GetServerScoreLeasesRow{}
+	for _, ip := range arg.Ips {
+		server, err := db.GetServerIP(ctx, ip)
+		if err != nil {
+			continue
+		}
+		ss, ok := db.state.serverScores[[2]uint32{arg.MonitorID, server.ID}]
+		if ok && ss.LeaseExpires.Valid {
+			leases = append(leases, ntpdb.GetServerScoreLeasesRow{ID: ss.ID, LeaseExpires: ss.LeaseExpires})
+		}
+	}
+	return leases, nil
+}
+
+func (db *fakeDB) AddMonitorLeasesReturned(ctx context.Context, arg ntpdb.AddMonitorLeasesReturnedParams) error {
+	db.state.leaseStats.MonitorID = arg.MonitorID
+	db.state.leaseStats.Returned += arg.Returned
+	db.state.leaseStats.Late += arg.Late
+	return nil
+}
+
+func (db *fakeDB) GetPeerResponseCounts(ctx context.Context, arg ntpdb.GetPeerResponseCountsParams) (ntpdb.GetPeerResponseCountsRow, error) {
+	return db.peerCounts, nil
+}
+
+func (db *fakeDB) GetMonitorResponseCounts(ctx context.Context, arg ntpdb.GetMonitorResponseCountsParams) (ntpdb.GetMonitorResponseCountsRow, error) {
+	return db.monitorCounts, nil
+}
+
+// fakeResult is the sql.Result for an insert
+type fakeResult int64
+
+func (r fakeResult) LastInsertId() (int64, error) { return int64(r), nil }
+func (r fakeResult) RowsAffected() (int64, error) { return 1, nil }
@@ -29,6 +29,10 @@ type MonitorSettings struct {
 	IntervalTesting timeutil.Duration `json:"interval_testing"`
 	IntervalAll     timeutil.Duration `json:"interval_all"`
 	BatchSize       int32             `json:"batch_size"`
+
+	// LeaseTime is how long the monitor has to submit the results
+	// for a batch before the servers are handed out again
+	LeaseTime timeutil.Duration `json:"lease_time"`
 }
 
 func (srv *Server) getMonitor(ctx context.Context, monIP string) (*ntpdb.Monitor, *ntpdb.Account, context.Context, error) {
@@ -180,10 +184,11 @@ func (srv *Server) ValidateIPs(signature []byte, monitorID uint32, batchID []byt
 }
 
 type ServerListResponse struct {
-	BatchID []byte
-	Config  *ntpdb.MonitorConfig
-	Servers []ntpdb.Server
-	monitor *ntpdb.Monitor
+	BatchID     []byte
+	Config      *ntpdb.MonitorConfig
+	Servers     []ntpdb.Server
+	Traceroutes []ntpdb.Server
+	monitor     *ntpdb.Monitor
 }
 
 func (srv *Server) GetServers(ctx context.Context, monID string) (*ServerListResponse, error) {
@@ -231,6 +236,9 @@ func (srv *Server) GetServers(ctx context.Context, monID string) (*ServerListRes
 	if settings.BatchSize <= 0 {
 		settings.BatchSize = 10
 	}
+	if settings.LeaseTime.Seconds() < 30 {
+		settings.LeaseTime = timeutil.Duration{Duration: 5 * time.Minute}
+	}
 
 	// log.Debug("interval settings", "intervals", settings)
 
@@ -283,6 +291,11 @@ func (srv *Server) GetServers(ctx context.Context, monID string) (*ServerListRes
 			monitor: monitor,
 		}
 
+		list.Traceroutes, err = getTraceroutes(ctx, db, monitor)
+		if err != nil {
+			return err
+		}
+
 		if count := len(servers); count > 0 {
 			accountIDToken := ""
 			accountID := "0"
@@ -297,7 +310,7 @@ func (srv *Server) GetServers(ctx context.Context, monID string) (*ServerListRes
 				accountID,
 			).Add(float64(count))
 
-			now := sql.NullTime{Time: time.Now(), Valid: true}
+			now := time.Now()
 
 			ids := make([]uint32, len(servers))
 			for i, s := range servers {
@@ -306,15 +319,24 @@ func (srv *Server) GetServers(ctx context.Context, monID string) (*ServerListRes
 
 			err = db.UpdateServerScoreQueue(ctx,
 				ntpdb.UpdateServerScoreQueueParams{
-					MonitorID: monitor.ID,
-					QueueTs:   now,
-					ServerIds: ids,
+					MonitorID:    monitor.ID,
+					QueueTs:      sql.NullTime{Time: now, Valid: true},
+					LeaseExpires: sql.NullTime{Time: now.Add(settings.LeaseTime.Duration), Valid: true},
+					ServerIds:    ids,
 				},
 			)
 			if err != nil {
 				return err
 			}
 
+			err = db.AddMonitorLeasesIssued(ctx, ntpdb.AddMonitorLeasesIssuedParams{
+				MonitorID: monitor.ID,
+				Issued:    uint32(count),
+			})
+			if err != nil {
+				return err
+			}
+
 		}
 
 		return nil
@@ -8,6 +8,8 @@ type Metrics struct {
 	r              prometheus.Registerer
 	TestsRequested *prometheus.CounterVec
 	TestsCompleted *prometheus.CounterVec
+	Outages        *prometheus.CounterVec
+	ClockFlagged   *prometheus.CounterVec
 }
 
 func New(r prometheus.Registerer) *Metrics {
@@ -44,6 +46,24 @@ func New(r prometheus.Registerer) *Metrics {
 	m.TestsRequested = requestCounters["tests_requested_total"]
 	m.TestsCompleted = requestCounters["tests_completed_total"]
 
+	m.Outages = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "monitor_outages_total",
+			Help: "count of batches that looked like a monitor network outage",
+		},
+		[]string{"monitor", "ip_version", "mode"},
+	)
+	r.MustRegister(m.Outages)
+
+	m.ClockFlagged = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "monitor_clock_flagged_total",
+			Help: "count of batches where the monitor's clock estimate was too uncertain",
+		},
+		[]string{"monitor", "ip_version"},
+	)
+	r.MustRegister(m.ClockFlagged)
+
 	return m
 }
 
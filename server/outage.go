@@ -0,0 +1,173 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"go.ntppool.org/common/logger"
+	"go.ntppool.org/common/timeutil"
+	apiv2 "go.ntppool.org/monitor/gen/monitor/v2"
+	"go.ntppool.org/monitor/ntpdb"
+)
+
+const (
+	outageModeQuarantine = "quarantine"
+	outageModeDownweight = "downweight"
+)
+
+// OutageSettings configure how batches that look like the monitor
+// lost its network connection are handled. They are loaded from the
+// "outage" system setting.
+type OutageSettings struct {
+	// MinResults is the smallest batch that's checked
+	MinResults int `json:"min_results"`
+	// FailureRate is the share of results without a response
+	// that makes a batch suspicious
+	FailureRate float64 `json:"failure_rate"`
+	// ResponseRate is the share of responses other monitors (or the
+	// monitor itself, recently) must have gotten for the batch to
+	// be considered an outage on the monitor side
+	ResponseRate float64 `json:"response_rate"`
+	// Lookback is how far back results are compared
+	Lookback timeutil.Duration `json:"lookback"`
+
+	// Mode is "quarantine" to not score the timeouts in the batch,
+	// or "downweight" to score them with Weight times the step
+	Mode   string  `json:"mode"`
+	Weight float64 `json:"weight"`
+}
+
+func defaultOutageSettings() OutageSettings {
+	return OutageSettings{
+		MinResults:   4,
+		FailureRate:  0.75,
+		ResponseRate: 0.8,
+		Lookback:     timeutil.Duration{Duration: 30 * time.Minute},
+		Mode:         outageModeQuarantine,
+		Weight:       0.2,
+	}
+}
+
+func (s *OutageSettings) setDefaults() {
+	defaults := defaultOutageSettings()
+	if s.MinResults <= 0 {
+		s.MinResults = defaults.MinResults
+	}
+	if s.FailureRate <= 0 {
+		s.FailureRate = defaults.FailureRate
+	}
+	if s.ResponseRate <= 0 {
+		s.ResponseRate = defaults.ResponseRate
+	}
+	if s.Lookback.Duration <= 0 {
+		s.Lookback = defaults.Lookback
+	}
+	if s.Mode != outageModeDownweight {
+		s.Mode = outageModeQuarantine
+	}
+	if s.Weight < 0 || s.Weight > 1 {
+		s.Weight = defaults.Weight
+	}
+}
+
+// outageSettings returns the settings from the "outage" system
+// setting, with defaults for anything not set.
+func (srv *Server) outageSettings(ctx context.Context) OutageSettings {
+	log := logger.FromContext(ctx)
+
+	var settings OutageSettings
+
+	settingsStr, err := srv.db.GetSystemSetting(ctx, "outage")
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.WarnContext(ctx, "could not fetch outage settings", "err", err)
+	}
+	if len(settingsStr) > 0 {
+		if err := json.Unmarshal([]byte(settingsStr), &settings); err != nil {
+			log.WarnContext(ctx, "could not unmarshal outage settings", "err", err)
+			settings = OutageSettings{}
+		}
+	}
+
+	settings.setDefaults()
+
+	return settings
+}
+
+// monitorOutage is the result of checking a batch for a monitor
+// side network outage
+type monitorOutage struct {
+	Detected     bool
+	FailureRate  float64 // no response share in the batch
+	PeerRate     float64 // response share for other monitors, -1 if unknown
+	MonitorRate  float64 // recent response share for the monitor, -1 if unknown
+	PeerResults  int64
+	RecentResult int64
+}
+
+// checkMonitorOutage looks for batches where most servers didn't
+// respond to the monitor while other monitors (or the same monitor
+// in its recent batches) got responses. Those are most likely
+// caused by the monitor losing its network connection rather than
+// by the servers.
+func (srv *Server) checkMonitorOutage(ctx context.Context, monitor *ntpdb.Monitor, settings OutageSettings, list []*apiv2.ServerStatus, results []apiv2.ResultStatus) (monitorOutage, error) {
+	mo := monitorOutage{PeerRate: -1, MonitorRate: -1}
+
+	total, failed := 0, 0
+	ips := []string{}
+	for i, status := range list {
+		if results[i] != apiv2.ResultStatus_RESULT_STATUS_ACCEPTED {
+			continue
+		}
+		total++
+		if status.NoResponse {
+			failed++
+			ips = append(ips, status.GetIP().String())
+		}
+	}
+
+	if total < settings.MinResults {
+		return mo, nil
+	}
+	mo.FailureRate = float64(failed) / float64(total)
+	if mo.FailureRate < settings.FailureRate {
+		return mo, nil
+	}
+
+	since := time.Now().Add(-settings.Lookback.Duration)
+	monitorID := sql.NullInt32{Int32: int32(monitor.ID), Valid: true}
+
+	peers, err := srv.db.GetPeerResponseCounts(ctx, ntpdb.GetPeerResponseCountsParams{
+		Ips:       ips,
+		MonitorID: monitorID,
+		Since:     since,
+	})
+	if err != nil {
+		return mo, err
+	}
+	mo.PeerResults = peers.Total
+	if peers.Total >= int64(settings.MinResults) {
+		mo.PeerRate = float64(peers.Responses) / float64(peers.Total)
+		mo.Detected = mo.PeerRate >= settings.ResponseRate
+		return mo, nil
+	}
+
+	// not enough results from other monitors; compare with the
+	// monitor's own recent batches
+	recent, err := srv.db.GetMonitorResponseCounts(ctx, ntpdb.GetMonitorResponseCountsParams{
+		MonitorID: monitorID,
+		Ts:        since,
+	})
+	if err != nil {
+		return mo, err
+	}
+	mo.RecentResult = recent.Total
+	if recent.Total >= int64(settings.MinResults) {
+		mo.MonitorRate = float64(recent.Responses) / float64(recent.Total)
+		mo.Detected = mo.MonitorRate >= settings.ResponseRate
+	}
+
+	return mo, nil
+}
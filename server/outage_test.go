@@ -0,0 +1,181 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"testing"
+	"time"
+
+	apiv2 "go.ntppool.org/monitor/gen/monitor/v2"
+	"go.ntppool.org/monitor/ntpdb"
+)
+
+func TestCheckMonitorOutage(t *testing.T) {
+	ctx := context.Background()
+	monitor := &ntpdb.Monitor{ID: 1}
+	settings := defaultOutageSettings()
+
+	// batch returns n results, the first failed of them timeouts
+	batch := func(n, failed int) ([]*apiv2.ServerStatus, []apiv2.ResultStatus) {
+		list := []*apiv2.ServerStatus{}
+		results := []apiv2.ResultStatus{}
+		for i := range n {
+			status := &apiv2.ServerStatus{NoResponse: i < failed}
+			ip := netip.MustParseAddr(fmt.Sprintf("198.51.100.%d", 10+i))
+			status.SetIP(&ip)
+			list = append(list, status)
+			results = append(results, apiv2.ResultStatus_RESULT_STATUS_ACCEPTED)
+		}
+		return list, results
+	}
+
+	tests := []struct {
+		name        string
+		n, failed   int
+		peers       ntpdb.GetPeerResponseCountsRow
+		recent      ntpdb.GetMonitorResponseCountsRow
+		detected    bool
+		peerRate    float64
+		monitorRate float64
+	}{
+		{
+			name: "small batch", n: 3, failed: 3,
+			peers:    ntpdb.GetPeerResponseCountsRow{Total: 20, Responses: 20},
+			peerRate: -1, monitorRate: -1,
+		},
+		{
+			name: "few timeouts", n: 10, failed: 5,
+			peers:    ntpdb.GetPeerResponseCountsRow{Total: 20, Responses: 20},
+			peerRate: -1, monitorRate: -1,
+		},
+		{
+			name: "peers got responses", n: 10, failed: 9,
+			peers:    ntpdb.GetPeerResponseCountsRow{Total: 20, Responses: 18},
+			detected: true, peerRate: 0.9, monitorRate: -1,
+		},
+		{
+			name: "servers down for the peers too", n: 10, failed: 10,
+			peers:    ntpdb.GetPeerResponseCountsRow{Total: 20, Responses: 2},
+			peerRate: 0.1, monitorRate: -1,
+		},
+		{
+			name: "no peers, monitor got responses recently", n: 10, failed: 10,
+			peers:    ntpdb.GetPeerResponseCountsRow{Total: 2, Responses: 2},
+			recent:   ntpdb.GetMonitorResponseCountsRow{Total: 100, Responses: 95},
+			detected: true, peerRate: -1, monitorRate: 0.95,
+		},
+		{
+			name: "no peers, monitor failing recently", n: 10, failed: 10,
+			recent:   ntpdb.GetMonitorResponseCountsRow{Total: 100, Responses: 10},
+			peerRate: -1, monitorRate: 0.1,
+		},
+		{
+			name: "no results to compare", n: 10, failed: 10,
+			peerRate: -1, monitorRate: -1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newFakeDB()
+			db.peerCounts = tt.peers
+			db.monitorCounts = tt.recent
+			srv := &Server{db: db}
+
+			list, results := batch(tt.n, tt.failed)
+			mo, err := srv.checkMonitorOutage(ctx, monitor, settings, list, results)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mo.Detected != tt.detected {
+				t.Errorf("detected %t, want %t (%+v)", mo.Detected, tt.detected, mo)
+			}
+			if mo.PeerRate != tt.peerRate || mo.MonitorRate != tt.monitorRate {
+				t.Errorf("peer rate %f monitor rate %f, want %f and %f",
+					mo.PeerRate, mo.MonitorRate, tt.peerRate, tt.monitorRate)
+			}
+		})
+	}
+
+	// results that weren't accepted aren't part of the check
+	db := newFakeDB()
+	db.peerCounts = ntpdb.GetPeerResponseCountsRow{Total: 20, Responses: 20}
+	srv := &Server{db: db}
+	list, results := batch(10, 10)
+	for i := range 7 {
+		results[i] = apiv2.ResultStatus_RESULT_STATUS_BAD_SIGNATURE
+	}
+	mo, err := srv.checkMonitorOutage(ctx, monitor, settings, list, results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mo.Detected {
+		t.Errorf("outage detected from %d accepted results", 3)
+	}
+}
+
+func TestSubmitResultsQuarantine(t *testing.T) {
+	ips := []string{"198.51.100.10", "198.51.100.11", "198.51.100.12", "198.51.100.13"}
+	srv, db, ctx, monitor := newSubmitTest(t, ips...)
+
+	db.peerCounts = ntpdb.GetPeerResponseCountsRow{Total: 20, Responses: 20}
+
+	results, err := srv.SubmitResults(ctx, testBatch(time.Now(), true, ips...), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, rs := range results {
+		if rs != apiv2.ResultStatus_RESULT_STATUS_QUARANTINED {
+			t.Fatalf("result %d status %s, want quarantined", i, rs)
+		}
+	}
+
+	// the timeouts are stored flagged as quarantined, but don't
+	// change the scores or queue traceroutes
+	if len(db.state.logScores) != len(ips) {
+		t.Fatalf("got %d log scores, want %d", len(db.state.logScores), len(ips))
+	}
+	for _, ls := range db.state.logScores {
+		var attributes ntpdb.LogScoreAttributes
+		if err := json.Unmarshal([]byte(ls.Attributes.String), &attributes); err != nil {
+			t.Fatal(err)
+		}
+		if !attributes.Quarantined || !attributes.NoResponse {
+			t.Errorf("log score for server %d attributes %s", ls.ServerID, ls.Attributes.String)
+		}
+	}
+	for i := range ips {
+		if ss := db.serverScore(monitor.ID, uint32(10+i)); ss.ScoreTs.Valid {
+			t.Errorf("quarantined result updated the server score: %+v", ss)
+		}
+	}
+	if len(db.state.traceQueue) != 0 {
+		t.Errorf("quarantined results queued %d traceroutes", len(db.state.traceQueue))
+	}
+
+	// timeouts without an outage are scored
+	db.peerCounts = ntpdb.GetPeerResponseCountsRow{Total: 20, Responses: 1}
+	results, err = srv.SubmitResults(ctx, testBatch(time.Now().Add(time.Second), true, ips...), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, rs := range results {
+		if rs != apiv2.ResultStatus_RESULT_STATUS_ACCEPTED {
+			t.Fatalf("result %d status %s, want accepted", i, rs)
+		}
+	}
+	for _, ls := range db.state.logScores[len(ips):] {
+		var attributes ntpdb.LogScoreAttributes
+		if err := json.Unmarshal([]byte(ls.Attributes.String), &attributes); err != nil {
+			t.Fatal(err)
+		}
+		if attributes.Quarantined || !attributes.NoResponse {
+			t.Errorf("log score for server %d attributes %s", ls.ServerID, ls.Attributes.String)
+		}
+	}
+	if ss := db.serverScore(monitor.ID, 10); !ss.ScoreTs.Valid || ss.ScoreRaw >= 0 {
+		t.Errorf("timeout didn't lower the server score: %+v", ss)
+	}
+}
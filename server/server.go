@@ -44,6 +44,9 @@ type Server struct {
 	jwtAuth     *JWTAuthenticator
 	clientCAs   *x509.CertPool
 	shutdownFns []func(ctx context.Context) error
+
+	// settings caches the system settings for SubmitResults
+	settings settingsCache
 }
 
 type Config struct {
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.ntppool.org/monitor/scorer/score"
+	"go.ntppool.org/monitor/scorer/statusscore"
+)
+
+// settingsTTL is how long the system settings for scoring results
+// are cached; changes apply to the batches submitted after that.
+const settingsTTL = time.Minute
+
+// submitSettings are the system settings SubmitResults uses
+type submitSettings struct {
+	statusScore  statusscore.Settings
+	decay        score.Decay
+	outage       OutageSettings
+	clockQuality ClockQualitySettings
+}
+
+// settingsCache keeps the parsed settings so they aren't read from
+// the database for every batch
+type settingsCache struct {
+	mu       sync.Mutex
+	settings submitSettings
+	expires  time.Time
+}
+
+// submitSettings returns the cached settings, reading them from the
+// database again when they are older than settingsTTL.
+func (srv *Server) submitSettings(ctx context.Context) submitSettings {
+	c := &srv.settings
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if now.Before(c.expires) {
+		return c.settings
+	}
+
+	c.settings = submitSettings{
+		statusScore:  srv.statusScoreSettings(ctx),
+		decay:        srv.scoreDecay(ctx),
+		outage:       srv.outageSettings(ctx),
+		clockQuality: srv.clockQualitySettings(ctx),
+	}
+	c.expires = now.Add(settingsTTL)
+
+	return c.settings
+}
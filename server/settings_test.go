@@ -0,0 +1,27 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSubmitSettingsCache(t *testing.T) {
+	ctx := context.Background()
+	db := newFakeDB()
+	srv := &Server{db: db}
+
+	if mode := srv.submitSettings(ctx).outage.Mode; mode != outageModeQuarantine {
+		t.Fatalf("outage mode %q, want the default %q", mode, outageModeQuarantine)
+	}
+
+	// changes apply once the cached settings expire
+	db.state.settings["outage"] = `{"mode": "downweight"}`
+	if mode := srv.submitSettings(ctx).outage.Mode; mode != outageModeQuarantine {
+		t.Errorf("outage mode %q before the settings expired", mode)
+	}
+	srv.settings.expires = time.Now()
+	if mode := srv.submitSettings(ctx).outage.Mode; mode != outageModeDownweight {
+		t.Errorf("outage mode %q, want %q", mode, outageModeDownweight)
+	}
+}
@@ -3,9 +3,11 @@ package server
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
+	"net/netip"
 	"strconv"
 	"strings"
 	"time"
@@ -21,9 +23,18 @@ import (
 	"go.ntppool.org/common/version"
 	apiv2 "go.ntppool.org/monitor/gen/monitor/v2"
 	"go.ntppool.org/monitor/ntpdb"
+	"go.ntppool.org/monitor/scorer/score"
 	"go.ntppool.org/monitor/scorer/statusscore"
 )
 
+// maxSubmitRetries is how many times the transaction for a batch is
+// retried without the results that caused internal errors.
+const maxSubmitRetries = 3
+
+// errUnknownServer is returned by processStatus when the server
+// isn't in the database or isn't assigned to the monitor
+var errUnknownServer = errors.New("unknown server")
+
 type CounterOpt struct {
 	Name    string
 	Counter int
@@ -35,15 +46,69 @@ type SubmitCounters struct {
 	Timeout    *CounterOpt
 	Sig        *CounterOpt
 	BatchOrder *CounterOpt
+	Unknown    *CounterOpt
+	Internal   *CounterOpt
+	Duplicate  *CounterOpt
+	Quarantine *CounterOpt
+	Clock      *CounterOpt
+}
+
+func newSubmitCounters() *SubmitCounters {
+	return &SubmitCounters{
+		Ok:         &CounterOpt{"ok", 0},
+		Offset:     &CounterOpt{"offset", 0},
+		Timeout:    &CounterOpt{"timeout", 0},
+		Sig:        &CounterOpt{"signature_validation", 0},
+		BatchOrder: &CounterOpt{"batch_out_of_order", 0},
+		Unknown:    &CounterOpt{"unknown_server", 0},
+		Internal:   &CounterOpt{"internal_error", 0},
+		Duplicate:  &CounterOpt{"duplicate_batch", 0},
+		Quarantine: &CounterOpt{"monitor_outage", 0},
+		Clock:      &CounterOpt{"clock_uncertain", 0},
+	}
+}
+
+func (c *SubmitCounters) list() []*CounterOpt {
+	return []*CounterOpt{
+		c.Ok, c.Offset,
+		c.Timeout, c.Sig,
+		c.BatchOrder, c.Unknown,
+		c.Internal, c.Duplicate,
+		c.Quarantine, c.Clock,
+	}
+}
+
+// add adds the counts from o
+func (c *SubmitCounters) add(o *SubmitCounters) {
+	l := o.list()
+	for i, co := range c.list() {
+		co.Counter += l[i].Counter
+	}
+}
+
+// submitFeatures are the optional parts of the submission supported
+// by the client's data version
+type submitFeatures struct {
+	Packets bool
+}
+
+// scoring is how the results in a batch are scored
+type scoring struct {
+	scorer *statusscore.StatusScorer
+	decay  score.Decay
 }
 
 type SubmitResultsParam struct {
 	Version int32
 	List    []*apiv2.ServerStatus
 	BatchId []byte
+	Clock   *apiv2.ClockQuality
 }
 
-func (srv *Server) SubmitResults(ctx context.Context, in SubmitResultsParam, monIP string) (bool, error) {
+// SubmitResults processes the results from a monitor and returns
+// the status of each result. Results with a bad signature or for an
+// unknown server are skipped without failing the rest of the batch.
+func (srv *Server) SubmitResults(ctx context.Context, in SubmitResultsParam, monIP string) ([]apiv2.ResultStatus, error) {
 	span := otrace.SpanFromContext(ctx)
 	now := time.Now()
 	log := logger.FromContext(ctx)
@@ -51,41 +116,29 @@ func (srv *Server) SubmitResults(ctx context.Context, in SubmitResultsParam, mon
 	monitor, account, ctx, err := srv.getMonitor(ctx, monIP)
 	if err != nil {
 		log.Error("get monitor error", "err", err)
-		return false, err
+		return nil, err
 	}
 
 	log = log.With("mon_id", monitor.ID)
 
 	if !monitor.IsLive() {
-		return false, twirp.PermissionDenied.Error("monitor not active")
+		return nil, twirp.PermissionDenied.Error("monitor not active")
 	}
 
-	// features := struct {
-	// 	Packets bool
-	// }{}
+	features := submitFeatures{}
 
-	if in.Version < 2 || in.Version > 4 {
-		return false, twirp.InvalidArgumentError("Version", "Unsupported data version")
+	if in.Version < 2 || in.Version > 5 {
+		return nil, twirp.InvalidArgumentError("Version", "Unsupported data version")
 	}
 
-	// if in.Version >= 5 {
-	// 	features.Packets = true
-	// }
-
-	counters := &SubmitCounters{
-		Ok:         &CounterOpt{"ok", 0},
-		Offset:     &CounterOpt{"offset", 0},
-		Timeout:    &CounterOpt{"timeout", 0},
-		Sig:        &CounterOpt{"signature_validation", 0},
-		BatchOrder: &CounterOpt{"batch_out_of_order", 0},
+	if in.Version >= 5 {
+		features.Packets = true
 	}
 
+	counters := newSubmitCounters()
+
 	defer func() {
-		for _, c := range []*CounterOpt{
-			counters.Ok, counters.Offset,
-			counters.Timeout, counters.Sig,
-			counters.BatchOrder,
-		} {
+		for _, c := range counters.list() {
 			accountIDToken := ""
 			accountID := "0"
 			if account != nil {
@@ -105,7 +158,7 @@ func (srv *Server) SubmitResults(ctx context.Context, in SubmitResultsParam, mon
 
 	batchID := ulid.ULID{}
 	if err := batchID.UnmarshalText(in.BatchId); err != nil {
-		return false, fmt.Errorf("invalid batch ID: %w", err)
+		return nil, fmt.Errorf("invalid batch ID: %w", err)
 	}
 
 	span.SetAttributes(attribute.String("batchID", batchID.String()))
@@ -120,8 +173,8 @@ func (srv *Server) SubmitResults(ctx context.Context, in SubmitResultsParam, mon
 		log.InfoContext(ctx, "monitor had no last submit!")
 	}
 
-	if batchTime.Before(lastSubmit.Time) {
-		log.Warn("new batch is older than previous batch",
+	if now.Sub(batchTime) > ntpdb.MaxBatchAge {
+		log.Warn("batch outside of the acceptance window",
 			"last_submit", lastSubmit.Time.String(),
 			"new_submit", batchTime.String(),
 		)
@@ -131,12 +184,42 @@ func (srv *Server) SubmitResults(ctx context.Context, in SubmitResultsParam, mon
 
 		span.AddEvent("Out of order batch", otrace.WithAttributes(attribute.String("previous", lastSubmit.Time.String())))
 
-		return false, fmt.Errorf("invalid batch submission")
+		return nil, twirp.InvalidArgumentError("BatchId", "batch is too old")
+	}
+
+	// the agent might resubmit a batch if it didn't get the
+	// response; acknowledge it without scoring the results again
+	previous, err := srv.db.GetMonitorBatchResults(ctx, ntpdb.GetMonitorBatchResultsParams{
+		MonitorID: monitor.ID,
+		BatchID:   batchID.String(),
+	})
+	switch {
+	case err == nil:
+		log.InfoContext(ctx, "batch already processed")
+		span.AddEvent("Duplicate batch")
+		counters.Duplicate.Counter += len(in.List)
+		return decodeResultStatus(previous), nil
+	case !errors.Is(err, sql.ErrNoRows):
+		log.ErrorContext(ctx, "could not check for duplicate batch", "err", err)
+		return nil, twirp.InternalErrorWith(err)
+	}
+
+	// don't move last_submit backwards for late batches
+	submitTime := batchTime
+
+	if batchTime.Before(lastSubmit.Time) {
+		// replayed from the agent's spool
+		log.InfoContext(ctx, "accepting late batch",
+			"last_submit", lastSubmit.Time.String(),
+			"new_submit", batchTime.String(),
+		)
+		span.AddEvent("Late batch", otrace.WithAttributes(attribute.String("previous", lastSubmit.Time.String())))
+		submitTime = lastSubmit.Time
 	}
 
 	if err := srv.db.UpdateMonitorSubmit(ctx, ntpdb.UpdateMonitorSubmitParams{
 		ID:         monitor.ID,
-		LastSubmit: sql.NullTime{Time: batchTime, Valid: true},
+		LastSubmit: sql.NullTime{Time: submitTime, Valid: true},
 		LastSeen:   sql.NullTime{Time: now, Valid: true},
 	}); err != nil {
 		// Log warning but don't fail the request
@@ -153,61 +236,263 @@ func (srv *Server) SubmitResults(ctx context.Context, in SubmitResultsParam, mon
 
 	bidb, _ := batchID.MarshalText()
 
+	settings := srv.submitSettings(ctx)
+
+	sc := scoring{
+		scorer: statusscore.NewScorerWithSettings(settings.statusScore).WithBatchID(batchID.String()),
+		decay:  settings.decay,
+	}
+
+	results := make([]apiv2.ResultStatus, len(in.List))
+
+	for i, status := range in.List {
+		results[i] = apiv2.ResultStatus_RESULT_STATUS_ACCEPTED
+
+		if in.Version > 2 {
+			ticketOk, err := srv.ValidateIPs(status.Ticket, monitor.ID, bidb, status.GetIP())
+			if err != nil || !ticketOk {
+				span.AddEvent("signature validation failed")
+				log.Error("signature validation failed", "test_ip", status.GetIP().String(), "err", err)
+				counters.Sig.Counter++
+				results[i] = apiv2.ResultStatus_RESULT_STATUS_BAD_SIGNATURE
+				continue
+			}
+		}
+
+		if !safeZeroOffset {
+			// client might have broken error handling for some
+			// network errors, so don't trust zero offset.
+			if status.Stratum == 0 && status.Offset.AsDuration() == 0 {
+				if status.Error == "" {
+					status.Offset = nil
+					status.Error = "untrusted zero offset"
+				}
+			}
+		}
+	}
+
+	// the work for these servers was returned, whether or not the
+	// results are scored
+	if err := srv.returnLeases(ctx, monitor, in.List, results); err != nil {
+		log.WarnContext(ctx, "could not update leases", "err", err)
+	}
+
+	// the monitor's own clock estimate; offsets smaller than its
+	// uncertainty don't say much about the server
+	clockSettings := settings.clockQuality
+	clock := checkClockQuality(in.Clock, clockSettings)
+	if clock.Flagged {
+		log.WarnContext(ctx, "monitor clock uncertain",
+			"reason", clock.Reason,
+			"uncertainty", clock.Uncertainty,
+			"mode", clockSettings.Mode,
+		)
+		span.AddEvent("Monitor clock uncertain", otrace.WithAttributes(
+			attribute.String("reason", clock.Reason),
+			attribute.String("mode", clockSettings.Mode),
+		))
+		srv.m.ClockFlagged.WithLabelValues(
+			monitor.TlsName.String,
+			monitor.IpVersion.MonitorsIpVersion.String(),
+		).Inc()
+
+		if clockSettings.Mode == clockModeReject {
+			for i, status := range in.List {
+				if results[i] == apiv2.ResultStatus_RESULT_STATUS_ACCEPTED && clock.uncertain(status, clockSettings) {
+					counters.Clock.Counter++
+					results[i] = apiv2.ResultStatus_RESULT_STATUS_CLOCK_UNCERTAIN
+				}
+			}
+		}
+	}
+
+	// timeouts in a batch from a monitor that lost its network
+	// connection shouldn't count against the servers
+	outageSettings := settings.outage
+	outage, err := srv.checkMonitorOutage(ctx, monitor, outageSettings, in.List, results)
+	if err != nil {
+		log.WarnContext(ctx, "could not check for monitor outage", "err", err)
+	}
+	if outage.Detected {
+		log.WarnContext(ctx, "monitor outage detected",
+			"failure_rate", outage.FailureRate,
+			"peer_rate", outage.PeerRate,
+			"monitor_rate", outage.MonitorRate,
+			"mode", outageSettings.Mode,
+		)
+		span.AddEvent("Monitor outage", otrace.WithAttributes(
+			attribute.Float64("failure_rate", outage.FailureRate),
+			attribute.String("mode", outageSettings.Mode),
+		))
+		srv.m.Outages.WithLabelValues(
+			monitor.TlsName.String,
+			monitor.IpVersion.MonitorsIpVersion.String(),
+			outageSettings.Mode,
+		).Inc()
+
+		switch outageSettings.Mode {
+		case outageModeDownweight:
+			sc.scorer = sc.scorer.WithTimeoutWeight(outageSettings.Weight)
+		default:
+			for i, status := range in.List {
+				if results[i] == apiv2.ResultStatus_RESULT_STATUS_ACCEPTED && status.NoResponse {
+					counters.Quarantine.Counter++
+					results[i] = apiv2.ResultStatus_RESULT_STATUS_QUARANTINED
+				}
+			}
+		}
+	}
+
 	// closure to have a function for the tracing span
-	rv, err := func() (bool, error) {
+	err = func() error {
 		ctx, span := tracing.Start(ctx, "processStatus")
 		defer span.End()
 
-		txErr := database.WithTransaction(ctx, srv.db, func(ctx context.Context, db ntpdb.QuerierTx) error {
-			for i, status := range in.List {
-
-				if in.Version > 2 {
-					ticketOk, err := srv.ValidateIPs(status.Ticket, monitor.ID, bidb, status.GetIP())
-					if err != nil || !ticketOk {
-						span.AddEvent("signature validation failed")
-						log.Error("signature validation failed", "test_ip", status.GetIP().String(), "err", err)
-						counters.Sig.Counter += len(in.List) - i
-						return twirp.NewError(twirp.InvalidArgument, "signature validation failed")
+		for retry := 0; ; retry++ {
+			// counted when the transaction is committed
+			txCounters := newSubmitCounters()
+			failed := -1
+
+			txErr := database.WithTransaction(ctx, srv.db, func(ctx context.Context, db ntpdb.QuerierTx) error {
+				for i, status := range in.List {
+					// quarantined timeouts are stored, but not scored
+					quarantined := results[i] == apiv2.ResultStatus_RESULT_STATUS_QUARANTINED
+					if results[i] != apiv2.ResultStatus_RESULT_STATUS_ACCEPTED && !quarantined {
+						continue
 					}
-				}
 
-				if !safeZeroOffset {
-					// client might have broken error handling for some
-					// network errors, so don't trust zero offset.
-					if status.Stratum == 0 && status.Offset.AsDuration() == 0 {
-						if status.Error == "" {
-							status.Offset = nil
-							status.Error = "untrusted zero offset"
-						}
+					err := srv.processStatus(ctx, db, monitor, sc, status, quarantined, features, txCounters)
+					if errors.Is(err, errUnknownServer) {
+						// nothing was written for the status yet
+						log.Warn("unknown server", "test_ip", status.GetIP().String())
+						counters.Unknown.Counter++
+						results[i] = apiv2.ResultStatus_RESULT_STATUS_UNKNOWN_SERVER
+						continue
+					}
+					if err != nil {
+						span.AddEvent("error processing status", otrace.WithAttributes(attribute.String("error", err.Error())))
+						log.Error("error processing status", "status", status, "err", err)
+						failed = i
+						return err
 					}
 				}
 
-				if err := srv.processStatus(ctx, db, monitor, status, counters); err != nil {
-					span.AddEvent("error processing status", otrace.WithAttributes(attribute.String("error", err.Error())))
-					log.Error("error processing status", "status", status, "err", err)
-					return twirp.InternalErrorWith(err)
+				batch := ntpdb.InsertMonitorBatchParams{
+					MonitorID: monitor.ID,
+					BatchID:   batchID.String(),
+					BatchTs:   batchTime,
+					Results:   encodeResultStatus(results),
 				}
+				monitorBatchClock(&batch, in.Clock, clock)
+				if err := db.InsertMonitorBatch(ctx, batch); err != nil {
+					return fmt.Errorf("recording batch: %w", err)
+				}
+
+				// batches older than this are rejected anyway
+				return db.DeleteMonitorBatches(ctx, ntpdb.DeleteMonitorBatchesParams{
+					MonitorID: monitor.ID,
+					BatchTs:   now.Add(-ntpdb.MaxBatchAge),
+				})
+			})
+
+			if txErr == nil {
+				counters.add(txCounters)
+				return nil
 			}
 
-			return nil
+			if failed < 0 || retry >= maxSubmitRetries {
+				return twirp.InternalErrorWith(txErr)
+			}
+
+			// roll back and process the batch again without
+			// the result that failed
+			counters.Internal.Counter++
+			results[failed] = apiv2.ResultStatus_RESULT_STATUS_INTERNAL_ERROR
+		}
+	}()
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// returnLeases clears the leases for the servers in the batch and
+// counts them as returned (or late) in monitor_lease_stats. Results
+// with a bad signature don't count.
+func (srv *Server) returnLeases(ctx context.Context, monitor *ntpdb.Monitor, list []*apiv2.ServerStatus, results []apiv2.ResultStatus) error {
+	ips := []string{}
+	for i, status := range list {
+		if results[i] == apiv2.ResultStatus_RESULT_STATUS_BAD_SIGNATURE {
+			continue
+		}
+		ips = append(ips, status.GetIP().String())
+	}
+	if len(ips) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+
+	return database.WithTransaction(ctx, srv.db, func(ctx context.Context, db ntpdb.QuerierTx) error {
+		leases, err := db.GetServerScoreLeases(ctx, ntpdb.GetServerScoreLeasesParams{
+			MonitorID: monitor.ID,
+			Ips:       ips,
 		})
+		if err != nil {
+			return err
+		}
+		if len(leases) == 0 {
+			return nil
+		}
 
-		if txErr != nil {
-			var twErr twirp.Error
-			if errors.As(txErr, &twErr) {
-				return false, twErr
+		stats := ntpdb.AddMonitorLeasesReturnedParams{MonitorID: monitor.ID}
+		for _, lease := range leases {
+			if now.After(lease.LeaseExpires.Time) {
+				stats.Late++
+			} else {
+				stats.Returned++
+			}
+			if err := db.ClearServerScoreLease(ctx, lease.ID); err != nil {
+				return fmt.Errorf("clearing lease: %w", err)
 			}
-			return false, twirp.InternalErrorWith(txErr)
 		}
-		return true, nil
-	}()
 
-	return rv, err
+		if err := db.AddMonitorLeasesReturned(ctx, stats); err != nil {
+			return fmt.Errorf("updating lease stats: %w", err)
+		}
+		return nil
+	})
+}
+
+// encodeResultStatus stores the result statuses of a batch as one
+// byte each
+func encodeResultStatus(results []apiv2.ResultStatus) []byte {
+	b := make([]byte, len(results))
+	for i, rs := range results {
+		b[i] = byte(rs)
+	}
+	return b
+}
+
+func decodeResultStatus(b []byte) []apiv2.ResultStatus {
+	results := make([]apiv2.ResultStatus, len(b))
+	for i, rs := range b {
+		results[i] = apiv2.ResultStatus(rs)
+	}
+	return results
 }
 
-func (srv *Server) processStatus(ctx context.Context, db ntpdb.QuerierTx, monitor *ntpdb.Monitor, status *apiv2.ServerStatus, counters *SubmitCounters) error {
+// processStatus scores the status and stores the result. The log
+// score keeps the step as measured, before the timeout weight.
+// Quarantined results are stored flagged as such without changing
+// the running score.
+func (srv *Server) processStatus(ctx context.Context, db ntpdb.QuerierTx, monitor *ntpdb.Monitor, sc scoring, status *apiv2.ServerStatus, quarantined bool, features submitFeatures, counters *SubmitCounters) error {
 	server, err := db.GetServerIP(ctx, status.GetIP().String())
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errUnknownServer
+		}
 		return err
 	}
 	serverScore, err := db.GetServerScore(ctx, ntpdb.GetServerScoreParams{
@@ -215,17 +500,92 @@ func (srv *Server) processStatus(ctx context.Context, db ntpdb.QuerierTx, monito
 		ServerID:  server.ID,
 	})
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errUnknownServer
+		}
 		return err
 	}
 
-	scorer := statusscore.NewScorer()
+	scorer := sc.scorer
+	if quarantined {
+		scorer = scorer.WithQuarantined()
+	}
 
 	score, err := scorer.Score(ctx, &server, status)
 	if err != nil {
 		return err
 	}
 
-	serverScore.ScoreRaw = (serverScore.ScoreRaw * 0.95) + score.Step
+	// results replayed from the agent's spool can be older than the
+	// running score; they are only stored in log_scores so the score
+	// (and score_ts) isn't moved back in time
+	late := serverScore.ScoreTs.Valid && score.Ts.Before(serverScore.ScoreTs.Time)
+
+	if !late && !quarantined {
+		if err := updateServerScore(ctx, db, sc, &server, &serverScore, score, status); err != nil {
+			return err
+		}
+	}
+
+	ls := ntpdb.InsertLogScoreParams{
+		ServerID:   server.ID,
+		MonitorID:  sql.NullInt32{Int32: int32(monitor.ID), Valid: true}, // todo: sqlc type
+		Ts:         score.Ts,
+		Step:       score.Step,
+		Offset:     score.Offset,
+		Rtt:        score.Rtt,
+		Score:      serverScore.ScoreRaw,
+		Attributes: score.Attributes,
+	}
+
+	res, err := db.InsertLogScore(ctx, ls)
+	if err != nil {
+		return err
+	}
+
+	if features.Packets && len(status.Responses) > 0 {
+		logScoreID, err := res.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("log score id: %w", err)
+		}
+		if err := insertPackets(ctx, db, uint64(logScoreID), status); err != nil {
+			return fmt.Errorf("inserting packets: %w", err)
+		}
+	}
+
+	if quarantined {
+		// counted as quarantined when the batch was checked
+		return nil
+	}
+
+	if status.NoResponse {
+		if err := queueTraceroute(ctx, db, monitor.ID, server.ID); err != nil {
+			return fmt.Errorf("queueing traceroute: %w", err)
+		}
+	}
+
+	// todo: have score give a category
+	switch {
+	case status.NoResponse:
+		counters.Timeout.Counter += 1
+	case ls.Step < 1:
+		counters.Offset.Counter += 1
+	default:
+		counters.Ok.Counter += 1
+	}
+
+	// todo:
+	//   if score is low and step == 1, also traceroute?
+	//      schedule new monitors?
+	//   if step < 0 and retesting isn't recent, mark server_scores for retesting?
+
+	return nil
+}
+
+// updateServerScore adds the score to the running score for the
+// monitor and updates the stratum of the server.
+func updateServerScore(ctx context.Context, db ntpdb.QuerierTx, sc scoring, server *ntpdb.Server, serverScore *ntpdb.ServerScore, score *score.Score, status *apiv2.ServerStatus) error {
+	serverScore.ScoreRaw = sc.decay.Apply(serverScore.ScoreRaw, serverScore.ScoreTs, score.Ts, score.ScoreStep)
 	if score.HasMaxScore {
 		serverScore.ScoreRaw = math.Min(serverScore.ScoreRaw, score.MaxScore)
 	}
@@ -258,42 +618,81 @@ func (srv *Server) processStatus(ctx context.Context, db ntpdb.QuerierTx, monito
 		return fmt.Errorf("updating server score: %w", err)
 	}
 
-	ls := ntpdb.InsertLogScoreParams{
-		ServerID:   server.ID,
-		MonitorID:  sql.NullInt32{Int32: int32(monitor.ID), Valid: true}, // todo: sqlc type
-		Ts:         score.Ts,
-		Step:       score.Step,
-		Offset:     score.Offset,
-		Rtt:        score.Rtt,
-		Score:      serverScore.ScoreRaw,
-		Attributes: score.Attributes,
-	}
+	return nil
+}
 
-	if _, err := db.InsertLogScore(ctx, ls); err != nil {
-		return err
-	}
+// statusScoreSettings returns the scoring policy from the
+// "statusscore" system setting. An invalid policy is logged and
+// the built-in policy is used instead.
+func (srv *Server) statusScoreSettings(ctx context.Context) statusscore.Settings {
+	log := logger.FromContext(ctx)
 
-	// todo: have score give a category
-	switch {
-	case ls.Step == -5:
-		counters.Timeout.Counter += 1
-	case ls.Step < 1:
-		counters.Offset.Counter += 1
-	default:
-		counters.Ok.Counter += 1
+	settings := statusscore.DefaultSettings()
+
+	settingsStr, err := srv.db.GetSystemSetting(ctx, "statusscore")
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.WarnContext(ctx, "could not fetch statusscore settings", "err", err)
+	}
+	if len(settingsStr) > 0 {
+		settings, err = statusscore.ParseSettings([]byte(settingsStr))
+		if err != nil {
+			log.ErrorContext(ctx, "invalid statusscore policy, using the built-in policy", "err", err)
+			settings = statusscore.DefaultSettings()
+		}
 	}
 
-	// todo:
-	//   if NoResponse == true OR score is low and step == 1:
-	//      mark for traceroute if it's not been done recently
-	//      maybe track why we traceroute'd last?
-	//      schedule new monitors?
-	//   if step < 0 and retesting isn't recent, mark server_scores for retesting?
+	return settings
+}
+
+// scoreDecay returns the score decay configured in the "scorer"
+// system setting (shared with the scorer job).
+func (srv *Server) scoreDecay(ctx context.Context) score.Decay {
+	log := logger.FromContext(ctx)
+
+	var settings score.DecaySettings
+
+	settingsStr, err := srv.db.GetSystemSetting(ctx, "scorer")
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.WarnContext(ctx, "could not fetch scorer settings", "err", err)
+	}
+	if len(settingsStr) > 0 {
+		if err := json.Unmarshal([]byte(settingsStr), &settings); err != nil {
+			log.WarnContext(ctx, "could not unmarshal scorer settings", "err", err)
+		}
+	}
 
-	// new schemas:
-	//    traceroute_queue
-	//       server_id, monitor_id, last_traceroute
-	//
+	return settings.Decay()
+}
 
+// insertPackets stores the raw NTP packets the monitor sent along
+// with the status, so disputed scores can be investigated later.
+func insertPackets(ctx context.Context, db ntpdb.QuerierTx, logScoreID uint64, status *apiv2.ServerStatus) error {
+	for i, pkt := range status.Responses {
+		if i > math.MaxUint8 {
+			break
+		}
+		p := ntpdb.InsertLogScorePacketParams{
+			LogScoreID:   logScoreID,
+			Sample:       uint8(i),
+			Selected:     int32(i) == status.SelectedResponse,
+			QueryData:    pkt.QueryData,
+			ResponseData: pkt.Data,
+		}
+		if ip, ok := netip.AddrFromSlice(pkt.SourceIpBytes); ok {
+			p.SourceIp = sql.NullString{String: ip.String(), Valid: true}
+		}
+		if ip, ok := netip.AddrFromSlice(pkt.DestinationIpBytes); ok {
+			p.DestinationIp = sql.NullString{String: ip.String(), Valid: true}
+		}
+		if pkt.T1 != nil {
+			p.T1 = sql.NullTime{Time: pkt.T1.AsTime(), Valid: true}
+		}
+		if pkt.T4 != nil {
+			p.T4 = sql.NullTime{Time: pkt.T4.AsTime(), Valid: true}
+		}
+		if err := db.InsertLogScorePacket(ctx, p); err != nil {
+			return err
+		}
+	}
 	return nil
 }
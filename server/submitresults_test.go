@@ -0,0 +1,274 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"fmt"
+	"net/netip"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/twitchtv/twirp"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	apiv2 "go.ntppool.org/monitor/gen/monitor/v2"
+	"go.ntppool.org/monitor/ntpdb"
+	sctx "go.ntppool.org/monitor/server/context"
+	"go.ntppool.org/monitor/server/metrics"
+)
+
+// newSubmitTest returns a server with the fake database and a
+// context for an active monitor with the servers assigned
+func newSubmitTest(t *testing.T, serverIPs ...string) (*Server, *fakeDB, context.Context, *ntpdb.Monitor) {
+	t.Helper()
+
+	db := newFakeDB()
+	monitor := &ntpdb.Monitor{
+		ID:            1,
+		Status:        ntpdb.MonitorsStatusActive,
+		ClientVersion: "v4.2.0",
+	}
+	for i, ip := range serverIPs {
+		id := uint32(10 + i)
+		db.addServer(id, ip)
+		db.addServerScore(monitor.ID, id)
+	}
+
+	srv := &Server{db: db, m: metrics.New(prometheus.NewRegistry())}
+	ctx := context.WithValue(context.Background(), sctx.MonitorKey, monitor)
+
+	return srv, db, ctx, monitor
+}
+
+// testBatch returns a batch from ts with a result for each IP; the
+// results are offsets of a millisecond unless noResponse is set
+func testBatch(ts time.Time, noResponse bool, ips ...string) SubmitResultsParam {
+	batchID := ulid.MustNew(ulid.Timestamp(ts), rand.Reader)
+	bid, _ := batchID.MarshalText()
+
+	in := SubmitResultsParam{Version: 2, BatchId: bid}
+	for _, ip := range ips {
+		status := &apiv2.ServerStatus{
+			Ts:         timestamppb.New(ts),
+			NoResponse: noResponse,
+		}
+		if !noResponse {
+			status.Stratum = 2
+			status.Offset = durationpb.New(time.Millisecond)
+			status.Rtt = durationpb.New(20 * time.Millisecond)
+		}
+		addr := netip.MustParseAddr(ip)
+		status.SetIP(&addr)
+		in.List = append(in.List, status)
+	}
+	return in
+}
+
+func TestSubmitResultsLateBatch(t *testing.T) {
+	srv, db, ctx, monitor := newSubmitTest(t, "198.51.100.10")
+
+	now := time.Now()
+
+	results, err := srv.SubmitResults(ctx, testBatch(now.Add(-time.Minute), false, "198.51.100.10"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0] != apiv2.ResultStatus_RESULT_STATUS_ACCEPTED {
+		t.Fatalf("result status %s", results[0])
+	}
+
+	current := db.serverScore(monitor.ID, 10)
+	if !current.ScoreTs.Valid || current.ScoreRaw <= 0 {
+		t.Fatalf("server score not updated: %+v", current)
+	}
+	monitor.LastSubmit = db.state.lastSubmit
+
+	// a batch replayed from the agent's spool after the newer one
+	results, err = srv.SubmitResults(ctx, testBatch(now.Add(-time.Hour), true, "198.51.100.10"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0] != apiv2.ResultStatus_RESULT_STATUS_ACCEPTED {
+		t.Fatalf("late result status %s", results[0])
+	}
+
+	// the late result is stored, but doesn't change the running score
+	if len(db.state.logScores) != 2 {
+		t.Fatalf("got %d log scores, want 2", len(db.state.logScores))
+	}
+	late := db.state.logScores[1]
+	if !late.Ts.Before(current.ScoreTs.Time) {
+		t.Errorf("late log score at %s, after %s", late.Ts, current.ScoreTs.Time)
+	}
+	if late.Step >= 0 {
+		t.Errorf("late log score step %f, want the timeout step", late.Step)
+	}
+	if late.Score != current.ScoreRaw {
+		t.Errorf("late log score %f, want the running score %f", late.Score, current.ScoreRaw)
+	}
+
+	ss := db.serverScore(monitor.ID, 10)
+	if !ss.ScoreTs.Time.Equal(current.ScoreTs.Time) || ss.ScoreRaw != current.ScoreRaw {
+		t.Errorf("late batch changed the server score from %s/%f to %s/%f",
+			current.ScoreTs.Time, current.ScoreRaw, ss.ScoreTs.Time, ss.ScoreRaw)
+	}
+	if !db.state.lastSubmit.Time.Equal(monitor.LastSubmit.Time) {
+		t.Errorf("last submit moved from %s to %s", monitor.LastSubmit.Time, db.state.lastSubmit.Time)
+	}
+
+	// the next live result decays from the newer score
+	results, err = srv.SubmitResults(ctx, testBatch(now, false, "198.51.100.10"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0] != apiv2.ResultStatus_RESULT_STATUS_ACCEPTED {
+		t.Fatalf("result status %s", results[0])
+	}
+	ss = db.serverScore(monitor.ID, 10)
+	if ss.ScoreRaw <= current.ScoreRaw {
+		t.Errorf("score %f after a good result, was %f", ss.ScoreRaw, current.ScoreRaw)
+	}
+}
+
+func TestSubmitResultsStatus(t *testing.T) {
+	ips := []string{"198.51.100.10", "198.51.100.11", "198.51.100.12"}
+	srv, db, ctx, monitor := newSubmitTest(t, ips...)
+
+	db.failIPs = map[string]bool{"198.51.100.11": true}
+
+	// 198.51.100.20 isn't assigned to the monitor
+	in := testBatch(time.Now(), false, append(ips, "198.51.100.20")...)
+	results, err := srv.SubmitResults(ctx, in, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []apiv2.ResultStatus{
+		apiv2.ResultStatus_RESULT_STATUS_ACCEPTED,
+		apiv2.ResultStatus_RESULT_STATUS_INTERNAL_ERROR,
+		apiv2.ResultStatus_RESULT_STATUS_ACCEPTED,
+		apiv2.ResultStatus_RESULT_STATUS_UNKNOWN_SERVER,
+	}
+	if !slices.Equal(results, want) {
+		t.Fatalf("got results %v, want %v", results, want)
+	}
+
+	// the accepted results are stored once, the attempt with the
+	// failed result was rolled back
+	if len(db.state.logScores) != 2 {
+		t.Fatalf("got %d log scores, want 2", len(db.state.logScores))
+	}
+	for i, id := range []uint32{10, 12} {
+		if ls := db.state.logScores[i]; ls.ServerID != id {
+			t.Errorf("log score %d for server %d, want %d", i, ls.ServerID, id)
+		}
+	}
+	if ss := db.serverScore(monitor.ID, 11); ss.ScoreTs.Valid {
+		t.Errorf("failed result updated the server score: %+v", ss)
+	}
+
+	// a resubmitted batch gets the same statuses without being
+	// scored again
+	db.failIPs = nil
+	results, err = srv.SubmitResults(ctx, in, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(results, want) {
+		t.Errorf("resubmitted batch got results %v, want %v", results, want)
+	}
+	if len(db.state.logScores) != 2 {
+		t.Errorf("resubmitted batch was scored again")
+	}
+}
+
+func TestSubmitResultsRetryLimit(t *testing.T) {
+	ips := []string{"198.51.100.10"}
+	failIPs := map[string]bool{}
+	for i := range maxSubmitRetries + 1 {
+		ip := fmt.Sprintf("198.51.100.%d", 20+i)
+		ips = append(ips, ip)
+		failIPs[ip] = true
+	}
+
+	srv, db, ctx, _ := newSubmitTest(t, ips...)
+	db.failIPs = failIPs
+
+	// after maxSubmitRetries failed results the batch fails
+	_, err := srv.SubmitResults(ctx, testBatch(time.Now(), false, ips...), "")
+	if err == nil {
+		t.Fatal("batch with too many failed results accepted")
+	}
+	if terr, ok := err.(twirp.Error); !ok || terr.Code() != twirp.Internal {
+		t.Errorf("got error %v, want an internal error", err)
+	}
+	if len(db.state.logScores) != 0 || len(db.state.batches) != 0 {
+		t.Errorf("failed batch stored %d log scores and %d batches", len(db.state.logScores), len(db.state.batches))
+	}
+}
+
+func TestSubmitResultsLeases(t *testing.T) {
+	ips := []string{"198.51.100.10", "198.51.100.11", "198.51.100.12", "198.51.100.13"}
+	srv, db, ctx, monitor := newSubmitTest(t, ips...)
+
+	now := time.Now()
+	setLeases := func(expires ...time.Time) {
+		for i, exp := range expires {
+			id := db.serverScore(monitor.ID, uint32(10+i)).ID
+			db.updateServerScore(id, func(ss *ntpdb.ServerScore) {
+				ss.LeaseExpires = sql.NullTime{Time: exp, Valid: true}
+			})
+		}
+	}
+	checkLeases := func(returned, late uint32) {
+		t.Helper()
+		for i := range ips {
+			if ss := db.serverScore(monitor.ID, uint32(10+i)); ss.LeaseExpires.Valid {
+				t.Errorf("lease for server %d not cleared", ss.ServerID)
+			}
+		}
+		if db.state.leaseStats.Returned != returned || db.state.leaseStats.Late != late {
+			t.Errorf("leases returned %d late %d, want %d and %d",
+				db.state.leaseStats.Returned, db.state.leaseStats.Late, returned, late)
+		}
+	}
+
+	// quarantined results (other monitors got responses) still
+	// return the work
+	setLeases(now.Add(time.Minute), now.Add(time.Minute), now.Add(time.Minute), now.Add(-time.Minute))
+	db.peerCounts = ntpdb.GetPeerResponseCountsRow{Total: 20, Responses: 20}
+
+	results, err := srv.SubmitResults(ctx, testBatch(now, true, ips...), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, rs := range results {
+		if rs != apiv2.ResultStatus_RESULT_STATUS_QUARANTINED {
+			t.Fatalf("result %d status %s, want quarantined", i, rs)
+		}
+	}
+	checkLeases(3, 1)
+
+	// so do results not scored because of the monitor's clock
+	db.state.settings["clockquality"] = `{"mode": "reject"}`
+	srv.settings.expires = time.Time{}
+	setLeases(now.Add(time.Minute), now.Add(time.Minute))
+
+	in := testBatch(now.Add(time.Second), false, ips[:2]...)
+	in.Clock = &apiv2.ClockQuality{Offset: durationpb.New(50 * time.Millisecond)}
+	results, err = srv.SubmitResults(ctx, in, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, rs := range results {
+		if rs != apiv2.ResultStatus_RESULT_STATUS_CLOCK_UNCERTAIN {
+			t.Fatalf("result %d status %s, want clock uncertain", i, rs)
+		}
+	}
+	checkLeases(5, 1)
+}
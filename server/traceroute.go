@@ -0,0 +1,186 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/twitchtv/twirp"
+	"go.opentelemetry.io/otel/attribute"
+	otrace "go.opentelemetry.io/otel/trace"
+
+	"go.ntppool.org/common/database"
+	"go.ntppool.org/common/logger"
+	"go.ntppool.org/common/version"
+	apiv2 "go.ntppool.org/monitor/gen/monitor/v2"
+	"go.ntppool.org/monitor/ntpdb"
+)
+
+const (
+	// tracerouteInterval is the minimum time between traceroutes
+	// from a monitor to the same server
+	tracerouteInterval = 6 * time.Hour
+
+	// tracerouteLease is how long a traceroute sent to a monitor has
+	// to come back before it's sent again
+	tracerouteLease = 30 * time.Minute
+
+	// maxTraceroutesPerBatch is how many traceroutes are sent to a
+	// monitor with each GetServers request
+	maxTraceroutesPerBatch = 2
+
+	// maxTracerouteOutput is the largest traceroute output accepted
+	maxTracerouteOutput = 256 * 1024
+
+	// clients before this version didn't submit the traceroute results
+	tracerouteMinVersion = "v4.2.0"
+)
+
+// queueTraceroute schedules a traceroute from the monitor to the
+// server, unless one was done recently.
+func queueTraceroute(ctx context.Context, db ntpdb.QuerierTx, monitorID, serverID uint32) error {
+	return db.QueueTraceroute(ctx, ntpdb.QueueTracerouteParams{
+		ServerID:  serverID,
+		MonitorID: monitorID,
+		LastTracerouteBefore: sql.NullTime{
+			Time:  time.Now().Add(-tracerouteInterval),
+			Valid: true,
+		},
+	})
+}
+
+// getTraceroutes returns the servers the monitor should traceroute
+// and marks them as sent.
+func getTraceroutes(ctx context.Context, db ntpdb.QuerierTx, monitor *ntpdb.Monitor) ([]ntpdb.Server, error) {
+	clientVersion := monitor.ClientVersion
+	if idx := strings.Index(clientVersion, "/"); idx >= 0 {
+		clientVersion = clientVersion[0:idx]
+	}
+	if !version.CheckVersion(clientVersion, tracerouteMinVersion) {
+		return nil, nil
+	}
+
+	now := time.Now()
+
+	servers, err := db.GetTracerouteQueue(ctx, ntpdb.GetTracerouteQueueParams{
+		MonitorID:  monitor.ID,
+		SentBefore: sql.NullTime{Time: now.Add(-tracerouteLease), Valid: true},
+		Limit:      maxTraceroutesPerBatch,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(servers) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]uint32, len(servers))
+	for i, s := range servers {
+		ids[i] = s.ID
+	}
+
+	err = db.UpdateTracerouteQueueSent(ctx, ntpdb.UpdateTracerouteQueueSentParams{
+		SentTs:    sql.NullTime{Time: now, Valid: true},
+		MonitorID: monitor.ID,
+		ServerIds: ids,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return servers, nil
+}
+
+// SubmitTraceroute stores the traceroute output from a monitor
+func (srv *Server) SubmitTraceroute(ctx context.Context, in *apiv2.SubmitTracerouteRequest) (bool, error) {
+	span := otrace.SpanFromContext(ctx)
+	log := logger.FromContext(ctx)
+
+	monitor, _, ctx, err := srv.getMonitor(ctx, in.MonId)
+	if err != nil {
+		log.Error("get monitor error", "err", err)
+		return false, err
+	}
+
+	log = log.With("mon_id", monitor.ID)
+
+	if !monitor.IsLive() {
+		return false, twirp.PermissionDenied.Error("monitor not active")
+	}
+
+	if l := len(in.IpBytes); l != 4 && l != 16 {
+		return false, twirp.InvalidArgumentError("IpBytes", "invalid IP")
+	}
+	ip := in.GetIP()
+
+	span.SetAttributes(attribute.String("ip", ip.String()))
+
+	if len(in.Output) > maxTracerouteOutput {
+		return false, twirp.InvalidArgumentError("Output", "traceroute output too large")
+	}
+
+	ticketOk, err := srv.ValidateIPs(in.Ticket, monitor.ID, in.BatchId, ip)
+	if err != nil || !ticketOk {
+		log.Error("traceroute signature validation failed", "test_ip", ip.String(), "err", err)
+		return false, twirp.NewError(twirp.InvalidArgument, "signature validation failed")
+	}
+
+	ts := time.Now()
+	if in.Ts != nil {
+		ts = in.Ts.AsTime()
+	}
+
+	err = database.WithTransaction(ctx, srv.db, func(ctx context.Context, db ntpdb.QuerierTx) error {
+		server, err := db.GetServerIP(ctx, ip.String())
+		if err != nil {
+			return err
+		}
+
+		tr := ntpdb.InsertTracerouteParams{
+			ServerID:  server.ID,
+			MonitorID: monitor.ID,
+			Ts:        ts,
+		}
+		if len(in.Output) > 0 {
+			tr.Output = sql.NullString{String: string(in.Output), Valid: true}
+		}
+		if len(in.Error) > 0 {
+			tr.Error = sql.NullString{String: truncateError(in.Error, 255), Valid: true}
+		}
+
+		if err := db.InsertTraceroute(ctx, tr); err != nil {
+			return fmt.Errorf("inserting traceroute: %w", err)
+		}
+
+		return db.UpdateTracerouteQueueDone(ctx, ntpdb.UpdateTracerouteQueueDoneParams{
+			LastTraceroute: sql.NullTime{Time: ts, Valid: true},
+			MonitorID:      monitor.ID,
+			ServerID:       server.ID,
+		})
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, twirp.NotFoundError("unknown server")
+		}
+		log.ErrorContext(ctx, "could not store traceroute", "err", err)
+		return false, twirp.InternalErrorWith(err)
+	}
+
+	return true, nil
+}
+
+// truncateError shortens s to at most n bytes without splitting a
+// UTF-8 character.
+func truncateError(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,180 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/twitchtv/twirp"
+
+	apiv2 "go.ntppool.org/monitor/gen/monitor/v2"
+	"go.ntppool.org/monitor/ntpdb"
+	sctx "go.ntppool.org/monitor/server/context"
+)
+
+func TestTracerouteQueue(t *testing.T) {
+	ctx := context.Background()
+	db := newFakeDB()
+	db.addServer(10, "198.51.100.10")
+	db.addServer(11, "198.51.100.11")
+	db.addServer(12, "198.51.100.12")
+
+	monitor := &ntpdb.Monitor{ID: 1, ClientVersion: "v4.2.0/abc123"}
+
+	for _, id := range []uint32{10, 11, 12} {
+		if err := queueTraceroute(ctx, db, monitor.ID, id); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Millisecond) // keep the queue order
+	}
+	// queueing again doesn't move the server to the back
+	if err := queueTraceroute(ctx, db, monitor.ID, 10); err != nil {
+		t.Fatal(err)
+	}
+
+	servers, err := getTraceroutes(ctx, db, monitor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(servers) != maxTraceroutesPerBatch || servers[0].ID != 10 || servers[1].ID != 11 {
+		t.Fatalf("got traceroutes %+v, want servers 10 and 11", servers)
+	}
+
+	// sent traceroutes aren't sent again until the lease expires
+	servers, err = getTraceroutes(ctx, db, monitor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(servers) != 1 || servers[0].ID != 12 {
+		t.Fatalf("got traceroutes %+v, want server 12", servers)
+	}
+	servers, err = getTraceroutes(ctx, db, monitor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(servers) != 0 {
+		t.Fatalf("got traceroutes %+v, want none", servers)
+	}
+
+	key := [2]uint32{monitor.ID, 11}
+	tq := db.state.traceQueue[key]
+	tq.sentTs.Time = time.Now().Add(-tracerouteLease - time.Minute)
+	db.state.traceQueue[key] = tq
+	servers, err = getTraceroutes(ctx, db, monitor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(servers) != 1 || servers[0].ID != 11 {
+		t.Fatalf("got traceroutes %+v, want server 11 after the lease", servers)
+	}
+
+	// a completed traceroute isn't queued again within the interval
+	if err := db.UpdateTracerouteQueueDone(ctx, ntpdb.UpdateTracerouteQueueDoneParams{
+		LastTraceroute: sql.NullTime{Time: time.Now(), Valid: true},
+		MonitorID:      monitor.ID,
+		ServerID:       10,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := queueTraceroute(ctx, db, monitor.ID, 10); err != nil {
+		t.Fatal(err)
+	}
+	if tq := db.state.traceQueue[[2]uint32{monitor.ID, 10}]; tq.queueTs.Valid {
+		t.Errorf("traceroute queued again within %s", tracerouteInterval)
+	}
+}
+
+func TestTracerouteVersionGate(t *testing.T) {
+	ctx := context.Background()
+	db := newFakeDB()
+	db.addServer(10, "198.51.100.10")
+
+	if err := queueTraceroute(ctx, db, 1, 10); err != nil {
+		t.Fatal(err)
+	}
+
+	// older clients don't submit traceroutes, so don't send them any
+	old := &ntpdb.Monitor{ID: 1, ClientVersion: "v4.1.5/abc123"}
+	servers, err := getTraceroutes(ctx, db, old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(servers) != 0 {
+		t.Fatalf("got traceroutes %+v for %s", servers, old.ClientVersion)
+	}
+	if tq := db.state.traceQueue[[2]uint32{1, 10}]; tq.sentTs.Valid {
+		t.Errorf("traceroute marked as sent to %s", old.ClientVersion)
+	}
+
+	current := &ntpdb.Monitor{ID: 1, ClientVersion: tracerouteMinVersion}
+	servers, err = getTraceroutes(ctx, db, current)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(servers) != 1 {
+		t.Fatalf("got %d traceroutes for %s, want 1", len(servers), current.ClientVersion)
+	}
+}
+
+func TestSubmitTracerouteInvalid(t *testing.T) {
+	srv := &Server{db: newFakeDB()}
+
+	monitor := &ntpdb.Monitor{ID: 1, Status: ntpdb.MonitorsStatusActive}
+	ctx := context.WithValue(context.Background(), sctx.MonitorKey, monitor)
+
+	valid := &apiv2.SubmitTracerouteRequest{
+		IpBytes: []byte{198, 51, 100, 10},
+		Output:  []byte("1 192.0.2.1"),
+	}
+
+	tests := []struct {
+		name    string
+		monitor ntpdb.MonitorsStatus
+		req     *apiv2.SubmitTracerouteRequest
+		code    twirp.ErrorCode
+	}{
+		{"paused monitor", ntpdb.MonitorsStatusPaused, valid, twirp.PermissionDenied},
+		{"invalid ip", ntpdb.MonitorsStatusActive, &apiv2.SubmitTracerouteRequest{IpBytes: []byte{1, 2, 3}}, twirp.InvalidArgument},
+		{"large output", ntpdb.MonitorsStatusActive, &apiv2.SubmitTracerouteRequest{
+			IpBytes: valid.IpBytes,
+			Output:  make([]byte, maxTracerouteOutput+1),
+		}, twirp.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			monitor.Status = tt.monitor
+			ok, err := srv.SubmitTraceroute(ctx, tt.req)
+			if ok || err == nil {
+				t.Fatalf("traceroute accepted")
+			}
+			if terr, isTwirp := err.(twirp.Error); !isTwirp || terr.Code() != tt.code {
+				t.Errorf("got error %v, want %s", err, tt.code)
+			}
+		})
+	}
+}
+
+func TestTruncateError(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"short", 255, "short"},
+		{"abcdef", 4, "abcd"},
+		// "é" is two bytes; it's dropped rather than split
+		{"abcé", 4, "abc"},
+		{"ab€", 4, "ab"},
+		{"ab€", 5, "ab€"},
+	}
+
+	for _, tt := range tests {
+		got := truncateError(tt.s, tt.n)
+		if got != tt.want || !utf8.ValidString(got) {
+			t.Errorf("truncateError(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
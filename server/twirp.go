@@ -111,7 +111,7 @@ func (s *TwServer) SubmitResults(ctx context.Context, in *pb.ServerStatusList) (
 			NoResponse: e.NoResponse,
 		})
 	}
-	ok, err := s.srv.SubmitResults(ctx, p, "")
-	resp := pb.ServerStatusResult{Ok: ok}
+	_, err := s.srv.SubmitResults(ctx, p, "")
+	resp := pb.ServerStatusResult{Ok: err == nil}
 	return &resp, err
 }